@@ -3,16 +3,23 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/jirfag/go-queryset/queryset"
 )
 
 func main() {
-	inFile := flag.String("in", "models.go", "path to input file")
-	outFile := flag.String("out", "autogenerated_{in}", "path to output file")
+	inFile := flag.String("in", "models.go", "path to input file or directory of package")
+	outFile := flag.String("out", "autogenerated_{in}",
+		"path to output file, autogenerated_querysets.go in input directory by default")
 	flag.Parse()
 
+	if fi, err := os.Stat(*inFile); err == nil && fi.IsDir() {
+		*outFile = strings.Replace(*outFile, "autogenerated_{in}",
+			filepath.Join(*inFile, "autogenerated_querysets.go"), 1)
+	}
 	*outFile = strings.Replace(*outFile, "{in}", *inFile, 1)
 	if err := queryset.GenerateQuerySets(*inFile, *outFile); err != nil {
 		log.Fatalf("can't generate query sets: %s", err)
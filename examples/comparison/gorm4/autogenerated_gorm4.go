@@ -1,19 +1,24 @@
 package gorm4
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/jirfag/go-queryset/queryset/base"
 )
 
-// ===== BEGIN of all query sets
-
 // ===== BEGIN of query set UserQuerySet
 
 // UserQuerySet is an queryset type for User
 type UserQuerySet struct {
-	db *gorm.DB
+	db       *gorm.DB
+	deferred []func(UserQuerySet) UserQuerySet
 }
 
 // NewUserQuerySet constructs new UserQuerySet
@@ -24,67 +29,756 @@ func NewUserQuerySet(db *gorm.DB) UserQuerySet {
 }
 
 func (qs UserQuerySet) w(db *gorm.DB) UserQuerySet {
-	return NewUserQuerySet(db)
+	qs.db = db
+	return qs
+}
+
+// prepare applies deferred transformations of query set
+func (qs UserQuerySet) prepare() UserQuerySet {
+	for len(qs.deferred) != 0 {
+		deferred := qs.deferred
+		qs.deferred = nil
+		for _, fn := range deferred {
+			qs = fn(qs)
+		}
+	}
+	return qs
+}
+
+// scopedDB returns db of prepared query set with implicit conditions
+func (qs UserQuerySet) scopedDB() *gorm.DB {
+	qs = qs.prepare()
+	return qs.db
+}
+
+// exec executes terminal operation op by f on prepared query set db
+// in span of tracer set by WithTracer
+func (qs UserQuerySet) exec(op string, f func(db *gorm.DB) error) error {
+	db := qs.scopedDB()
+	db, span := base.StartSpan(db, "UserQuerySet."+op)
+	defer span.End()
+	err := base.CheckContext(db)
+	if err == nil {
+		err = base.Retry(db, func() error {
+			return f(db)
+		})
+	}
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+	return nil
+}
+
+// UserConditions are values of User fields
+// for equality conditions of WhereMap
+type UserConditions map[userDBSchemaField]interface{}
+
+// RankedUser is a User with its rank selected by AllRanked
+type RankedUser struct {
+	User
+	Rank int
+}
+
+// UserRangeFilter is a filter by ranges of User fields
+// values: [Min, Max]. Nil bounds aren't applied.
+type UserRangeFilter struct {
+	IDMin          *uint
+	IDMax          *uint
+	CreatedAtMin   *time.Time
+	CreatedAtMax   *time.Time
+	UpdatedAtMin   *time.Time
+	UpdatedAtMax   *time.Time
+	RatingMin      *int
+	RatingMax      *int
+	RatingMarksMin *int
+	RatingMarksMax *int
+}
+
+// UserFilterInput is a GraphQL-style filter by User fields:
+// nil fields and operators aren't applied
+type UserFilterInput struct {
+	ID          *UserIDFilter
+	CreatedAt   *UserCreatedAtFilter
+	UpdatedAt   *UserUpdatedAtFilter
+	Rating      *UserRatingFilter
+	RatingMarks *UserRatingMarksFilter
+}
+
+// UserIDFilter is a set of operators of UserFilterInput.ID
+type UserIDFilter struct {
+	Eq   *uint
+	Ne   *uint
+	In   []uint
+	Gt   *uint
+	Gte  *uint
+	Lt   *uint
+	Lte  *uint
+	Like *string
+}
+
+// UserCreatedAtFilter is a set of operators of UserFilterInput.CreatedAt
+type UserCreatedAtFilter struct {
+	Eq   *time.Time
+	Ne   *time.Time
+	In   []time.Time
+	Gt   *time.Time
+	Gte  *time.Time
+	Lt   *time.Time
+	Lte  *time.Time
+	Like *string
+}
+
+// UserUpdatedAtFilter is a set of operators of UserFilterInput.UpdatedAt
+type UserUpdatedAtFilter struct {
+	Eq   *time.Time
+	Ne   *time.Time
+	In   []time.Time
+	Gt   *time.Time
+	Gte  *time.Time
+	Lt   *time.Time
+	Lte  *time.Time
+	Like *string
+}
+
+// UserRatingFilter is a set of operators of UserFilterInput.Rating
+type UserRatingFilter struct {
+	Eq   *int
+	Ne   *int
+	In   []int
+	Gt   *int
+	Gte  *int
+	Lt   *int
+	Lte  *int
+	Like *string
+}
+
+// UserRatingMarksFilter is a set of operators of UserFilterInput.RatingMarks
+type UserRatingMarksFilter struct {
+	Eq   *int
+	Ne   *int
+	In   []int
+	Gt   *int
+	Gte  *int
+	Lt   *int
+	Lte  *int
+	Like *string
 }
 
+// ===== END of query set UserQuerySet
+
+// ===== BEGIN of User modifiers
+
+type userDBSchemaField string
+
+// UserDBSchema stores db field names of User
+var UserDBSchema = struct {
+	ID          userDBSchemaField
+	CreatedAt   userDBSchemaField
+	UpdatedAt   userDBSchemaField
+	DeletedAt   userDBSchemaField
+	Rating      userDBSchemaField
+	RatingMarks userDBSchemaField
+}{
+
+	ID:          userDBSchemaField("id"),
+	CreatedAt:   userDBSchemaField("created_at"),
+	UpdatedAt:   userDBSchemaField("updated_at"),
+	DeletedAt:   userDBSchemaField("deleted_at"),
+	Rating:      userDBSchemaField("rating"),
+	RatingMarks: userDBSchemaField("rating_marks"),
+}
+
+// Update updates User fields by primary key
+func (o *User) Update(db *gorm.DB, fields ...userDBSchemaField) error {
+	if err := base.CheckContext(db); err != nil {
+		return err
+	}
+	if err := base.CheckPrimaryKey(db, o); err != nil {
+		return err
+	}
+	dbNameToFieldName := map[string]interface{}{
+		"id":           o.ID,
+		"created_at":   o.CreatedAt,
+		"updated_at":   o.UpdatedAt,
+		"deleted_at":   o.DeletedAt,
+		"rating":       o.Rating,
+		"rating_marks": o.RatingMarks,
+	}
+	u := map[string]interface{}{}
+	for _, f := range fields {
+		fs := string(f)
+		u[fs] = dbNameToFieldName[fs]
+	}
+	if err := base.Primary(db).Model(o).Updates(u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return err
+		}
+
+		return fmt.Errorf("can't update User %v fields %v: %s",
+			o, fields, err)
+	}
+
+	return nil
+}
+
+// UserUpdater is an User updates manager
+type UserUpdater struct {
+	fields map[string]interface{}
+	db     *gorm.DB
+}
+
+// NewUserUpdater creates new User updater
+func NewUserUpdater(db *gorm.DB) UserUpdater {
+	return UserUpdater{
+		fields: map[string]interface{}{},
+		db:     db.Model(&User{}),
+	}
+}
+
+// ===== END of User modifiers
+
 // All is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) All(ret *[]User) error {
-	return qs.db.Find(ret).Error
+	return qs.exec("All", func(db *gorm.DB) error {
+		return db.Find(ret).Error
+	})
+}
+
+// AllCached is like All, but results are loaded from cache by key.
+// On cache miss results are selected and stored in cache for ttl
+func (qs UserQuerySet) AllCached(cache base.Cache, key string, ttl time.Duration, ret *[]User) error {
+	return base.Cached(cache, key, ttl, ret, func() error {
+		return qs.All(ret)
+	})
+}
+
+// AllIndexedBy selects all matching records into map pointed by dest
+// indexed by field, e.g. *map[string]User for string field. Records with
+// duplicate values of field overwrite previous ones: the last one wins.
+func (qs UserQuerySet) AllIndexedBy(field userDBSchemaField, dest interface{}) error {
+	keyFields := map[string]string{
+		"id":           "ID",
+		"rating":       "Rating",
+		"rating_marks": "RatingMarks",
+	}
+	keyField, ok := keyFields[string(field)]
+	if !ok {
+		return fmt.Errorf("can't index User by field %q: it can't be map key", field)
+	}
+
+	var ret []User
+	if err := base.CheckIndex(ret, keyField, dest); err != nil {
+		return err
+	}
+	if err := qs.All(&ret); err != nil {
+		return err
+	}
+
+	base.Index(ret, keyField, dest)
+	return nil
+}
+
+// AllInto selects only columns of fields of matching records and scans
+// them into slice of projection structs pointed by dest, e.g. *[]UserListItem
+func (qs UserQuerySet) AllInto(dest interface{}, fields ...userDBSchemaField) error {
+	columns := []string{"id", "created_at", "updated_at", "deleted_at", "rating", "rating_marks"}
+	selected := make([]string, 0, len(fields))
+	for _, f := range fields {
+		selected = append(selected, string(f))
+	}
+	return qs.exec("AllInto", func(db *gorm.DB) error {
+		return base.SelectInto(db.Model(&User{}), dest, columns, selected)
+	})
+}
+
+// AllRanked returns matching records with their ranks, e.g. for
+// leaderboards: row numbers by descending values of orderField are selected
+// by ROW_NUMBER() OVER (ORDER BY orderField DESC), records are ordered
+// by them. It's supported by dialects with window functions.
+func (qs UserQuerySet) AllRanked(orderField userDBSchemaField) (ret []RankedUser, err error) {
+	err = qs.exec("AllRanked", func(db *gorm.DB) error {
+		err = base.AllRanked(db.Model(&User{}), string(orderField), &ret)
+		return err
+	})
+	return
+}
+
+// AllWithHasMore selects up to size matching records into ret and reports
+// whether there are more of them: size + 1 records are selected for it
+// instead of counting, e.g. for infinite scroll
+func (qs UserQuerySet) AllWithHasMore(size int, ret *[]User) (hasMore bool, err error) {
+	if size < 0 {
+		return false, fmt.Errorf("invalid page size %d", size)
+	}
+
+	if err = qs.Limit(size + 1).All(ret); err != nil {
+		return false, err
+	}
+
+	if len(*ret) > size {
+		*ret = (*ret)[:size]
+		return true, nil
+	}
+	return false, nil
+}
+
+// AllowGlobalUpdate allows bulk updates (e.g. SetFieldForAll) of query set
+// without conditions: all records are updated
+func (qs UserQuerySet) AllowGlobalUpdate() UserQuerySet {
+	return qs.w(base.AllowGlobalUpdate(qs.db))
+}
+
+// And adds conditions added by fn as one group: (...), e.g.
+// to nest groups of Or. fn must add only conditions to passed empty query set
+func (qs UserQuerySet) And(fn func(qs UserQuerySet) UserQuerySet) UserQuerySet {
+	group := fn(UserQuerySet{db: qs.db.New()}).prepare()
+	return qs.w(base.And(qs.db, group.db))
+}
+
+// ApplyFieldMask adds equality conditions for every path of field mask
+// (e.g. of protobuf FieldMask): column = values[path]. Paths are converted
+// to snake_case and must be columns of model.
+func (qs UserQuerySet) ApplyFieldMask(mask []string, values map[string]interface{}) (UserQuerySet, error) {
+	columns := []string{"id", "created_at", "updated_at", "deleted_at", "rating", "rating_marks"}
+	db, err := base.ApplyFieldMask(qs.db, columns, mask, values)
+	if err != nil {
+		return qs, err
+	}
+	return qs.w(db), nil
+}
+
+// ApplyFilterInput adds conditions for every not nil operator of every
+// not nil field of GraphQL-style filter input. Operators not supported
+// by kind of field (e.g. Like for numeric field) are errors. In is split
+// into chunks like {Field}In, empty not nil In matches no records.
+func (qs UserQuerySet) ApplyFilterInput(input UserFilterInput) (UserQuerySet, error) {
+	if f := input.ID; f != nil {
+		if f.Eq != nil {
+			qs = qs.IDEq(*f.Eq)
+		}
+		if f.Ne != nil {
+			qs = qs.IDNe(*f.Ne)
+		}
+		if f.In != nil {
+			qs = qs.IDIn(f.In...)
+		}
+		if f.Gt != nil {
+			qs = qs.IDGt(*f.Gt)
+		}
+		if f.Gte != nil {
+			qs = qs.IDGte(*f.Gte)
+		}
+		if f.Lt != nil {
+			qs = qs.IDLt(*f.Lt)
+		}
+		if f.Lte != nil {
+			qs = qs.IDLte(*f.Lte)
+		}
+		if f.Like != nil {
+			return qs, errors.New("UserFilterInput.ID: Like is supported only by string fields")
+		}
+	}
+	if f := input.CreatedAt; f != nil {
+		if f.Eq != nil {
+			qs = qs.CreatedAtEq(*f.Eq)
+		}
+		if f.Ne != nil {
+			qs = qs.CreatedAtNe(*f.Ne)
+		}
+		if f.In != nil {
+			qs = qs.w(base.WhereIn(qs.db, "created_at", f.In))
+		}
+		if f.Gt != nil {
+			qs = qs.CreatedAtGt(*f.Gt)
+		}
+		if f.Gte != nil {
+			qs = qs.CreatedAtGte(*f.Gte)
+		}
+		if f.Lt != nil {
+			qs = qs.CreatedAtLt(*f.Lt)
+		}
+		if f.Lte != nil {
+			qs = qs.CreatedAtLte(*f.Lte)
+		}
+		if f.Like != nil {
+			return qs, errors.New("UserFilterInput.CreatedAt: Like is supported only by string fields")
+		}
+	}
+	if f := input.UpdatedAt; f != nil {
+		if f.Eq != nil {
+			qs = qs.UpdatedAtEq(*f.Eq)
+		}
+		if f.Ne != nil {
+			qs = qs.UpdatedAtNe(*f.Ne)
+		}
+		if f.In != nil {
+			qs = qs.w(base.WhereIn(qs.db, "updated_at", f.In))
+		}
+		if f.Gt != nil {
+			qs = qs.UpdatedAtGt(*f.Gt)
+		}
+		if f.Gte != nil {
+			qs = qs.UpdatedAtGte(*f.Gte)
+		}
+		if f.Lt != nil {
+			qs = qs.UpdatedAtLt(*f.Lt)
+		}
+		if f.Lte != nil {
+			qs = qs.UpdatedAtLte(*f.Lte)
+		}
+		if f.Like != nil {
+			return qs, errors.New("UserFilterInput.UpdatedAt: Like is supported only by string fields")
+		}
+	}
+	if f := input.Rating; f != nil {
+		if f.Eq != nil {
+			qs = qs.RatingEq(*f.Eq)
+		}
+		if f.Ne != nil {
+			qs = qs.RatingNe(*f.Ne)
+		}
+		if f.In != nil {
+			qs = qs.RatingIn(f.In...)
+		}
+		if f.Gt != nil {
+			qs = qs.RatingGt(*f.Gt)
+		}
+		if f.Gte != nil {
+			qs = qs.RatingGte(*f.Gte)
+		}
+		if f.Lt != nil {
+			qs = qs.RatingLt(*f.Lt)
+		}
+		if f.Lte != nil {
+			qs = qs.RatingLte(*f.Lte)
+		}
+		if f.Like != nil {
+			return qs, errors.New("UserFilterInput.Rating: Like is supported only by string fields")
+		}
+	}
+	if f := input.RatingMarks; f != nil {
+		if f.Eq != nil {
+			qs = qs.RatingMarksEq(*f.Eq)
+		}
+		if f.Ne != nil {
+			qs = qs.RatingMarksNe(*f.Ne)
+		}
+		if f.In != nil {
+			qs = qs.RatingMarksIn(f.In...)
+		}
+		if f.Gt != nil {
+			qs = qs.RatingMarksGt(*f.Gt)
+		}
+		if f.Gte != nil {
+			qs = qs.RatingMarksGte(*f.Gte)
+		}
+		if f.Lt != nil {
+			qs = qs.RatingMarksLt(*f.Lt)
+		}
+		if f.Lte != nil {
+			qs = qs.RatingMarksLte(*f.Lte)
+		}
+		if f.Like != nil {
+			return qs, errors.New("UserFilterInput.RatingMarks: Like is supported only by string fields")
+		}
+	}
+	return qs, nil
+}
+
+// ApplyRangeFilter adds conditions min <= field <= max
+// for every not nil bound of range filter f
+func (qs UserQuerySet) ApplyRangeFilter(f UserRangeFilter) UserQuerySet {
+	if f.IDMin != nil {
+		qs = qs.IDGte(*f.IDMin)
+	}
+	if f.IDMax != nil {
+		qs = qs.IDLte(*f.IDMax)
+	}
+	if f.CreatedAtMin != nil {
+		qs = qs.CreatedAtGte(*f.CreatedAtMin)
+	}
+	if f.CreatedAtMax != nil {
+		qs = qs.CreatedAtLte(*f.CreatedAtMax)
+	}
+	if f.UpdatedAtMin != nil {
+		qs = qs.UpdatedAtGte(*f.UpdatedAtMin)
+	}
+	if f.UpdatedAtMax != nil {
+		qs = qs.UpdatedAtLte(*f.UpdatedAtMax)
+	}
+	if f.RatingMin != nil {
+		qs = qs.RatingGte(*f.RatingMin)
+	}
+	if f.RatingMax != nil {
+		qs = qs.RatingLte(*f.RatingMax)
+	}
+	if f.RatingMarksMin != nil {
+		qs = qs.RatingMarksGte(*f.RatingMarksMin)
+	}
+	if f.RatingMarksMax != nil {
+		qs = qs.RatingMarksLte(*f.RatingMarksMax)
+	}
+	return qs
+}
+
+// AsScope returns GORM scope adding conditions of query set, e.g. for
+// db.Scopes(...) or preloads. Query set must have only conditions
+func (qs UserQuerySet) AsScope() func(*gorm.DB) *gorm.DB {
+	return base.AsScope(qs.scopedDB())
+}
+
+// AvgRating returns AVG of field Rating of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) AvgRating() (ret float64, err error) {
+	err = qs.exec("AvgRating", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateAvg, "rating", &ret)
+		return err
+	})
+	return
+}
+
+// AvgRatingMarks returns AVG of field RatingMarks of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) AvgRatingMarks() (ret float64, err error) {
+	err = qs.exec("AvgRatingMarks", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateAvg, "rating_marks", &ret)
+		return err
+	})
+	return
+}
+
+// ContinueAfter selects records after the last record of cursor made
+// by User.KeysetCursor by lexicographic comparison of sort keys, e.g.
+// (name, id) > (?, ?). Records must be ordered by the same fields
+// in the same direction.
+func (qs UserQuerySet) ContinueAfter(cursor string) (UserQuerySet, error) {
+	orderedColumns := []string{"id", "created_at", "updated_at", "rating", "rating_marks"}
+	db, err := base.ContinueAfter(qs.db, &User{}, cursor, orderedColumns)
+	if err != nil {
+		return qs, err
+	}
+	return qs.w(db), nil
+}
+
+// Count returns count of matching records, soft deleted records
+// aren't counted
+func (qs UserQuerySet) Count() (ret int, err error) {
+	err = qs.exec("Count", func(db *gorm.DB) error {
+		err = db.Model(&User{}).Count(&ret).Error
+		return err
+	})
+	return
+}
+
+// CountApprox returns approximate count of records from table statistics
+// if query set has no conditions, otherwise it's an exact count. Soft delete
+// condition isn't taken into account: estimate includes soft deleted records.
+func (qs UserQuerySet) CountApprox() (ret int64, err error) {
+	err = qs.exec("CountApprox", func(db *gorm.DB) error {
+		ret, err = base.CountApprox(db.Model(&User{}))
+		return err
+	})
+	return
+}
+
+// CountByFieldWithRollup returns counts of records grouped by values
+// of field and the last summary row with grand total marked by Total:
+// WITH ROLLUP in MySQL, GROUPING SETS in PostgreSQL
+func (qs UserQuerySet) CountByFieldWithRollup(field userDBSchemaField) (ret []base.RollupEntry, err error) {
+	err = qs.exec("CountByFieldWithRollup", func(db *gorm.DB) error {
+		ret, err = base.CountByFieldWithRollup(db.Model(&User{}), string(field))
+		return err
+	})
+	return
+}
+
+// CountByHour returns counts of matching records grouped by hour of time
+// field ordered chronologically. Not time fields are errors.
+func (qs UserQuerySet) CountByHour(field userDBSchemaField) (ret []base.HourCount, err error) {
+	timeColumns := []string{"created_at", "updated_at"}
+	err = qs.exec("CountByHour", func(db *gorm.DB) error {
+		ret, err = base.CountByHour(db.Model(&User{}), string(field), timeColumns)
+		return err
+	})
+	return
+}
+
+// CountByTwoFields returns counts of records grouped by values of fields a and b
+func (qs UserQuerySet) CountByTwoFields(a, b userDBSchemaField) (ret []base.TwoFieldCount, err error) {
+	err = qs.exec("CountByTwoFields", func(db *gorm.DB) error {
+		ret, err = base.CountByTwoFields(db.Model(&User{}), string(a), string(b))
+		return err
+	})
+	return
+}
+
+// CountDeleted returns count of matching soft deleted records
+func (qs UserQuerySet) CountDeleted() (int, error) {
+	return qs.OnlyDeleted().Count()
+}
+
+// CountUnscoped returns count of matching records including soft deleted
+func (qs UserQuerySet) CountUnscoped() (int, error) {
+	return qs.Unscoped().Count()
 }
 
 // Create is an autogenerated method
 // nolint: dupl
 func (o *User) Create(db *gorm.DB) error {
-	return db.Create(o).Error
+	if err := base.CheckContext(db); err != nil {
+		return err
+	}
+	return base.Primary(db).Create(o).Error
+}
+
+// CreateIfNotMatched creates o only if there are no matching records in one
+// transaction: existence is checked by locking read SELECT ... FOR UPDATE.
+// PostgreSQL doesn't lock absent rows, so unique constraint is needed there
+// to prevent races. Only PostgreSQL and MySQL are supported.
+func (qs UserQuerySet) CreateIfNotMatched(o *User) (created bool, err error) {
+	err = qs.exec("CreateIfNotMatched", func(db *gorm.DB) error {
+		created, err = base.CreateIfNotMatched(db.Model(&User{}), o.Create)
+		return err
+	})
+	return
 }
 
 // CreatedAtEq is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtEq(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at = ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "eq", createdAt, "created_at = ?", createdAt))
 }
 
 // CreatedAtGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtGt(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at > ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "gt", createdAt, "created_at > ?", createdAt))
 }
 
 // CreatedAtGte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtGte(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at >= ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "gte", createdAt, "created_at >= ?", createdAt))
 }
 
 // CreatedAtLt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtLt(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at < ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "lt", createdAt, "created_at < ?", createdAt))
 }
 
 // CreatedAtLte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtLte(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at <= ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "lte", createdAt, "created_at <= ?", createdAt))
 }
 
 // CreatedAtNe is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) CreatedAtNe(createdAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("created_at != ?", createdAt))
+	return qs.w(base.DescribedWhere(qs.db, "CreatedAt", "ne", createdAt, "created_at != ?", createdAt))
+}
+
+// CreatedAtOnDateInLocation filters records by calendar day of date in location loc.
+// Boundaries of this day are converted to UTC: [00:00, 24:00) of the day in loc
+func (qs UserQuerySet) CreatedAtOnDateInLocation(date time.Time, loc *time.Location) UserQuerySet {
+	y, m, d := date.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	return qs.w(qs.db.Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()))
+}
+
+// CreatedAtThisMonth filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) CreatedAtThisMonth(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodMonth)
+	return qs.w(qs.db.Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()))
+}
+
+// CreatedAtThisWeek filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) CreatedAtThisWeek(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodWeek)
+	return qs.w(qs.db.Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()))
+}
+
+// CreatedAtToday filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) CreatedAtToday(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodDay)
+	return qs.w(qs.db.Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()))
+}
+
+// DecrementRating sets Rating to rating - n atomically
+func (u UserUpdater) DecrementRating(n int) UserUpdater {
+	u.fields[string(UserDBSchema.Rating)] = gorm.Expr(string(UserDBSchema.Rating)+" - ?", n)
+	return u
+}
+
+// DecrementRatingMarks sets RatingMarks to rating_marks - n atomically
+func (u UserUpdater) DecrementRatingMarks(n int) UserUpdater {
+	u.fields[string(UserDBSchema.RatingMarks)] = gorm.Expr(string(UserDBSchema.RatingMarks)+" - ?", n)
+	return u
+}
+
+// Defer registers transformation fn of query set. It's applied
+// just before execution of terminal method (All, One, Delete, etc).
+// Deferred transformations are applied in order of registration.
+func (qs UserQuerySet) Defer(fn func(qs UserQuerySet) UserQuerySet) UserQuerySet {
+	n := len(qs.deferred)
+	qs.deferred = append(qs.deferred[:n:n], fn)
+	return qs
 }
 
 // Delete is an autogenerated method
 // nolint: dupl
 func (o *User) Delete(db *gorm.DB) error {
-	return db.Delete(o).Error
+	if err := base.CheckContext(db); err != nil {
+		return err
+	}
+	if err := base.CheckPrimaryKey(db, o); err != nil {
+		return err
+	}
+	return base.Primary(db).Delete(o).Error
 }
 
 // Delete is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) Delete() error {
-	return qs.db.Delete(User{}).Error
+	return qs.exec("Delete", func(db *gorm.DB) error {
+		return db.Delete(User{}).Error
+	})
+}
+
+// DeleteHard physically deletes records matching query set by DELETE
+// even for soft delete models, soft deleted records are deleted too.
+// It returns count of deleted records.
+func (qs UserQuerySet) DeleteHard() (ret int64, err error) {
+	err = qs.exec("DeleteHard", func(db *gorm.DB) error {
+		res := db.Unscoped().Delete(&User{})
+		ret, err = res.RowsAffected, res.Error
+		return err
+	})
+	return
+}
+
+// DeleteOlderThan deletes matching records with time field older than
+// current time minus d (field < cutoff) and returns count of deleted records.
+// Records are deleted like by Delete. Not time fields are errors.
+func (qs UserQuerySet) DeleteOlderThan(field userDBSchemaField, d time.Duration) (ret int64, err error) {
+	timeColumns := []string{"created_at", "updated_at"}
+	qs = qs.w(base.OlderThan(qs.db, string(field), timeColumns, d))
+	err = qs.exec("DeleteOlderThan", func(db *gorm.DB) error {
+		res := db.Delete(User{})
+		ret, err = res.RowsAffected, res.Error
+		return err
+	})
+	return
 }
 
 // DeletedAtEq is an autogenerated method
@@ -93,6 +787,15 @@ func (qs UserQuerySet) DeletedAtEq(deletedAt time.Time) UserQuerySet {
 	return qs.w(qs.db.Where("deleted_at = ?", deletedAt))
 }
 
+// DeletedAtEqNullable selects records with deleted_at = v if v is valid
+// or with deleted_at IS NULL otherwise
+func (qs UserQuerySet) DeletedAtEqNullable(v sql.NullTime) UserQuerySet {
+	if !v.Valid {
+		return qs.w(qs.db.Where("deleted_at IS NULL"))
+	}
+	return qs.w(qs.db.Where("deleted_at = ?", v.Time))
+}
+
 // DeletedAtGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) DeletedAtGt(deletedAt time.Time) UserQuerySet {
@@ -129,46 +832,511 @@ func (qs UserQuerySet) DeletedAtNe(deletedAt time.Time) UserQuerySet {
 	return qs.w(qs.db.Where("deleted_at != ?", deletedAt))
 }
 
+// DeletedAtOnDateInLocation filters records by calendar day of date in location loc.
+// Boundaries of this day are converted to UTC: [00:00, 24:00) of the day in loc
+func (qs UserQuerySet) DeletedAtOnDateInLocation(date time.Time, loc *time.Location) UserQuerySet {
+	y, m, d := date.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	return qs.w(qs.db.Where("deleted_at >= ? AND deleted_at < ?", from.UTC(), to.UTC()))
+}
+
+// DeletedAtThisMonth filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) DeletedAtThisMonth(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodMonth)
+	return qs.w(qs.db.Where("deleted_at >= ? AND deleted_at < ?", from.UTC(), to.UTC()))
+}
+
+// DeletedAtThisWeek filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) DeletedAtThisWeek(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodWeek)
+	return qs.w(qs.db.Where("deleted_at >= ? AND deleted_at < ?", from.UTC(), to.UTC()))
+}
+
+// DeletedAtToday filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) DeletedAtToday(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodDay)
+	return qs.w(qs.db.Where("deleted_at >= ? AND deleted_at < ?", from.UTC(), to.UTC()))
+}
+
+// Describe returns serializable description of conditions of query set
+// (e.g. to save search) to apply them again by FromDescription. Only
+// conditions added by Eq, Ne, Lt, Gt, Lte and Gte filter methods of fields
+// can be described: query set with other conditions is an error.
+func (qs UserQuerySet) Describe() (base.QueryDescription, error) {
+	return base.Describe(qs.prepare().db)
+}
+
+// DiffFromDB reloads User by primary key and returns fields
+// having different values in o and in db
+func (o *User) DiffFromDB(db *gorm.DB) ([]userDBSchemaField, error) {
+	var dbo User
+	if err := base.ReloadByPK(db, o, &dbo); err != nil {
+		return nil, err
+	}
+
+	ret := []userDBSchemaField{}
+	if !base.FieldsEqual(o.ID, dbo.ID) {
+		ret = append(ret, UserDBSchema.ID)
+	}
+	if !base.FieldsEqual(o.CreatedAt, dbo.CreatedAt) {
+		ret = append(ret, UserDBSchema.CreatedAt)
+	}
+	if !base.FieldsEqual(o.UpdatedAt, dbo.UpdatedAt) {
+		ret = append(ret, UserDBSchema.UpdatedAt)
+	}
+	if !base.FieldsEqual(o.DeletedAt, dbo.DeletedAt) {
+		ret = append(ret, UserDBSchema.DeletedAt)
+	}
+	if !base.FieldsEqual(o.Rating, dbo.Rating) {
+		ret = append(ret, UserDBSchema.Rating)
+	}
+	if !base.FieldsEqual(o.RatingMarks, dbo.RatingMarks) {
+		ret = append(ret, UserDBSchema.RatingMarks)
+	}
+	return ret, nil
+}
+
+// EachRow calls fn for every matching record: records are loaded by chunks
+// of base.EachRowChunkSize records ordered by ID (order of query set is
+// replaced). It stops on first error of fn. Limit or Offset of query set
+// is an error.
+func (qs UserQuerySet) EachRow(fn func(User) error) error {
+	if err := base.CheckNoLimitOffset(qs.db); err != nil {
+		return err
+	}
+
+	// order of query set is replaced: chunks are continued by ID
+	qs = qs.w(qs.db.Order("id ASC", true))
+	size := base.EachRowChunkSize(qs.db)
+	chunk := qs
+	for {
+		var rows []User
+		if err := chunk.Limit(size).All(&rows); err != nil {
+			return err
+		}
+
+		for _, o := range rows {
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < size {
+			return nil
+		}
+		chunk = qs.IDGt(rows[len(rows)-1].ID)
+	}
+}
+
+// Explain returns plan of query selecting records by All:
+// query with the same conditions is prefixed by EXPLAIN
+func (qs UserQuerySet) Explain() (ret string, err error) {
+	err = qs.exec("Explain", func(db *gorm.DB) error {
+		ret, err = base.Explain(db.Model(&User{}), false)
+		return err
+	})
+	return
+}
+
+// ExplainAnalyze is like Explain, but query is executed by EXPLAIN ANALYZE
+// to get actual times. It's supported only by PostgreSQL and MySQL.
+func (qs UserQuerySet) ExplainAnalyze() (ret string, err error) {
+	err = qs.exec("ExplainAnalyze", func(db *gorm.DB) error {
+		ret, err = base.Explain(db.Model(&User{}), true)
+		return err
+	})
+	return
+}
+
+// FacetField returns distinct values of field of matching records with
+// their counts, e.g. for faceted search sidebars
+func (qs UserQuerySet) FacetField(field userDBSchemaField) (ret []base.Facet, err error) {
+	err = qs.exec("FacetField", func(db *gorm.DB) error {
+		ret, err = base.FacetField(db.Model(&User{}), string(field))
+		return err
+	})
+	return
+}
+
+// FieldEqExpr selects records with field = computed SQL expression expr,
+// e.g. "cost * ?": field = (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldEqExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" = ("+expr+")", args...))
+}
+
+// FieldEqScalarSubQuery selects records with field = value selected
+// by scalar subquery sub of ScalarSubQuery: field = (SELECT ...)
+func (qs UserQuerySet) FieldEqScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), "=", sub))
+}
+
+// FieldGtExpr selects records with field > computed SQL expression expr,
+// e.g. "cost * ?": field > (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldGtExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" > ("+expr+")", args...))
+}
+
+// FieldGtScalarSubQuery selects records with field > value selected
+// by scalar subquery sub of ScalarSubQuery: field > (SELECT ...)
+func (qs UserQuerySet) FieldGtScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), ">", sub))
+}
+
+// FieldGteExpr selects records with field >= computed SQL expression expr,
+// e.g. "cost * ?": field >= (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldGteExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" >= ("+expr+")", args...))
+}
+
+// FieldGteScalarSubQuery selects records with field >= value selected
+// by scalar subquery sub of ScalarSubQuery: field >= (SELECT ...)
+func (qs UserQuerySet) FieldGteScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), ">=", sub))
+}
+
+// FieldLtExpr selects records with field < computed SQL expression expr,
+// e.g. "cost * ?": field < (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldLtExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" < ("+expr+")", args...))
+}
+
+// FieldLtScalarSubQuery selects records with field < value selected
+// by scalar subquery sub of ScalarSubQuery: field < (SELECT ...)
+func (qs UserQuerySet) FieldLtScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), "<", sub))
+}
+
+// FieldLteExpr selects records with field <= computed SQL expression expr,
+// e.g. "cost * ?": field <= (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldLteExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" <= ("+expr+")", args...))
+}
+
+// FieldLteScalarSubQuery selects records with field <= value selected
+// by scalar subquery sub of ScalarSubQuery: field <= (SELECT ...)
+func (qs UserQuerySet) FieldLteScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), "<=", sub))
+}
+
+// FieldNeExpr selects records with field != computed SQL expression expr,
+// e.g. "cost * ?": field != (expr). Values of placeholders ? of expr are
+// args and are bound safely, but expr itself is inserted into query as is:
+// it must never be built from user input.
+func (qs UserQuerySet) FieldNeExpr(field userDBSchemaField, expr string, args ...interface{}) UserQuerySet {
+	return qs.w(qs.db.Where(string(field)+" != ("+expr+")", args...))
+}
+
+// FieldNeScalarSubQuery selects records with field != value selected
+// by scalar subquery sub of ScalarSubQuery: field != (SELECT ...)
+func (qs UserQuerySet) FieldNeScalarSubQuery(field userDBSchemaField, sub base.SubQuery) UserQuerySet {
+	return qs.w(base.CompareScalarSubQuery(qs.db, string(field), "!=", sub))
+}
+
+// FilterFromStruct selects records with fields equal to not zero fields
+// of struct v (or pointer to it), e.g. of admin search form. Fields of v are
+// matched to fields of User by gorm column tag or by name, types must match.
+// Fields tagged with queryset:"-" are skipped, other unknown fields are errors.
+func (qs UserQuerySet) FilterFromStruct(v interface{}) (UserQuerySet, error) {
+	db, err := base.FilterFromStruct(qs.db, &User{}, v)
+	if err != nil {
+		return qs, err
+	}
+	return qs.w(db), nil
+}
+
+// FindDuplicates returns values of field met in more than one record
+// and counts of these records
+func (qs UserQuerySet) FindDuplicates(field userDBSchemaField) (ret []base.DuplicateGroup, err error) {
+	err = qs.exec("FindDuplicates", func(db *gorm.DB) error {
+		ret, err = base.FindDuplicates(db.Model(&User{}), string(field))
+		return err
+	})
+	return
+}
+
+// FromDescription adds conditions of serialized description desc (e.g. saved
+// search) by filter methods of query set. Fields, operators and values of
+// conditions are validated.
+func (qs UserQuerySet) FromDescription(desc base.QueryDescription) (UserQuerySet, error) {
+	for i, c := range desc.Conditions {
+		switch c.Field {
+		case "ID":
+			var v uint
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %d on ID: %s", i, err)
+			}
+			switch c.Op {
+			case "eq":
+				qs = qs.IDEq(v)
+			case "ne":
+				qs = qs.IDNe(v)
+			case "lt":
+				qs = qs.IDLt(v)
+			case "gt":
+				qs = qs.IDGt(v)
+			case "lte":
+				qs = qs.IDLte(v)
+			case "gte":
+				qs = qs.IDGte(v)
+			default:
+				return qs, fmt.Errorf("invalid op %q of condition %d on ID", c.Op, i)
+			}
+		case "CreatedAt":
+			var v time.Time
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %d on CreatedAt: %s", i, err)
+			}
+			switch c.Op {
+			case "eq":
+				qs = qs.CreatedAtEq(v)
+			case "ne":
+				qs = qs.CreatedAtNe(v)
+			case "lt":
+				qs = qs.CreatedAtLt(v)
+			case "gt":
+				qs = qs.CreatedAtGt(v)
+			case "lte":
+				qs = qs.CreatedAtLte(v)
+			case "gte":
+				qs = qs.CreatedAtGte(v)
+			default:
+				return qs, fmt.Errorf("invalid op %q of condition %d on CreatedAt", c.Op, i)
+			}
+		case "UpdatedAt":
+			var v time.Time
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %d on UpdatedAt: %s", i, err)
+			}
+			switch c.Op {
+			case "eq":
+				qs = qs.UpdatedAtEq(v)
+			case "ne":
+				qs = qs.UpdatedAtNe(v)
+			case "lt":
+				qs = qs.UpdatedAtLt(v)
+			case "gt":
+				qs = qs.UpdatedAtGt(v)
+			case "lte":
+				qs = qs.UpdatedAtLte(v)
+			case "gte":
+				qs = qs.UpdatedAtGte(v)
+			default:
+				return qs, fmt.Errorf("invalid op %q of condition %d on UpdatedAt", c.Op, i)
+			}
+		case "Rating":
+			var v int
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %d on Rating: %s", i, err)
+			}
+			switch c.Op {
+			case "eq":
+				qs = qs.RatingEq(v)
+			case "ne":
+				qs = qs.RatingNe(v)
+			case "lt":
+				qs = qs.RatingLt(v)
+			case "gt":
+				qs = qs.RatingGt(v)
+			case "lte":
+				qs = qs.RatingLte(v)
+			case "gte":
+				qs = qs.RatingGte(v)
+			default:
+				return qs, fmt.Errorf("invalid op %q of condition %d on Rating", c.Op, i)
+			}
+		case "RatingMarks":
+			var v int
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %d on RatingMarks: %s", i, err)
+			}
+			switch c.Op {
+			case "eq":
+				qs = qs.RatingMarksEq(v)
+			case "ne":
+				qs = qs.RatingMarksNe(v)
+			case "lt":
+				qs = qs.RatingMarksLt(v)
+			case "gt":
+				qs = qs.RatingMarksGt(v)
+			case "lte":
+				qs = qs.RatingMarksLte(v)
+			case "gte":
+				qs = qs.RatingMarksGte(v)
+			default:
+				return qs, fmt.Errorf("invalid op %q of condition %d on RatingMarks", c.Op, i)
+			}
+		default:
+			return qs, fmt.Errorf("invalid field %q of condition %d", c.Field, i)
+		}
+	}
+	return qs, nil
+}
+
+// GetDB returns db of query set with all conditions, orders and limits
+// added so far, e.g. to add clause which can't be expressed by query set
+func (qs UserQuerySet) GetDB() *gorm.DB {
+	return qs.scopedDB()
+}
+
+// GetOrCreate returns first record matching query set or creates attrs
+// if there are no such records: created is true then. Values of {Field}Eq
+// conditions are assigned to fields of attrs before create, so created record
+// matches query set. Soft delete condition is applied only to lookup.
+// Lookup and create aren't atomic: if create fails by duplicate key of
+// concurrently created record, it's selected again. Use unique constraint
+// or CreateIfNotMatched to prevent duplicates by races.
+func (qs UserQuerySet) GetOrCreate(attrs *User) (ret User, created bool, err error) {
+	err = qs.exec("GetOrCreate", func(db *gorm.DB) error {
+		created, err = base.GetOrCreate(db, &ret, attrs, attrs.Create)
+		if created {
+			ret = *attrs
+		}
+		return err
+	})
+	return
+}
+
 // GetUpdater is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) GetUpdater() UserUpdater {
-	return NewUserUpdater(qs.db)
+	return NewUserUpdater(qs.scopedDB())
+}
+
+// HistogramField returns counts of matching records by buckets [From, To)
+// of size bucketSize of numeric field values, e.g. for histogram charts:
+// GROUP BY FLOOR(field / bucketSize). Not numeric fields are errors.
+func (qs UserQuerySet) HistogramField(field userDBSchemaField, bucketSize float64) (ret []base.Bucket, err error) {
+	numericColumns := []string{"id", "rating", "rating_marks"}
+	err = qs.exec("HistogramField", func(db *gorm.DB) error {
+		ret, err = base.Histogram(db.Model(&User{}), string(field), numericColumns, bucketSize)
+		return err
+	})
+	return
 }
 
 // IDEq is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDEq(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id = ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "eq", ID, "id = ?", ID))
 }
 
 // IDGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDGt(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id > ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "gt", ID, "id > ?", ID))
 }
 
 // IDGte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDGte(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id >= ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "gte", ID, "id >= ?", ID))
+}
+
+// IDIn filters by id IN (values): more than base.MaxInListSize
+// values are split into IN lists ORed together. No values match no records.
+func (qs UserQuerySet) IDIn(ID ...uint) UserQuerySet {
+	return qs.w(base.WhereIn(qs.db, "id", ID))
 }
 
 // IDLt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDLt(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id < ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "lt", ID, "id < ?", ID))
 }
 
 // IDLte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDLte(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id <= ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "lte", ID, "id <= ?", ID))
 }
 
 // IDNe is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) IDNe(ID uint) UserQuerySet {
-	return qs.w(qs.db.Where("id != ?", ID))
+	return qs.w(base.DescribedWhere(qs.db, "ID", "ne", ID, "id != ?", ID))
+}
+
+// IDNotIn filters by id NOT IN (values): more than base.MaxInListSize
+// values are split into NOT IN lists joined by AND. No values match all records.
+func (qs UserQuerySet) IDNotIn(ID ...uint) UserQuerySet {
+	return qs.w(base.WhereNotIn(qs.db, "id", ID))
+}
+
+// InTransaction calls fn in transaction: it's committed if fn returns nil
+// and rolled back otherwise. Query set qs passed to fn has the same conditions
+// and is bound to transaction, tx is the same transaction without conditions
+// for model methods, e.g. o.Create(tx). If query set is made on transaction
+// it's used as is.
+func (qs UserQuerySet) InTransaction(fn func(tx *gorm.DB, qs UserQuerySet) error) error {
+	return base.InTransaction(qs.db, func(tx *gorm.DB) error {
+		return fn(tx.New(), qs.w(tx))
+	})
+}
+
+// IncrementRating sets Rating to rating + n atomically
+func (u UserUpdater) IncrementRating(n int) UserUpdater {
+	u.fields[string(UserDBSchema.Rating)] = gorm.Expr(string(UserDBSchema.Rating)+" + ?", n)
+	return u
+}
+
+// IncrementRatingMarks sets RatingMarks to rating_marks + n atomically
+func (u UserUpdater) IncrementRatingMarks(n int) UserUpdater {
+	u.fields[string(UserDBSchema.RatingMarks)] = gorm.Expr(string(UserDBSchema.RatingMarks)+" + ?", n)
+	return u
+}
+
+// IntoTempTable creates temporary table name from matching records:
+// CREATE TEMPORARY TABLE name AS SELECT ... Temporary table is visible
+// only to connection, so query set must be made on transaction and
+// queries joining the table must be made on it.
+func (qs UserQuerySet) IntoTempTable(name string) error {
+	return qs.exec("IntoTempTable", func(db *gorm.DB) error {
+		return base.IntoTempTable(db.Model(&User{}), name)
+	})
+}
+
+// IsEmpty returns true if there are no matching records.
+// It selects at most one record: LIMIT 1
+func (qs UserQuerySet) IsEmpty() (ret bool, err error) {
+	err = qs.exec("IsEmpty", func(db *gorm.DB) error {
+		ret, err = base.IsEmpty(db.Model(&User{}))
+		return err
+	})
+	return
+}
+
+// KeysetCursor returns opaque cursor of o as the last record of page
+// ordered by fields, descending if desc is set: pass it to ContinueAfter
+// to select the next page
+func (o *User) KeysetCursor(desc bool, fields ...userDBSchemaField) (string, error) {
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		columns = append(columns, string(f))
+	}
+	return base.EncodeKeysetCursor(o, desc, columns)
+}
+
+// LatestPerField selects only the latest version of records
+// with the same value of key field: records with max UpdatedAt
+func (qs UserQuerySet) LatestPerField(key userDBSchemaField) UserQuerySet {
+	return qs.w(base.LatestPerField(qs.db, &User{}, string(key), "updated_at"))
 }
 
 // Limit is an autogenerated method
@@ -177,10 +1345,169 @@ func (qs UserQuerySet) Limit(limit int) UserQuerySet {
 	return qs.w(qs.db.Limit(limit))
 }
 
+// LockTable explicitly locks table for bulk operations: LOCK TABLES t WRITE
+// (or READ) in MySQL, LOCK TABLE t IN mode MODE in PostgreSQL. Query set
+// must be made on transaction: lock is held by its connection.
+// Other dialects are errors. WARNING: MySQL commits transaction implicitly
+// by LOCK TABLES and UNLOCK TABLES, statements between them aren't rolled
+// back with transaction (see base.LockTable).
+func (qs UserQuerySet) LockTable(mode string) error {
+	return base.LockTable(qs.db, &User{}, mode)
+}
+
+// MaxRating returns MAX of field Rating of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) MaxRating() (ret int, err error) {
+	err = qs.exec("MaxRating", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateMax, "rating", &ret)
+		return err
+	})
+	return
+}
+
+// MaxRatingMarks returns MAX of field RatingMarks of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) MaxRatingMarks() (ret int, err error) {
+	err = qs.exec("MaxRatingMarks", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateMax, "rating_marks", &ret)
+		return err
+	})
+	return
+}
+
+// MinMaxCreatedAt returns minimal and maximal values of field CreatedAt of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxCreatedAt() (min, max time.Time, err error) {
+	err = qs.exec("MinMaxCreatedAt", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "created_at", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinMaxDeletedAt returns minimal and maximal values of field DeletedAt of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxDeletedAt() (min, max time.Time, err error) {
+	err = qs.exec("MinMaxDeletedAt", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "deleted_at", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinMaxID returns minimal and maximal values of field ID of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxID() (min, max uint, err error) {
+	err = qs.exec("MinMaxID", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "id", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinMaxRating returns minimal and maximal values of field Rating of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxRating() (min, max int, err error) {
+	err = qs.exec("MinMaxRating", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "rating", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinMaxRatingMarks returns minimal and maximal values of field RatingMarks of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxRatingMarks() (min, max int, err error) {
+	err = qs.exec("MinMaxRatingMarks", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "rating_marks", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinMaxUpdatedAt returns minimal and maximal values of field UpdatedAt of matching
+// records by one query: zero values are returned if there are no records
+func (qs UserQuerySet) MinMaxUpdatedAt() (min, max time.Time, err error) {
+	err = qs.exec("MinMaxUpdatedAt", func(db *gorm.DB) error {
+		err = base.MinMax(db.Model(&User{}), "updated_at", &min, &max)
+		return err
+	})
+	return
+}
+
+// MinRating returns MIN of field Rating of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) MinRating() (ret int, err error) {
+	err = qs.exec("MinRating", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateMin, "rating", &ret)
+		return err
+	})
+	return
+}
+
+// MinRatingMarks returns MIN of field RatingMarks of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) MinRatingMarks() (ret int, err error) {
+	err = qs.exec("MinRatingMarks", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateMin, "rating_marks", &ret)
+		return err
+	})
+	return
+}
+
+// NextBy finds matching record next to current by ascending order of field
+// into ret, e.g. for navigation on detail page. Ties are resolved by ID.
+// gorm.ErrRecordNotFound is returned if there is no such record.
+func (qs UserQuerySet) NextBy(field userDBSchemaField, current, ret *User) error {
+	orderedColumns := []string{"id", "created_at", "updated_at", "rating", "rating_marks"}
+	return qs.exec("NextBy", func(db *gorm.DB) error {
+		return base.Neighbor(db, current, string(field), "id", orderedColumns, true, ret)
+	})
+}
+
+// Not adds negation of conditions added by fn: NOT (...).
+// fn must add only conditions to passed empty query set
+func (qs UserQuerySet) Not(fn func(qs UserQuerySet) UserQuerySet) UserQuerySet {
+	group := fn(UserQuerySet{db: qs.db.New()}).prepare()
+	return qs.w(base.Not(qs.db, group.db))
+}
+
+// Offset is an autogenerated method
+// nolint: dupl
+func (qs UserQuerySet) Offset(offset int) UserQuerySet {
+	return qs.w(qs.db.Offset(offset))
+}
+
 // One is used to retrieve one result. It returns gorm.ErrRecordNotFound
 // if nothing was fetched
 func (qs UserQuerySet) One(ret *User) error {
-	return qs.db.First(ret).Error
+	return qs.exec("One", func(db *gorm.DB) error {
+		return db.First(ret).Error
+	})
+}
+
+// OneForUpdateNoWait is like One, but locks fetched row by FOR UPDATE NOWAIT.
+// It returns base.ErrRowLocked if row is already locked by another transaction
+func (qs UserQuerySet) OneForUpdateNoWait(ret *User) error {
+	return qs.exec("OneForUpdateNoWait", func(db *gorm.DB) error {
+		return base.OneForUpdateNoWait(db, ret)
+	})
+}
+
+// OnlyDeleted selects only soft deleted records
+func (qs UserQuerySet) OnlyDeleted() UserQuerySet {
+	return qs.w(qs.db.Unscoped().Where("deleted_at IS NOT NULL"))
+}
+
+// Or adds conditions added by fns ORed together: (...) OR (...).
+// Conditions added by one fn are ANDed. fns must add only conditions
+// to passed empty query sets
+func (qs UserQuerySet) Or(fns ...func(qs UserQuerySet) UserQuerySet) UserQuerySet {
+	groups := make([]*gorm.DB, 0, len(fns))
+	for _, fn := range fns {
+		groups = append(groups, fn(UserQuerySet{db: qs.db.New()}).prepare().db)
+	}
+	return qs.w(base.Or(qs.db, groups...))
 }
 
 // OrderAscByCreatedAt is an autogenerated method
@@ -255,76 +1582,204 @@ func (qs UserQuerySet) OrderDescByUpdatedAt() UserQuerySet {
 	return qs.w(qs.db.Order("updated_at DESC"))
 }
 
+// PageCursor returns page of records ordered by ID after opaque cursor
+// (empty for first page) and cursor of next page (empty for last page).
+// Order of query set is replaced by ID order. Not positive size and
+// Limit or Offset of query set are errors.
+func (qs UserQuerySet) PageCursor(after string, size int) (ret []User, nextCursor string, err error) {
+	if size <= 0 {
+		return nil, "", fmt.Errorf("invalid page size %d: it must be positive", size)
+	}
+	if err = base.CheckNoLimitOffset(qs.db); err != nil {
+		return nil, "", err
+	}
+
+	if after != "" {
+		var afterID uint
+		if err = base.DecodeCursor(after, &afterID); err != nil {
+			return nil, "", err
+		}
+		qs = qs.IDGt(afterID)
+	}
+
+	// order of query set is replaced: pages are continued by ID
+	qs = qs.w(qs.db.Order("id ASC", true))
+	if err = qs.Limit(size).All(&ret); err != nil {
+		return nil, "", err
+	}
+
+	if len(ret) != 0 && len(ret) == size {
+		nextCursor = base.EncodeCursor(ret[len(ret)-1].ID)
+	}
+	return ret, nextCursor, nil
+}
+
+// Percentile returns percentile p (0 <= p <= 1) of numeric field values
+// of matching records: PERCENTILE_CONT in PostgreSQL, approximation by
+// nearest rank in MySQL. Not numeric fields and other dialects are errors.
+func (qs UserQuerySet) Percentile(field userDBSchemaField, p float64) (ret float64, err error) {
+	numericColumns := []string{"id", "rating", "rating_marks"}
+	err = qs.exec("Percentile", func(db *gorm.DB) error {
+		ret, err = base.Percentile(db.Model(&User{}), string(field), numericColumns, p)
+		return err
+	})
+	return
+}
+
+// PrevBy finds matching record previous to current by descending order of field
+// into ret, e.g. for navigation on detail page. Ties are resolved by ID.
+// gorm.ErrRecordNotFound is returned if there is no such record.
+func (qs UserQuerySet) PrevBy(field userDBSchemaField, current, ret *User) error {
+	orderedColumns := []string{"id", "created_at", "updated_at", "rating", "rating_marks"}
+	return qs.exec("PrevBy", func(db *gorm.DB) error {
+		return base.Neighbor(db, current, string(field), "id", orderedColumns, false, ret)
+	})
+}
+
 // RatingEq is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingEq(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating = ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "eq", rating, "rating = ?", rating))
 }
 
 // RatingGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingGt(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating > ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "gt", rating, "rating > ?", rating))
 }
 
 // RatingGte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingGte(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating >= ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "gte", rating, "rating >= ?", rating))
+}
+
+// RatingIn filters by rating IN (values): more than base.MaxInListSize
+// values are split into IN lists ORed together. No values match no records.
+func (qs UserQuerySet) RatingIn(rating ...int) UserQuerySet {
+	return qs.w(base.WhereIn(qs.db, "rating", rating))
 }
 
 // RatingLt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingLt(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating < ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "lt", rating, "rating < ?", rating))
 }
 
 // RatingLte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingLte(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating <= ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "lte", rating, "rating <= ?", rating))
 }
 
 // RatingMarksEq is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksEq(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks = ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "eq", ratingMarks, "rating_marks = ?", ratingMarks))
 }
 
 // RatingMarksGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksGt(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks > ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "gt", ratingMarks, "rating_marks > ?", ratingMarks))
 }
 
 // RatingMarksGte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksGte(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks >= ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "gte", ratingMarks, "rating_marks >= ?", ratingMarks))
+}
+
+// RatingMarksIn filters by rating_marks IN (values): more than base.MaxInListSize
+// values are split into IN lists ORed together. No values match no records.
+func (qs UserQuerySet) RatingMarksIn(ratingMarks ...int) UserQuerySet {
+	return qs.w(base.WhereIn(qs.db, "rating_marks", ratingMarks))
 }
 
 // RatingMarksLt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksLt(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks < ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "lt", ratingMarks, "rating_marks < ?", ratingMarks))
 }
 
 // RatingMarksLte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksLte(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks <= ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "lte", ratingMarks, "rating_marks <= ?", ratingMarks))
 }
 
 // RatingMarksNe is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingMarksNe(ratingMarks int) UserQuerySet {
-	return qs.w(qs.db.Where("rating_marks != ?", ratingMarks))
+	return qs.w(base.DescribedWhere(qs.db, "RatingMarks", "ne", ratingMarks, "rating_marks != ?", ratingMarks))
+}
+
+// RatingMarksNotIn filters by rating_marks NOT IN (values): more than base.MaxInListSize
+// values are split into NOT IN lists joined by AND. No values match all records.
+func (qs UserQuerySet) RatingMarksNotIn(ratingMarks ...int) UserQuerySet {
+	return qs.w(base.WhereNotIn(qs.db, "rating_marks", ratingMarks))
 }
 
 // RatingNe is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) RatingNe(rating int) UserQuerySet {
-	return qs.w(qs.db.Where("rating != ?", rating))
+	return qs.w(base.DescribedWhere(qs.db, "Rating", "ne", rating, "rating != ?", rating))
+}
+
+// RatingNotIn filters by rating NOT IN (values): more than base.MaxInListSize
+// values are split into NOT IN lists joined by AND. No values match all records.
+func (qs UserQuerySet) RatingNotIn(rating ...int) UserQuerySet {
+	return qs.w(base.WhereNotIn(qs.db, "rating", rating))
+}
+
+// Restore restores soft deleted records matching query set
+// and returns count of restored records
+func (qs UserQuerySet) Restore() (ret int64, err error) {
+	err = qs.exec("Restore", func(db *gorm.DB) error {
+		res := db.Unscoped().Model(&User{}).
+			Where("deleted_at IS NOT NULL").
+			UpdateColumn("deleted_at", gorm.Expr("NULL"))
+		ret, err = res.RowsAffected, res.Error
+		return err
+	})
+	return
+}
+
+// ResultHash returns stable hash of matching records values:
+// it changes only if any of these records is changed, added or removed
+func (qs UserQuerySet) ResultHash() (ret string, err error) {
+	err = qs.exec("ResultHash", func(db *gorm.DB) error {
+		ret, err = base.ResultHash(db.Model(&User{}))
+		return err
+	})
+	return
+}
+
+// ScalarSubQuery returns subquery selecting aggregate agg of field
+// of matching records, e.g. SELECT AVG(age) FROM users WHERE ...,
+// to compare fields with it by Field{Op}ScalarSubQuery methods
+func (qs UserQuerySet) ScalarSubQuery(agg base.Aggregate, field userDBSchemaField) base.SubQuery {
+	return base.ScalarSubQuery(qs.scopedDB(), &User{}, agg, string(field))
+}
+
+// Scopes applies GORM scopes fns to db of query set, e.g. to add raw
+// clause which can't be expressed by query set and continue the chain
+func (qs UserQuerySet) Scopes(fns ...func(*gorm.DB) *gorm.DB) UserQuerySet {
+	return qs.w(qs.db.Scopes(fns...))
+}
+
+// Select restricts columns selected by All, One, etc to fields,
+// other fields of records are zero. Without fields all columns are selected.
+func (qs UserQuerySet) Select(fields ...userDBSchemaField) UserQuerySet {
+	if len(fields) == 0 {
+		return qs
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		columns = append(columns, string(f))
+	}
+	return qs.w(qs.db.Select(columns))
 }
 
 // SetCreatedAt is an autogenerated method
@@ -334,6 +1789,23 @@ func (u UserUpdater) SetCreatedAt(createdAt time.Time) UserUpdater {
 	return u
 }
 
+// SetFieldForAll sets field to value for all matching records by one UPDATE
+// and returns count of updated records. Query set must have conditions
+// or update of all records must be allowed by AllowGlobalUpdate
+func (qs UserQuerySet) SetFieldForAll(field userDBSchemaField, value interface{}) (ret int64, err error) {
+	qs = qs.prepare()
+	if err = base.CheckConditions(qs.db); err != nil {
+		return 0, err
+	}
+
+	err = qs.exec("SetFieldForAll", func(db *gorm.DB) error {
+		res := db.Model(&User{}).UpdateColumn(string(field), value)
+		ret, err = res.RowsAffected, res.Error
+		return err
+	})
+	return
+}
+
 // SetID is an autogenerated method
 // nolint: dupl
 func (u UserUpdater) SetID(ID uint) UserUpdater {
@@ -362,113 +1834,333 @@ func (u UserUpdater) SetUpdatedAt(updatedAt time.Time) UserUpdater {
 	return u
 }
 
+// SumRating returns SUM of field Rating of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) SumRating() (ret int, err error) {
+	err = qs.exec("SumRating", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateSum, "rating", &ret)
+		return err
+	})
+	return
+}
+
+// SumRatingMarks returns SUM of field RatingMarks of matching records:
+// zero is returned if there are no records
+func (qs UserQuerySet) SumRatingMarks() (ret int, err error) {
+	err = qs.exec("SumRatingMarks", func(db *gorm.DB) error {
+		err = base.SelectAggregate(db.Model(&User{}), base.AggregateSum, "rating_marks", &ret)
+		return err
+	})
+	return
+}
+
+// UnlockTables releases MySQL table locks of transaction of query set
+// acquired by LockTable. PostgreSQL locks are released at the end
+// of transaction: it's an error.
+func (qs UserQuerySet) UnlockTables() error {
+	return base.UnlockTables(qs.db)
+}
+
+// Unscoped selects both soft deleted and not deleted records
+func (qs UserQuerySet) Unscoped() UserQuerySet {
+	return qs.w(qs.db.Unscoped())
+}
+
 // Update is an autogenerated method
 // nolint: dupl
 func (u UserUpdater) Update() error {
-	return u.db.Updates(u.fields).Error
+	if err := base.CheckContext(u.db); err != nil {
+		return err
+	}
+	return base.Retry(u.db, func() error {
+		return u.db.Updates(u.fields).Error
+	})
+}
+
+// UpdateNum is like Update, but returns count of updated records:
+// it's 0 if guard conditions (e.g. of WhereFieldGte) aren't met
+func (u UserUpdater) UpdateNum() (int64, error) {
+	if err := base.CheckContext(u.db); err != nil {
+		return 0, err
+	}
+	var n int64
+	err := base.Retry(u.db, func() error {
+		res := u.db.Updates(u.fields)
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err
 }
 
 // UpdatedAtEq is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtEq(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at = ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "eq", updatedAt, "updated_at = ?", updatedAt))
 }
 
 // UpdatedAtGt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtGt(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at > ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "gt", updatedAt, "updated_at > ?", updatedAt))
 }
 
 // UpdatedAtGte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtGte(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at >= ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "gte", updatedAt, "updated_at >= ?", updatedAt))
 }
 
 // UpdatedAtLt is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtLt(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at < ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "lt", updatedAt, "updated_at < ?", updatedAt))
 }
 
 // UpdatedAtLte is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtLte(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at <= ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "lte", updatedAt, "updated_at <= ?", updatedAt))
 }
 
 // UpdatedAtNe is an autogenerated method
 // nolint: dupl
 func (qs UserQuerySet) UpdatedAtNe(updatedAt time.Time) UserQuerySet {
-	return qs.w(qs.db.Where("updated_at != ?", updatedAt))
+	return qs.w(base.DescribedWhere(qs.db, "UpdatedAt", "ne", updatedAt, "updated_at != ?", updatedAt))
+}
+
+// UpdatedAtOnDateInLocation filters records by calendar day of date in location loc.
+// Boundaries of this day are converted to UTC: [00:00, 24:00) of the day in loc
+func (qs UserQuerySet) UpdatedAtOnDateInLocation(date time.Time, loc *time.Location) UserQuerySet {
+	y, m, d := date.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	return qs.w(qs.db.Where("updated_at >= ? AND updated_at < ?", from.UTC(), to.UTC()))
+}
+
+// UpdatedAtThisMonth filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) UpdatedAtThisMonth(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodMonth)
+	return qs.w(qs.db.Where("updated_at >= ? AND updated_at < ?", from.UTC(), to.UTC()))
+}
+
+// UpdatedAtThisWeek filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) UpdatedAtThisWeek(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodWeek)
+	return qs.w(qs.db.Where("updated_at >= ? AND updated_at < ?", from.UTC(), to.UTC()))
+}
+
+// UpdatedAtToday filters records by current calendar period in location loc
+// (weeks start on Monday): its boundaries are computed at call time and
+// converted to UTC, range is half-open [from, to)
+func (qs UserQuerySet) UpdatedAtToday(loc *time.Location) UserQuerySet {
+	from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.PeriodDay)
+	return qs.w(qs.db.Where("updated_at >= ? AND updated_at < ?", from.UTC(), to.UTC()))
+}
+
+// UsePrimary routes query set to primary handle of resolver set by
+// base.WithResolver, e.g. to read own writes. It must be called before
+// adding of conditions.
+func (qs UserQuerySet) UsePrimary() UserQuerySet {
+	return qs.w(base.UsePrimary(qs.db))
+}
+
+// UseReplica routes query set to replica handle of resolver set by
+// base.WithResolver. It must be called before adding of conditions.
+// Writes of structs by replica handle (e.g. Create) are routed to primary.
+func (qs UserQuerySet) UseReplica() UserQuerySet {
+	return qs.w(base.UseReplica(qs.db))
+}
+
+// UserCreateBatch creates User records by multi-row INSERTs split
+// by base.MaxBatchVars in one transaction. Records are validated and their
+// auto time fields are set like by Create before the first INSERT.
+// Auto-increment IDs are set only in PostgreSQL (by RETURNING), they stay
+// zero in other databases. Hooks aren't called
+func UserCreateBatch(db *gorm.DB, records []User) error {
+	return base.CreateBatch(db, records)
+}
+
+// UserCreateFromChan creates User records received from ch by bulk
+// INSERTs of up to batchSize records until ch is closed, e.g. for streaming
+// ingestion, and returns count of created records. Records are validated and
+// their auto time fields are set like by Create, but hooks aren't called and
+// IDs of created records aren't set
+func UserCreateFromChan(db *gorm.DB, ch <-chan User, batchSize int) (int64, error) {
+	return base.CreateFromChan(db, ch, batchSize, nil)
+}
+
+// UserSchemaJSON returns JSON with fields of User: their names,
+// columns, types, nullability and primary key flags, e.g. for admin UIs
+func UserSchemaJSON() []byte {
+	return []byte(`{
+	"model": "User",
+	"fields": [
+		{
+			"name": "ID",
+			"column": "id",
+			"type": "uint",
+			"nullable": false,
+			"primaryKey": true
+		},
+		{
+			"name": "CreatedAt",
+			"column": "created_at",
+			"type": "time.Time",
+			"nullable": false,
+			"primaryKey": false
+		},
+		{
+			"name": "UpdatedAt",
+			"column": "updated_at",
+			"type": "time.Time",
+			"nullable": false,
+			"primaryKey": false
+		},
+		{
+			"name": "DeletedAt",
+			"column": "deleted_at",
+			"type": "*time.Time",
+			"nullable": true,
+			"primaryKey": false
+		},
+		{
+			"name": "Rating",
+			"column": "rating",
+			"type": "int",
+			"nullable": false,
+			"primaryKey": false
+		},
+		{
+			"name": "RatingMarks",
+			"column": "rating_marks",
+			"type": "int",
+			"nullable": false,
+			"primaryKey": false
+		}
+	]
+}`)
+}
+
+// ValueInFieldRange selects records with value within range of their
+// fields: lowField <= value AND highField >= value, e.g. pricing tier
+// of amount. Fields of different types are errors.
+func (qs UserQuerySet) ValueInFieldRange(lowField, highField userDBSchemaField, value interface{}) UserQuerySet {
+	columnTypes := map[string]string{"id": "uint", "created_at": "time.Time", "updated_at": "time.Time", "rating": "int", "rating_marks": "int"}
+	return qs.w(base.ValueInFieldRange(qs.db, string(lowField), string(highField), value, columnTypes))
+}
+
+// VerifyUserSchema checks that table of User has all columns of model
+// with compatible types, e.g. to detect forgotten migrations on startup
+func VerifyUserSchema(db *gorm.DB) error {
+	return base.VerifySchema(db, &User{}, map[string]string{
+		"id":           base.ColumnKindNumeric,
+		"created_at":   base.ColumnKindTime,
+		"updated_at":   base.ColumnKindTime,
+		"deleted_at":   base.ColumnKindTime,
+		"rating":       base.ColumnKindNumeric,
+		"rating_marks": base.ColumnKindNumeric,
+	})
+}
+
+// WhereFieldEq adds guard condition field = value to WHERE of update:
+// use it to update record only if it's still in expected state,
+// e.g. optimistic locking by version
+func (u UserUpdater) WhereFieldEq(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" = ?", value)
+	return u
 }
 
-// ===== END of query set UserQuerySet
+// WhereFieldGt adds guard condition field > value to WHERE of update:
+// use it to decrement balance only while it stays positive
+func (u UserUpdater) WhereFieldGt(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" > ?", value)
+	return u
+}
 
-// ===== BEGIN of User modifiers
+// WhereFieldGte adds guard condition field >= value to WHERE of update:
+// use it to decrement stock only if it's sufficient
+func (u UserUpdater) WhereFieldGte(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" >= ?", value)
+	return u
+}
 
-type userDBSchemaField string
+// WhereFieldLt adds guard condition field < value to WHERE of update:
+// use it to increment counter only while it's below limit
+func (u UserUpdater) WhereFieldLt(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" < ?", value)
+	return u
+}
 
-// UserDBSchema stores db field names of User
-var UserDBSchema = struct {
-	ID          userDBSchemaField
-	CreatedAt   userDBSchemaField
-	UpdatedAt   userDBSchemaField
-	DeletedAt   userDBSchemaField
-	Rating      userDBSchemaField
-	RatingMarks userDBSchemaField
-}{
+// WhereFieldLte adds guard condition field <= value to WHERE of update:
+// use it to raise value only while it doesn't exceed cap
+func (u UserUpdater) WhereFieldLte(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" <= ?", value)
+	return u
+}
 
-	ID:          userDBSchemaField("id"),
-	CreatedAt:   userDBSchemaField("created_at"),
-	UpdatedAt:   userDBSchemaField("updated_at"),
-	DeletedAt:   userDBSchemaField("deleted_at"),
-	Rating:      userDBSchemaField("rating"),
-	RatingMarks: userDBSchemaField("rating_marks"),
+// WhereFieldNe adds guard condition field != value to WHERE of update:
+// use it to skip records already having the new value,
+// so UpdateNum counts only changed ones
+func (u UserUpdater) WhereFieldNe(field userDBSchemaField, value interface{}) UserUpdater {
+	u.db = u.db.Where(string(field)+" != ?", value)
+	return u
 }
 
-// Update updates User fields by primary key
-func (o *User) Update(db *gorm.DB, fields ...userDBSchemaField) error {
-	dbNameToFieldName := map[string]interface{}{
-		"id":           o.ID,
-		"created_at":   o.CreatedAt,
-		"updated_at":   o.UpdatedAt,
-		"deleted_at":   o.DeletedAt,
-		"rating":       o.Rating,
-		"rating_marks": o.RatingMarks,
+// WhereMap selects records with fields equal to values of conditions
+// by fields of UserDBSchema. Unknown fields and values of types different
+// from types of fields are errors with field: they are checked before
+// querying. Nil is IS NULL for pointer fields.
+func (qs UserQuerySet) WhereMap(conditions UserConditions) (UserQuerySet, error) {
+	columns := make(map[string]interface{}, len(conditions))
+	for f, v := range conditions {
+		columns[string(f)] = v
 	}
-	u := map[string]interface{}{}
-	for _, f := range fields {
-		fs := string(f)
-		u[fs] = dbNameToFieldName[fs]
-	}
-	if err := db.Model(o).Updates(u).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return err
-		}
-
-		return fmt.Errorf("can't update User %v fields %v: %s",
-			o, fields, err)
+	db, err := base.WhereMap(qs.db, &User{}, columns)
+	if err != nil {
+		return qs, err
 	}
+	return qs.w(db), nil
+}
 
-	return nil
+// WithAdvisoryLock acquires PostgreSQL advisory lock key by pg_advisory_xact_lock
+// just before execution of query. Query set must be made on transaction:
+// lock is released at the end of it.
+func (qs UserQuerySet) WithAdvisoryLock(key int64) UserQuerySet {
+	return qs.Defer(func(qs UserQuerySet) UserQuerySet {
+		return qs.w(base.AdvisoryXactLock(qs.db, key))
+	})
 }
 
-// UserUpdater is an User updates manager
-type UserUpdater struct {
-	fields map[string]interface{}
-	db     *gorm.DB
+// WithContext sets context of terminal operations and updater: they return
+// its error without executing queries if it's done. Already executing
+// query isn't cancelled: GORM v1 has no context API
+func (qs UserQuerySet) WithContext(ctx context.Context) UserQuerySet {
+	return qs.w(base.WithContext(qs.db, ctx))
 }
 
-// NewUserUpdater creates new User updater
-func NewUserUpdater(db *gorm.DB) UserUpdater {
-	return UserUpdater{
-		fields: map[string]interface{}{},
-		db:     db.Model(&User{}),
-	}
+// WithRetry makes terminal operations and updater retry up to attempts
+// times on deadlocks and serialization failures after backoff doubled
+// by every retry. Other errors are returned immediately. Operations in
+// transactions aren't retried: the whole transaction must be retried
+func (qs UserQuerySet) WithRetry(attempts int, backoff time.Duration) UserQuerySet {
+	return qs.w(base.WithRetry(qs.db, attempts, backoff))
 }
 
-// ===== END of User modifiers
+// WithTracer sets tracer of terminal operations: every operation is executed
+// in span named {QuerySet}.{Operation} with executed SQL as attribute
+func (qs UserQuerySet) WithTracer(tracer base.Tracer) UserQuerySet {
+	return qs.w(base.WithTracer(qs.db, tracer))
+}
 
-// ===== END of all query sets
+// WriteNDJSON writes every matching record to w as JSON object on its own
+// line (newline-delimited JSON). Records are loaded by chunks by EachRow.
+func (qs UserQuerySet) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return qs.EachRow(func(o User) error {
+		return enc.Encode(o)
+	})
+}
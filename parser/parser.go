@@ -31,8 +31,11 @@ type ParsedStruct struct {
 }
 
 func fileNameToPkgName(filePath string) string {
-	return strings.TrimPrefix(filepath.Dir(filePath),
-		fmt.Sprintf("%s/src/", os.Getenv("GOPATH")))
+	return dirToPkgName(filepath.Dir(filePath))
+}
+
+func dirToPkgName(dir string) string {
+	return strings.TrimPrefix(dir, fmt.Sprintf("%s/src/", os.Getenv("GOPATH")))
 }
 
 func typeCheckFuncBodies(path string) bool {
@@ -101,7 +104,52 @@ func GetStructsInFile(filePath string) (*loader.PackageInfo, ParsedStructs, erro
 		return nil, nil, fmt.Errorf("can't get struct names: %s", err)
 	}
 
-	packageFullName := fileNameToPkgName(absFilePath)
+	return getStructsInPackage(fileNameToPkgName(absFilePath), neededStructs)
+}
+
+// GetStructsInDir lists all structures in all Go files of package in directory
+// dirPath except test files and files excludeFiles (e.g. previously generated
+// output) and returns them with all fields. Structs of one file can reference
+// types of another one.
+func GetStructsInDir(dirPath string, excludeFiles ...string) (*loader.PackageInfo, ParsedStructs, error) {
+	absDirPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't get abs path for %s", dirPath)
+	}
+
+	excluded := map[string]bool{}
+	for _, f := range excludeFiles {
+		absFile, err := filepath.Abs(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't get abs path for %s", f)
+		}
+		excluded[absFile] = true
+	}
+
+	fileNames, err := filepath.Glob(filepath.Join(absDirPath, "*.go"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't list Go files in %s: %s", dirPath, err)
+	}
+
+	neededStructs := structNamesInfo{}
+	for _, fname := range fileNames {
+		if excluded[fname] || strings.HasSuffix(fname, "_test.go") {
+			continue
+		}
+
+		names, err := getStructNamesInFile(fname)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't get struct names: %s", err)
+		}
+		for name, decl := range names {
+			neededStructs[name] = decl
+		}
+	}
+
+	return getStructsInPackage(dirToPkgName(absDirPath), neededStructs)
+}
+
+func getStructsInPackage(packageFullName string, neededStructs structNamesInfo) (*loader.PackageInfo, ParsedStructs, error) {
 	lprog, err := loadProgramFromPackage(packageFullName)
 	if err != nil {
 		return nil, nil, err
@@ -109,8 +157,7 @@ func GetStructsInFile(filePath string) (*loader.PackageInfo, ParsedStructs, erro
 
 	pkgInfo := lprog.Package(packageFullName)
 	if pkgInfo == nil {
-		return nil, nil, fmt.Errorf("can't load types for file %s in package %q",
-			filePath, packageFullName)
+		return nil, nil, fmt.Errorf("can't load types of package %q", packageFullName)
 	}
 
 	ret := ParsedStructs{}
@@ -305,3 +305,60 @@ func testStructFields(t *testing.T, tc structFieldsCase) {
 		assert.Equal(t, tc.expectedDoc, docLines)
 	}
 }
+
+func getTmpDirForFiles(files map[string]string) string {
+	tmpDir, err := ioutil.TempDir(getTempDirRoot(), "tmptestdir")
+	if err != nil {
+		log.Fatalf("can't create temp dir: %s", err)
+	}
+
+	for name, code := range files {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(code), 0600); err != nil {
+			log.Fatalf("can't write temp file %q: %s", name, err)
+		}
+	}
+	return tmpDir
+}
+
+func TestGetStructsInDir(t *testing.T) {
+	dir := getTmpDirForFiles(map[string]string{
+		"t.go": `package p
+			// gen:qs
+			type T struct {
+				m
+				F int
+			}`,
+		"m.go": `package p
+			type m struct {
+				ID int
+			}`,
+		"t_test.go": `package p
+			type testT struct {
+				F int
+			}`,
+		"autogenerated.go": `package p
+			type TQuerySet struct {
+				F int
+			}`,
+	})
+	defer os.RemoveAll(dir)
+
+	pkg, structs, err := GetStructsInDir(dir, filepath.Join(dir, "autogenerated.go"))
+	assert.Nil(t, err)
+	assert.NotNil(t, pkg)
+	assert.Len(t, structs, 2)
+	assert.Len(t, structs["T"].Fields, 2) // ID of m from another file and F
+	assert.NotNil(t, structs["T"].Doc)
+	assert.Contains(t, structs, "m")
+
+	brokenDir := getTmpDirForFiles(map[string]string{
+		"t.go":      "package p\ntype T struct {F int}",
+		"broken.go": "package p\ntype B struct {",
+	})
+	defer os.RemoveAll(brokenDir)
+
+	_, _, err = GetStructsInDir(brokenDir)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), filepath.Join(brokenDir, "broken.go"))
+	}
+}
@@ -0,0 +1,75 @@
+package queryset
+
+import (
+	"text/template"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jirfag/go-queryset/queryset/methods"
+)
+
+// HasManyInfo is a has-many association of struct: slice of structs field.
+// Records of ElemTypeName reference struct by {StructName}ID field.
+type HasManyInfo struct {
+	Name         string // name of field
+	ElemTypeName string // name of type of slice elements
+}
+
+// StructInfo is a result of analysis of struct to generate query set for
+type StructInfo struct {
+	Name    string
+	Fields  []FieldInfo
+	HasMany []HasManyInfo
+
+	// SliceAssociations are names of fields which are slices of parsed
+	// structs or pointers to them: they are loaded by Preload
+	SliceAssociations []string
+
+	// ReadOnly is set by "gen:qs readOnly" annotation: no methods
+	// creating, updating or deleting records must be generated
+	ReadOnly bool
+
+	// WrapErrors is set by "gen:qs wrapErrors" annotation: errors of terminal
+	// methods must be wrapped with query set and method names
+	WrapErrors bool
+
+	// SQLComments is set by "gen:qs sqlComments" annotation: queries of
+	// terminal methods must have comment with query set and method names
+	SQLComments bool
+
+	// ActiveFlagField is a bool field set by "gen:qs activeFlag:is_active"
+	// annotation: it's used for soft delete instead of deleted_at, records
+	// with false value are deleted
+	ActiveFlagField *FieldInfo
+
+	// TenantField is a field set by "gen:qs tenantColumn:tenant_id"
+	// annotation: query set is constructed for tenant and all its queries,
+	// updates and deletes have condition by this field
+	TenantField *FieldInfo
+}
+
+func (s StructInfo) getFieldByDBName(dbName string) *FieldInfo {
+	for i := range s.Fields {
+		if gorm.ToDBName(s.Fields[i].Name) == dbName {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Backend is a query builder targeted by generated code. Analysis of
+// structs is the same for all backends and generator emits methods of
+// backend for every struct: backend only declares types they use.
+type Backend interface {
+	// GetMethods returns methods of query set (and related types) for struct
+	GetMethods(s StructInfo) []methods.Method
+
+	// GetDeclarationsTemplate returns template of declarations of struct
+	// used by its methods, e.g. query set type and its constructor. It's
+	// executed for every struct with fields StructName, Name (of query set
+	// type), Fields and Info (StructInfo). Methods are emitted after it.
+	GetDeclarationsTemplate() *template.Template
+
+	// GetImports returns import specs of generated file, e.g.
+	// `sq "github.com/Masterminds/squirrel"`: they are written to its header
+	GetImports() []string
+}
@@ -0,0 +1,35 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AdvisoryXactLock acquires PostgreSQL transaction level advisory lock key
+// by pg_advisory_xact_lock: it's released at the end of transaction db.
+// Errors are returned as errors of db.
+func AdvisoryXactLock(db *gorm.DB, key int64) *gorm.DB {
+	if dialect := db.NewScope(nil).Dialect().GetName(); dialect != "postgres" {
+		return withError(db, fmt.Errorf("advisory locks aren't supported by %s", dialect))
+	}
+
+	if !isInTransaction(db) {
+		return withError(db, errors.New("advisory lock must be acquired in transaction"))
+	}
+
+	if err := db.Exec("SELECT pg_advisory_xact_lock(?)", key).Error; err != nil {
+		return withError(db, fmt.Errorf("can't acquire advisory lock %d: %s", key, err))
+	}
+
+	return db
+}
+
+// withError returns copy of db with error err: db can be shared
+// by many query sets, so it mustn't be changed
+func withError(db *gorm.DB, err error) *gorm.DB {
+	db = db.Set("queryset:error", err) // Set returns copy of db
+	db.AddError(err)
+	return db
+}
@@ -0,0 +1,384 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DuplicateGroup is a value of field met in Count (> 1) records
+type DuplicateGroup struct {
+	Value interface{}
+	Count int
+}
+
+// FindDuplicates returns groups of records of db model having the same field value
+func FindDuplicates(db *gorm.DB, field string) ([]DuplicateGroup, error) {
+	rows, err := db.Select(field + ", count(*)").
+		Group(field).
+		Having("count(*) > 1").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select duplicates of %s: %s", field, err)
+	}
+	defer rows.Close()
+
+	ret := []DuplicateGroup{}
+	for rows.Next() {
+		var g DuplicateGroup
+		if err = rows.Scan(&g.Value, &g.Count); err != nil {
+			return nil, fmt.Errorf("can't scan duplicates of %s: %s", field, err)
+		}
+		g.Value = normalizeValue(g.Value)
+		ret = append(ret, g)
+	}
+
+	return ret, rows.Err()
+}
+
+// TwoFieldCount is a count of records having values A and B of two fields
+type TwoFieldCount struct {
+	A     interface{}
+	B     interface{}
+	Count int
+}
+
+// CountByTwoFields returns counts of records of db model grouped by fields a and b
+func CountByTwoFields(db *gorm.DB, a, b string) ([]TwoFieldCount, error) {
+	rows, err := db.Select(a + ", " + b + ", count(*)").
+		Group(a + ", " + b).
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select counts grouped by %s, %s: %s", a, b, err)
+	}
+	defer rows.Close()
+
+	ret := []TwoFieldCount{}
+	for rows.Next() {
+		var c TwoFieldCount
+		if err = rows.Scan(&c.A, &c.B, &c.Count); err != nil {
+			return nil, fmt.Errorf("can't scan counts grouped by %s, %s: %s", a, b, err)
+		}
+		c.A = normalizeValue(c.A)
+		c.B = normalizeValue(c.B)
+		ret = append(ret, c)
+	}
+
+	return ret, rows.Err()
+}
+
+// Facet is a count of records having distinct Value of field
+type Facet struct {
+	Value interface{}
+	Count int
+}
+
+// FacetField returns distinct values of column of db model records with
+// their counts, e.g. for faceted search: conditions of db are applied
+func FacetField(db *gorm.DB, column string) ([]Facet, error) {
+	rows, err := db.Select(column + ", count(*)").
+		Group(column).
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select facets of %s: %s", column, err)
+	}
+	defer rows.Close()
+
+	ret := []Facet{}
+	for rows.Next() {
+		var f Facet
+		if err = rows.Scan(&f.Value, &f.Count); err != nil {
+			return nil, fmt.Errorf("can't scan facets of %s: %s", column, err)
+		}
+		f.Value = normalizeValue(f.Value)
+		ret = append(ret, f)
+	}
+
+	return ret, rows.Err()
+}
+
+// Bucket is a count of records with values of field in [From, To)
+type Bucket struct {
+	From  float64
+	To    float64
+	Count int
+}
+
+// Histogram returns counts of records of db model grouped by buckets of size
+// bucketSize of values of column: GROUP BY FLOOR(column / bucketSize). Buckets
+// are ordered by values, empty buckets and NULL values are skipped. Column
+// must be from numericColumns: it's an error otherwise.
+func Histogram(db *gorm.DB, column string, numericColumns []string, bucketSize float64) ([]Bucket, error) {
+	if !isColumnOf(column, numericColumns) {
+		return nil, fmt.Errorf("can't build histogram of field %q: it isn't numeric field", column)
+	}
+	if bucketSize <= 0 {
+		return nil, fmt.Errorf("invalid bucket size %v: it must be positive", bucketSize)
+	}
+
+	// PostgreSQL infers type of parameter from column: integer division would
+	// truncate fractional bucket size
+	divisor := "?"
+	if db.NewScope(nil).Dialect().GetName() == "postgres" {
+		divisor = "CAST(? AS DOUBLE PRECISION)"
+	}
+
+	rows, err := db.Select(fmt.Sprintf("FLOOR(%s / %s) AS bucket, count(*)", column, divisor), bucketSize).
+		Where(column + " IS NOT NULL").
+		Group("bucket").
+		Order("bucket").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select histogram of %s: %s", column, err)
+	}
+	defer rows.Close()
+
+	ret := []Bucket{}
+	for rows.Next() {
+		var b Bucket
+		var n float64
+		if err = rows.Scan(&n, &b.Count); err != nil {
+			return nil, fmt.Errorf("can't scan histogram of %s: %s", column, err)
+		}
+		b.From = n * bucketSize
+		b.To = b.From + bucketSize
+		ret = append(ret, b)
+	}
+
+	return ret, rows.Err()
+}
+
+// RollupEntry is a count of records having Value of field or, for the
+// summary row with Total set, a grand total count of all records
+type RollupEntry struct {
+	Value interface{}
+	Count int
+	Total bool
+}
+
+// CountByFieldWithRollup returns counts of records of db model grouped by
+// column and a summary row with grand total: GROUP BY column WITH ROLLUP
+// in MySQL (8.0+), GROUP BY GROUPING SETS ((column), ()) in PostgreSQL.
+// Summary row is marked by GROUPING(column), so it's distinct from group
+// of NULL values.
+func CountByFieldWithRollup(db *gorm.DB, column string) ([]RollupEntry, error) {
+	var group string
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "mysql":
+		group = column + " WITH ROLLUP"
+	case "postgres":
+		group = fmt.Sprintf("GROUPING SETS ((%s), ())", column)
+	default:
+		return nil, fmt.Errorf("count with rollup isn't supported by %s", dialect)
+	}
+
+	rows, err := db.Select(fmt.Sprintf("%s, GROUPING(%s), count(*)", column, column)).
+		Group(group).
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select counts with rollup grouped by %s: %s", column, err)
+	}
+	defer rows.Close()
+
+	ret := []RollupEntry{}
+	for rows.Next() {
+		var e RollupEntry
+		var grouping int
+		if err = rows.Scan(&e.Value, &grouping, &e.Count); err != nil {
+			return nil, fmt.Errorf("can't scan counts with rollup grouped by %s: %s", column, err)
+		}
+		e.Value = normalizeValue(e.Value)
+		e.Total = grouping == 1
+		ret = append(ret, e)
+	}
+
+	return ret, rows.Err()
+}
+
+// normalizeValue converts []byte scanned by some drivers for text columns to string
+func normalizeValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// IsEmpty checks that there are no records of db model by selecting at most one record
+func IsEmpty(db *gorm.DB) (bool, error) {
+	rows, err := db.Select("1").Limit(1).Rows()
+	if err != nil {
+		return false, fmt.Errorf("can't check existence of records: %s", err)
+	}
+	defer rows.Close()
+
+	empty := !rows.Next()
+	return empty, rows.Err()
+}
+
+// MinMax selects MIN and MAX of column of db model records by one query and
+// stores them into values pointed by min and max. If there are no matching
+// records MIN and MAX are NULL: pointed values aren't changed then.
+func MinMax(db *gorm.DB, column string, min, max interface{}) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	// scan into pointers to values: NULL is scanned as nil pointer
+	minPtr := reflect.New(reflect.TypeOf(min))
+	maxPtr := reflect.New(reflect.TypeOf(max))
+	err := db.Select(fmt.Sprintf("MIN(%s), MAX(%s)", column, column)).
+		Row().
+		Scan(minPtr.Interface(), maxPtr.Interface())
+	if err != nil {
+		return err
+	}
+
+	if !minPtr.Elem().IsNil() {
+		reflect.ValueOf(min).Elem().Set(minPtr.Elem().Elem())
+	}
+	if !maxPtr.Elem().IsNil() {
+		reflect.ValueOf(max).Elem().Set(maxPtr.Elem().Elem())
+	}
+	return nil
+}
+
+// HourCount is a count of records in hour bucket starting at Hour
+type HourCount struct {
+	Hour  time.Time
+	Count int
+}
+
+const hourLayout = "2006-01-02 15:04:05"
+
+// hourTruncationSQL returns SQL expression truncating time column to hour
+func hourTruncationSQL(dialect, column string) (string, error) {
+	switch dialect {
+	case "mysql":
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00:00')", column), nil
+	case "postgres":
+		return fmt.Sprintf("date_trunc('hour', %s)", column), nil
+	case "sqlite3":
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:00:00', %s)", column), nil
+	default:
+		return "", fmt.Errorf("count by hour isn't supported by dialect %s", dialect)
+	}
+}
+
+// CountByHour returns counts of records of db model grouped by hour of time
+// column ordered chronologically. Column must be from timeColumns: it's an
+// error otherwise.
+func CountByHour(db *gorm.DB, column string, timeColumns []string) ([]HourCount, error) {
+	if !isColumnOf(column, timeColumns) {
+		return nil, fmt.Errorf("can't count by hour of field %q: it isn't time field", column)
+	}
+
+	hour, err := hourTruncationSQL(db.NewScope(nil).Dialect().GetName(), column)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Select(hour + " AS hour, count(*)").
+		Group("hour").
+		Order("hour").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("can't select counts by hour of %s: %s", column, err)
+	}
+	defer rows.Close()
+
+	ret := []HourCount{}
+	for rows.Next() {
+		var h HourCount
+		var v interface{}
+		if err = rows.Scan(&v, &h.Count); err != nil {
+			return nil, fmt.Errorf("can't scan counts by hour of %s: %s", column, err)
+		}
+		if h.Hour, err = parseHour(normalizeValue(v)); err != nil {
+			return nil, fmt.Errorf("can't scan counts by hour of %s: %s", column, err)
+		}
+		ret = append(ret, h)
+	}
+
+	return ret, rows.Err()
+}
+
+// parseHour parses hour returned as time or as formatted string (e.g. by DATE_FORMAT)
+func parseHour(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(hourLayout, v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid hour %v of type %T", v, v)
+	}
+}
+
+// SelectAggregate selects aggregate agg (SUM, AVG, MIN or MAX) of column
+// of db model records into ret (pointer): zero value is stored if there
+// are no records (agg is NULL then)
+func SelectAggregate(db *gorm.DB, agg Aggregate, column string, ret interface{}) error {
+	// scan into pointer to value: NULL is scanned as nil pointer
+	p := reflect.New(reflect.TypeOf(ret))
+	err := db.Select(fmt.Sprintf("%s(%s)", agg, column)).Row().Scan(p.Interface())
+	if err != nil {
+		return fmt.Errorf("can't select %s of %s: %s", agg, column, err)
+	}
+
+	v := reflect.ValueOf(ret).Elem()
+	if p.Elem().IsNil() {
+		v.Set(reflect.Zero(v.Type()))
+	} else {
+		v.Set(p.Elem().Elem())
+	}
+	return nil
+}
+
+// Percentile returns percentile p (0 <= p <= 1) of column values of db model
+// records, 0 if there are no records. PostgreSQL computes it by PERCENTILE_CONT.
+// MySQL has no percentile functions: nearest value at position p * (count - 1)
+// of ordered values is returned as approximation. Column must be from
+// numericColumns: it's an error otherwise. Other dialects aren't supported.
+func Percentile(db *gorm.DB, column string, numericColumns []string, p float64) (float64, error) {
+	if !isColumnOf(column, numericColumns) {
+		return 0, fmt.Errorf("can't compute percentile of field %q: it isn't numeric field", column)
+	}
+	if p < 0 || p > 1 {
+		return 0, fmt.Errorf("invalid percentile %v: it must be in [0, 1]", p)
+	}
+
+	var v sql.NullFloat64
+	var err error
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "postgres":
+		err = db.Select(fmt.Sprintf("PERCENTILE_CONT(?) WITHIN GROUP (ORDER BY %s)", column), p).
+			Row().
+			Scan(&v)
+	case "mysql":
+		v, err = approxPercentile(db.Where(column+" IS NOT NULL"), column, p)
+	default:
+		return 0, fmt.Errorf("percentile isn't supported by dialect %s", dialect)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't select percentile %v of %s: %s", p, column, err)
+	}
+	return v.Float64, nil
+}
+
+// approxPercentile selects value at position p * (count - 1) of ordered
+// column values: it's a percentile by nearest rank
+func approxPercentile(db *gorm.DB, column string, p float64) (sql.NullFloat64, error) {
+	var v sql.NullFloat64
+	var n int
+	if err := db.Count(&n).Error; err != nil || n == 0 {
+		return v, err
+	}
+
+	offset := int(math.Round(p * float64(n-1)))
+	err := db.Select(column).Order(column, true).Offset(offset).Limit(1).Row().Scan(&v)
+	return v, err
+}
@@ -0,0 +1,2 @@
+// Package base contains runtime helpers used by generated query sets code
+package base
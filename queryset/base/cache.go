@@ -0,0 +1,44 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cache is a storage of serialized query results
+type Cache interface {
+	// Get returns value by key, ok is false if there is no value
+	Get(key string) (value []byte, ok bool, err error)
+
+	// Set stores value by key for ttl
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// Cached loads ret from cache by key. On cache miss it calls load to fill ret
+// and stores ret in cache for ttl. Values are serialized to JSON.
+func Cached(cache Cache, key string, ttl time.Duration, ret interface{}, load func() error) error {
+	data, ok, err := cache.Get(key)
+	if err != nil {
+		return fmt.Errorf("can't get %q from cache: %s", key, err)
+	}
+	if ok {
+		if err = json.Unmarshal(data, ret); err != nil {
+			return fmt.Errorf("can't decode %q from cache: %s", key, err)
+		}
+		return nil
+	}
+
+	if err = load(); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(ret); err != nil {
+		return fmt.Errorf("can't encode %q for cache: %s", key, err)
+	}
+	if err = cache.Set(key, data, ttl); err != nil {
+		return fmt.Errorf("can't set %q in cache: %s", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package base
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SetClaimed sets column claimedBy to workerID and column claimedAt to now
+// for records of model with primary keys ids. Columns are updated like
+// by UpdateColumns: hooks aren't called and updated_at isn't changed.
+func SetClaimed(db *gorm.DB, model interface{}, claimedBy, claimedAt string, ids interface{},
+	workerID string, now time.Time) error {
+
+	scope := db.NewScope(model)
+	query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ? WHERE %s IN (?)",
+		scope.QuotedTableName(), scope.Quote(claimedBy), scope.Quote(claimedAt),
+		scope.Quote(scope.PrimaryKey()))
+	return db.New().Exec(query, workerID, now, ids).Error
+}
@@ -0,0 +1,33 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+var sqlOptionNames = []string{
+	"gorm:query_option",
+	"gorm:insert_option",
+	"gorm:update_option",
+	"gorm:delete_option",
+}
+
+// WithSQLComment appends comment /* comment */ to all queries of db.
+// GORM allows only appending of options to queries, not prepending.
+func WithSQLComment(db *gorm.DB, comment string) *gorm.DB {
+	c := fmt.Sprintf("/* %s */", strings.Replace(comment, "*/", "* /", -1))
+	for _, name := range sqlOptionNames {
+		db = appendSQLOption(db, name, c)
+	}
+	return db
+}
+
+// appendSQLOption appends opt to query option name of db
+func appendSQLOption(db *gorm.DB, name, opt string) *gorm.DB {
+	if prev, ok := db.Get(name); ok && fmt.Sprint(prev) != "" {
+		opt = fmt.Sprint(prev) + " " + opt
+	}
+	return db.Set(name, opt)
+}
@@ -0,0 +1,191 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// groupConditions returns where conditions of group as single SQL expression
+// with "?" placeholders and its args. Group must have only where conditions.
+func groupConditions(group *gorm.DB) (string, []interface{}) {
+	scope := group.NewScope(nil)
+	sql := strings.TrimPrefix(strings.TrimSpace(scope.CombinedConditionSql()), "WHERE ")
+	return toPlaceholders(scope, sql), scope.SQLVars
+}
+
+// toPlaceholders converts bind vars of sql built by scope back to "?": they are
+// dialect specific (e.g. $1 in PostgreSQL), but expression is passed
+// to another db. Go from the last bind var to not replace prefix of it
+// (e.g. $1 of $10).
+func toPlaceholders(scope *gorm.Scope, sql string) string {
+	d := scope.Dialect()
+	for i := len(scope.SQLVars); i > 0; i-- {
+		if bv := d.BindVar(i); bv != "?" {
+			sql = strings.Replace(sql, bv, "?", 1)
+		}
+	}
+	return sql
+}
+
+// Not adds negated where conditions of group to db as NOT (...).
+// Group is built on db.New() and must have only where conditions.
+func Not(db, group *gorm.DB) *gorm.DB {
+	sql, args := groupConditions(group)
+	if sql == "" {
+		return db
+	}
+
+	return db.Where("NOT ?", gorm.Expr("("+sql+")", args...))
+}
+
+// And adds where conditions of group to db as one expression: (...).
+// Group is built on db.New() and must have only where conditions.
+func And(db, group *gorm.DB) *gorm.DB {
+	sql, args := groupConditions(group)
+	if sql == "" {
+		return db
+	}
+
+	return db.Where("("+sql+")", args...)
+}
+
+// Or adds where conditions of groups ORed together: (...) OR (...).
+// Groups are built on db.New() and must have only where conditions.
+// Group without conditions matches all records, so nothing is added then.
+func Or(db *gorm.DB, groups ...*gorm.DB) *gorm.DB {
+	preds := make([]string, 0, len(groups))
+	args := []interface{}{}
+	for _, group := range groups {
+		sql, groupArgs := groupConditions(group)
+		if sql == "" {
+			return db
+		}
+		preds = append(preds, "("+sql+")")
+		args = append(args, groupArgs...)
+	}
+	if len(preds) == 0 {
+		return db
+	}
+
+	return db.Where(strings.Join(preds, " OR "), args...)
+}
+
+// AsScope returns GORM scope adding where conditions of db to another db,
+// e.g. by db.Scopes(...). Db must have only where conditions.
+func AsScope(db *gorm.DB) func(*gorm.DB) *gorm.DB {
+	sql, args := groupConditions(db)
+	return func(target *gorm.DB) *gorm.DB {
+		if sql == "" {
+			return target
+		}
+		return target.Where(sql, args...)
+	}
+}
+
+// WhereMap adds equality conditions column = value of conditions to db in
+// order of columns. Types of values must be types of fields of model
+// (pointer to struct): for pointer fields values of pointed types are
+// allowed and nil value is IS NULL. Mismatches are errors with field.
+func WhereMap(db *gorm.DB, model interface{}, conditions map[string]interface{}) (*gorm.DB, error) {
+	columns := make([]string, 0, len(conditions))
+	for column := range conditions {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		f, ok := fieldByColumn(reflect.ValueOf(model).Elem(), column)
+		if !ok {
+			return db, fmt.Errorf("invalid condition by field %q: no such field", column)
+		}
+
+		v := conditions[column]
+		ft := f.Type()
+		switch {
+		case v == nil && ft.Kind() == reflect.Ptr:
+			db = db.Where(column + " IS NULL")
+			continue
+		case v == nil:
+			return db, fmt.Errorf("invalid condition by field %q: nil value of %s", column, ft)
+		}
+
+		vt := reflect.TypeOf(v)
+		if !vt.AssignableTo(ft) && !(ft.Kind() == reflect.Ptr && vt.AssignableTo(ft.Elem())) {
+			return db, fmt.Errorf("invalid condition by field %q: value of type %s instead of %s",
+				column, vt, ft)
+		}
+		db = db.Where(column+" = ?", v)
+	}
+
+	return db, nil
+}
+
+// FilterFromStruct adds equality conditions by not zero fields of struct v
+// (or pointer to it) to db like WhereMap. Fields are matched to columns of model
+// by `gorm:"column:..."` tag or by name, fields of embedded structs are used too.
+// Fields with `queryset:"-"` tag are skipped, other unmatched fields are errors.
+func FilterFromStruct(db *gorm.DB, model, v interface{}) (*gorm.DB, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return db, fmt.Errorf("invalid filter: nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return db, fmt.Errorf("invalid filter of type %T: it isn't struct", v)
+	}
+
+	conditions := map[string]interface{}{}
+	if err := structConditions(rv, conditions); err != nil {
+		return db, err
+	}
+
+	return WhereMap(db, model, conditions)
+}
+
+// structConditions collects values of not zero exported fields of struct v
+// into conditions by their columns
+func structConditions(v reflect.Value, conditions map[string]interface{}) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("queryset") == "-" || f.Tag.Get("gorm") == "-" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := structConditions(v.Field(i), conditions); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" || v.Field(i).IsZero() {
+			continue
+		}
+
+		column := tagColumn(f.Tag.Get("gorm"))
+		if column == "" {
+			column = gorm.ToDBName(f.Name)
+		}
+		if _, ok := conditions[column]; ok {
+			return fmt.Errorf("invalid filter field %s: duplicate column %q", f.Name, column)
+		}
+		conditions[column] = v.Field(i).Interface()
+	}
+	return nil
+}
+
+// tagColumn returns column from gorm tag like `gorm:"column:name;not null"`
+func tagColumn(tag string) string {
+	for _, setting := range strings.Split(tag, ";") {
+		kv := strings.SplitN(setting, ":", 2)
+		if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "column") {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package base
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+)
+
+const contextKey = "queryset:context"
+
+// WithContext returns copy of db with context ctx of operations: they return
+// error of ctx without executing queries if it's done. GORM v1 has no context
+// API, so already executing query isn't cancelled.
+func WithContext(db *gorm.DB, ctx context.Context) *gorm.DB {
+	return db.Set(contextKey, ctx)
+}
+
+// Context returns context of db set by WithContext or context.Background()
+// if it wasn't set
+func Context(db *gorm.DB) context.Context {
+	if ctx, ok := db.Get(contextKey); ok && ctx != nil {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// CheckContext returns error of context of db set by WithContext
+// if it's done (canceled or its deadline exceeded)
+func CheckContext(db *gorm.DB) error {
+	return Context(db).Err()
+}
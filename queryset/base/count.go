@@ -0,0 +1,68 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CountApprox returns approximate count of db model records from statistics
+// of table (information_schema.tables in MySQL, pg_class in PostgreSQL):
+// it's much faster than count(*) on huge tables. Statistics know nothing
+// about conditions, so exact count is returned if db has conditions, if
+// dialect has no statistics or if table wasn't analyzed yet. Implicit soft
+// delete condition (deleted_at IS NULL) is ignored: estimate of statistics
+// includes soft deleted records.
+func CountApprox(db *gorm.DB) (int64, error) {
+	if db.Error != nil {
+		return 0, db.Error
+	}
+
+	// unscoped search has no implicit soft delete condition
+	scope := db.Unscoped().NewScope(db.Value)
+	if strings.TrimSpace(scope.CombinedConditionSql()) == "" {
+		n, ok, err := countByStats(scope)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return n, nil
+		}
+	}
+
+	var n int64
+	if err := db.Count(&n).Error; err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func countByStats(scope *gorm.Scope) (n int64, ok bool, err error) {
+	var query string
+	switch scope.Dialect().GetName() {
+	case "mysql":
+		query = "SELECT table_rows FROM information_schema.tables " +
+			"WHERE table_schema = DATABASE() AND table_name = ?"
+	case "postgres":
+		query = "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)"
+	default:
+		return 0, false, nil
+	}
+
+	var rows sql.NullInt64
+	err = scope.SQLDB().QueryRow(query, scope.TableName()).Scan(&rows)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("can't get statistics of table %s: %s", scope.TableName(), err)
+	}
+
+	// PostgreSQL has reltuples = -1 for never analyzed tables
+	if !rows.Valid || rows.Int64 < 0 {
+		return 0, false, nil
+	}
+	return rows.Int64, true, nil
+}
@@ -0,0 +1,360 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CreateIfNotMatched calls create with transaction if there are no records of
+// db model (set by db.Model): their existence is checked by locking read
+// SELECT 1 ... FOR UPDATE in the same transaction. Transaction passed to create
+// has no conditions of db.
+// Locking read can't lock absent rows in PostgreSQL (MySQL locks gaps of
+// index), so races must be prevented there by unique constraint too.
+// Only PostgreSQL and MySQL are supported.
+func CreateIfNotMatched(db *gorm.DB, create func(tx *gorm.DB) error) (created bool, err error) {
+	err = InTransaction(db, func(tx *gorm.DB) error {
+		lockedDB, err := withLockOption(tx, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		// query options (locking clause) are applied only by queries scanning
+		// into models: scan selected 1 into slice of db model
+		records := reflect.New(reflect.SliceOf(reflect.TypeOf(db.Value).Elem()))
+		res := lockedDB.Select("1").Limit(1).Find(records.Interface())
+		if res.Error != nil {
+			return fmt.Errorf("can't check existence of records: %s", res.Error)
+		}
+		if res.RowsAffected != 0 {
+			return nil
+		}
+
+		if err = create(tx.New()); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// GetOrCreate finds first record matching conditions of db into out like
+// gorm's FirstOrCreate or calls create if there are no such records: created
+// is true then. Like FirstOrCreate equality conditions (described ones added
+// by DescribedWhere with "eq" op) are assigned to fields of attrs (pointer
+// to struct) before create. Implicit conditions (e.g. soft delete) are applied
+// only to the lookup: create gets db without conditions. If create fails with
+// duplicate key error because of concurrent insert, record is selected again.
+func GetOrCreate(db *gorm.DB, out, attrs interface{},
+	create func(db *gorm.DB) error) (created bool, err error) {
+
+	res := db.First(out)
+	if !res.RecordNotFound() {
+		return false, res.Error
+	}
+
+	v := reflect.ValueOf(attrs).Elem()
+	for _, c := range getDescribedConditions(db).conditions {
+		if c.Op != "eq" {
+			continue
+		}
+		if f := v.FieldByName(c.Field); f.IsValid() && f.CanSet() {
+			f.Set(reflect.ValueOf(c.Value))
+		}
+	}
+
+	if err = create(db.New()); err != nil {
+		if IsDuplicateKey(err) {
+			if res := db.First(out); res.Error == nil {
+				return false, nil
+			}
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateFromChan creates records received from ch (channel of db models) by
+// multi-row INSERTs of up to batchSize records (and up to MaxBatchVars
+// placeholders) until ch is closed and returns count of created records.
+// Every received record is passed to prepare (if it's set) as pointer before
+// creation, e.g. to validate it. Unlike Create hooks aren't called, primary
+// keys of created records aren't set and columns defaults aren't applied.
+// CreatedAt and UpdatedAt are set to now. Receiving stops on the first error:
+// records of failed batch aren't created.
+func CreateFromChan(db *gorm.DB, ch interface{}, batchSize int,
+	prepare func(record interface{}) error) (int64, error) {
+
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	chv := reflect.ValueOf(ch)
+	var total int64
+	batch := make([]interface{}, 0, batchSize)
+	for {
+		v, ok := chv.Recv()
+		if ok {
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			if prepare != nil {
+				if err := prepare(p.Interface()); err != nil {
+					return total, err
+				}
+			}
+			batch = append(batch, p.Interface())
+		}
+
+		if len(batch) == batchSize || !ok && len(batch) != 0 {
+			n, err := createBatch(db, batch, false)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			batch = batch[:0]
+		}
+
+		if !ok {
+			return total, nil
+		}
+	}
+}
+
+const maxBatchVarsKey = "queryset:max_batch_vars"
+
+// maxBatchVarsByDialect are limits of count of placeholders of one statement
+var maxBatchVarsByDialect = map[string]int{
+	"mysql":    65535,
+	"postgres": 65535,
+	"sqlite3":  999,
+	"mssql":    2000,
+}
+
+// WithMaxBatchVars returns copy of db with max count of placeholders of one
+// multi-row INSERT of CreateBatch and CreateFromChan: records are split
+// into INSERTs having up to this count of placeholders
+func WithMaxBatchVars(db *gorm.DB, n int) *gorm.DB {
+	return db.Set(maxBatchVarsKey, n)
+}
+
+// MaxBatchVars returns max count of placeholders of one multi-row INSERT:
+// set by WithMaxBatchVars or limit of dialect (999 for unknown dialects)
+func MaxBatchVars(db *gorm.DB) int {
+	if n, ok := db.Get(maxBatchVarsKey); ok {
+		if n, ok := n.(int); ok && n > 0 {
+			return n
+		}
+	}
+	if n := maxBatchVarsByDialect[db.NewScope(nil).Dialect().GetName()]; n != 0 {
+		return n
+	}
+	return 999
+}
+
+// CreateBatch creates records of slice (of db models) by multi-row INSERTs
+// split by MaxBatchVars: empty slice is no-op. Auto-increment primary keys
+// of created records are set only in PostgreSQL by RETURNING: other databases
+// don't return IDs of all rows of multi-row INSERT (IDs aren't consecutive
+// e.g. with innodb_autoinc_lock_mode = 2 of MySQL), so they stay zero.
+// Primary keys must be set for all records or for none of them. INSERTs
+// of several chunks are executed in one transaction: nothing is created
+// on error. Unlike Create hooks aren't called and columns defaults aren't applied.
+// CreatedAt and UpdatedAt are set to now.
+func CreateBatch(db *gorm.DB, slice interface{}) error {
+	sv := reflect.ValueOf(slice)
+	if sv.Len() == 0 {
+		return nil
+	}
+
+	records := make([]interface{}, 0, sv.Len())
+	for i := 0; i < sv.Len(); i++ {
+		records = append(records, sv.Index(i).Addr().Interface())
+	}
+	_, err := createBatch(db, records, true)
+	return err
+}
+
+func createBatchReturningIDs(scope *gorm.Scope, chunk []*gorm.Scope, sql string, pk int) error {
+	sql += " RETURNING " + scope.Quote(scope.Fields()[pk].DBName)
+	rows, err := scope.SQLDB().Query(scope.Raw(sql).SQL, scope.SQLVars...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// rows are returned in order of VALUES
+	for _, s := range chunk {
+		if !rows.Next() {
+			break
+		}
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return fmt.Errorf("can't scan created id: %s", err)
+		}
+		if err = s.Fields()[pk].Set(id); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// createBatch creates records (pointers to db models) by multi-row INSERTs
+// of up to MaxBatchVars placeholders and returns count of created records.
+// INSERTs of several chunks are executed in one transaction: nothing is
+// created on error. Auto-increment IDs are set if setIDs and database
+// returns them.
+func createBatch(db *gorm.DB, records []interface{}, setIDs bool) (int64, error) {
+	scopes := newBatchScopes(db, records)
+	if err := checkBatchPrimaryKeys(scopes); err != nil {
+		return 0, err
+	}
+
+	pk := -1
+	if setIDs && scopes[0].Dialect().GetName() == "postgres" {
+		pk = getAutoPrimaryKeyIndex(scopes)
+	}
+
+	fieldIndexes := getBatchFieldIndexes(scopes)
+	size := len(scopes)
+	if len(fieldIndexes) != 0 {
+		size = MaxBatchVars(db) / len(fieldIndexes)
+	}
+	if size == 0 {
+		size = 1
+	}
+
+	var total int64
+	insert := func(tx *gorm.DB) error {
+		for i := 0; i < len(scopes); i += size {
+			end := i + size
+			if end > len(scopes) {
+				end = len(scopes)
+			}
+			chunk := scopes[i:end]
+			scope := tx.NewScope(chunk[0].Value)
+			sql := batchInsertSQL(scope, chunk, fieldIndexes)
+
+			if pk != -1 {
+				if err := createBatchReturningIDs(scope, chunk, sql, pk); err != nil {
+					return err
+				}
+				total += int64(len(chunk))
+				continue
+			}
+
+			res := scope.Raw(sql).Exec().DB()
+			if res.Error != nil {
+				return res.Error
+			}
+			total += res.RowsAffected
+		}
+		return nil
+	}
+
+	// one INSERT is atomic by itself
+	if len(scopes) <= size {
+		if err := insert(db); err != nil {
+			return 0, err
+		}
+		return total, nil
+	}
+	if err := InTransaction(db, insert); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// newBatchScopes returns scopes of records (pointers to db models) to create
+// with CreatedAt and UpdatedAt set to now
+func newBatchScopes(db *gorm.DB, records []interface{}) []*gorm.Scope {
+	now := gorm.NowFunc()
+	scopes := make([]*gorm.Scope, 0, len(records))
+	for _, r := range records {
+		scope := db.NewScope(r)
+		// like GORM ignore errors of models without these fields
+		_ = scope.SetColumn("CreatedAt", now)
+		_ = scope.SetColumn("UpdatedAt", now)
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// getBatchFieldIndexes returns indexes of fields of records of scopes
+// to insert: primary key columns are inserted only if they are set
+func getBatchFieldIndexes(scopes []*gorm.Scope) []int {
+	fieldIndexes := []int{}
+	for i, f := range scopes[0].Fields() {
+		if !f.IsNormal || f.IsPrimaryKey && !isSetForAny(scopes, i) {
+			continue
+		}
+		fieldIndexes = append(fieldIndexes, i)
+	}
+	return fieldIndexes
+}
+
+// batchInsertSQL returns multi-row INSERT of fields fieldIndexes of records
+// of chunk: its vars are added to scope
+func batchInsertSQL(scope *gorm.Scope, chunk []*gorm.Scope, fieldIndexes []int) string {
+	columns := make([]string, 0, len(fieldIndexes))
+	for _, i := range fieldIndexes {
+		columns = append(columns, scope.Quote(scope.Fields()[i].DBName))
+	}
+
+	rows := make([]string, 0, len(chunk))
+	for _, s := range chunk {
+		fields := s.Fields()
+		placeholders := make([]string, 0, len(fieldIndexes))
+		for _, i := range fieldIndexes {
+			placeholders = append(placeholders, scope.AddToVars(fields[i].Field.Interface()))
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ",")+")")
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", scope.QuotedTableName(),
+		strings.Join(columns, ","), strings.Join(rows, ","))
+}
+
+// checkBatchPrimaryKeys returns error if primary key is set only for some
+// of records of scopes: zero keys can't be inserted with set ones
+func checkBatchPrimaryKeys(scopes []*gorm.Scope) error {
+	for i, f := range scopes[0].Fields() {
+		if !f.IsPrimaryKey || !isSetForAny(scopes, i) {
+			continue
+		}
+		for _, s := range scopes {
+			if s.Fields()[i].IsBlank {
+				return fmt.Errorf("can't create batch of %s: primary key %s is set only for some records",
+					scopes[0].TableName(), f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// getAutoPrimaryKeyIndex returns index of field of the only primary key if
+// it isn't set for all records: it's auto-incremented then. Otherwise -1.
+func getAutoPrimaryKeyIndex(scopes []*gorm.Scope) int {
+	scope := scopes[0]
+	if len(scope.PrimaryFields()) != 1 {
+		return -1
+	}
+	for i, f := range scope.Fields() {
+		if f.IsPrimaryKey && !isSetForAny(scopes, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSetForAny(scopes []*gorm.Scope, fieldIndex int) bool {
+	for _, s := range scopes {
+		if !s.Fields()[fieldIndex].IsBlank {
+			return true
+		}
+	}
+	return false
+}
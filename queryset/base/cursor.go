@@ -0,0 +1,40 @@
+package base
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// EncodeCursor encodes primary key pk to opaque cursor for pagination
+func EncodeCursor(pk interface{}) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprint(pk)))
+}
+
+// DecodeCursor decodes cursor made by EncodeCursor into primary key pointed by pk
+func DecodeCursor(cursor string, pk interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("invalid cursor %q: %s", cursor, err)
+	}
+
+	if _, err = fmt.Sscan(string(b), pk); err != nil {
+		return fmt.Errorf("invalid cursor %q: %s", cursor, err)
+	}
+
+	return nil
+}
+
+// CheckNoLimitOffset returns error if limit or offset is set for db: keyset
+// pagination (e.g. by PageCursor or EachRow) selects records by its own
+// limit and conditions, so they would skip or repeat records
+func CheckNoLimitOffset(db *gorm.DB) error {
+	// GORM has no API to get them: not set ones are -1
+	search := reflect.ValueOf(db.NewScope(nil).Search).Elem()
+	if search.FieldByName("limit").Int() >= 0 || search.FieldByName("offset").Int() >= 0 {
+		return fmt.Errorf("keyset pagination can't be used with Limit or Offset")
+	}
+	return nil
+}
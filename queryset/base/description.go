@@ -0,0 +1,126 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// QueryDescription is a serializable description of conditions of query set,
+// e.g. for saved searches: it's made by Describe method of query set
+// and applied by FromDescription method
+type QueryDescription struct {
+	Conditions []ConditionDescription `json:"conditions"`
+}
+
+// ConditionDescription is a condition of QueryDescription: Field is a name
+// of field of model (e.g. "CreatedAt"), Op is one of "eq", "ne", "lt", "gt",
+// "lte", "gte". Lt, gt, lte and gte are allowed only for fields having
+// such filter methods: numeric, time and not enum string fields.
+type ConditionDescription struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+const descriptionSetting = "queryset:description"
+
+// describedConditions are conditions of db added by DescribedWhere
+type describedConditions struct {
+	conditions []ConditionDescription
+	complete   bool // there are no other conditions
+	count      int  // count of conditions of db after the last described one
+}
+
+func getDescribedConditions(db *gorm.DB) describedConditions {
+	if d, ok := db.Get(descriptionSetting); ok {
+		return d.(describedConditions)
+	}
+	return describedConditions{complete: true}
+}
+
+// DescribedWhere adds condition query with args to db like db.Where and
+// describes it for Describe as condition on field by op with value
+func DescribedWhere(db *gorm.DB, field, op string, value interface{},
+	query string, args ...interface{}) *gorm.DB {
+
+	d := getDescribedConditions(db)
+	complete := d.complete && conditionsCount(db) == d.count
+
+	db = db.Where(query, args...)
+	conditions := make([]ConditionDescription, len(d.conditions), len(d.conditions)+1)
+	copy(conditions, d.conditions)
+	return db.Set(descriptionSetting, describedConditions{
+		conditions: append(conditions, ConditionDescription{Field: field, Op: op, Value: value}),
+		complete:   complete,
+		count:      conditionsCount(db),
+	})
+}
+
+// Describe returns description of conditions of db added by DescribedWhere.
+// Other conditions (e.g. LIKE or IN ones) can't be described: it's an error
+// if db has them.
+func Describe(db *gorm.DB) (QueryDescription, error) {
+	d := getDescribedConditions(db)
+	if !d.complete || conditionsCount(db) != d.count {
+		return QueryDescription{}, errors.New("can't describe query: " +
+			"it has conditions not added by Eq, Ne, Lt, Gt, Lte or Gte methods")
+	}
+
+	conditions := make([]ConditionDescription, len(d.conditions))
+	copy(conditions, d.conditions)
+	return QueryDescription{Conditions: conditions}, nil
+}
+
+// conditionsCount returns count of where, or and not conditions of db:
+// GORM has no API to get them
+func conditionsCount(db *gorm.DB) int {
+	search := reflect.ValueOf(db.NewScope(nil).Search).Elem()
+	n := 0
+	for _, name := range []string{"whereConditions", "orConditions", "notConditions"} {
+		n += search.FieldByName(name).Len()
+	}
+	return n
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ConvertValue stores value into variable pointed by dest converting it
+// to type of dest if needed: values decoded from JSON are float64 for
+// numbers and RFC 3339 strings for times. Conversions losing data
+// (e.g. 1.5 to int) are errors.
+func ConvertValue(value, dest interface{}) error {
+	d := reflect.ValueOf(dest).Elem()
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return fmt.Errorf("no value for %s", d.Type())
+	}
+
+	if d.Type() == timeType && v.Kind() == reflect.String {
+		t, err := time.Parse(time.RFC3339Nano, v.String())
+		if err != nil {
+			return fmt.Errorf("invalid time %q: %s", v.String(), err)
+		}
+		d.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if !v.Type().ConvertibleTo(d.Type()) || isNumberKind(v.Kind()) != isNumberKind(d.Kind()) {
+		return fmt.Errorf("can't convert %T to %s", value, d.Type())
+	}
+
+	converted := v.Convert(d.Type())
+	if isNumberKind(v.Kind()) && !reflect.DeepEqual(converted.Convert(v.Type()).Interface(), value) {
+		return fmt.Errorf("can't convert %v to %s without loss", value, d.Type())
+	}
+
+	d.Set(converted)
+	return nil
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
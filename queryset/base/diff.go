@@ -0,0 +1,39 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ReloadByPK loads record having primary key of o into ret
+func ReloadByPK(db *gorm.DB, o, ret interface{}) error {
+	scope := db.NewScope(o)
+	if scope.PrimaryKeyZero() {
+		return errors.New("can't reload record without primary key")
+	}
+
+	cond := fmt.Sprintf("%s.%s = ?", scope.QuotedTableName(), scope.Quote(scope.PrimaryKey()))
+	return db.Where(cond, scope.PrimaryKeyValue()).First(ret).Error
+}
+
+// FieldsEqual compares values of field. Times are compared by time.Time.Equal:
+// they can have another location or no monotonic clock after loading from db.
+func FieldsEqual(a, b interface{}) bool {
+	switch at := a.(type) {
+	case time.Time:
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	case *time.Time:
+		bt, ok := b.(*time.Time)
+		if !ok || at == nil || bt == nil {
+			return ok && at == bt
+		}
+		return at.Equal(*bt)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,25 @@
+package base
+
+import "github.com/jinzhu/gorm"
+
+const eachRowChunkSizeKey = "queryset:each_row_chunk_size"
+
+// DefaultEachRowChunkSize is a default count of records loaded by EachRow at once
+const DefaultEachRowChunkSize = 1000
+
+// WithEachRowChunkSize returns copy of db with count of records loaded by
+// EachRow at once
+func WithEachRowChunkSize(db *gorm.DB, size int) *gorm.DB {
+	return db.Set(eachRowChunkSizeKey, size)
+}
+
+// EachRowChunkSize returns count of records loaded by EachRow at once: set by
+// WithEachRowChunkSize or DefaultEachRowChunkSize
+func EachRowChunkSize(db *gorm.DB) int {
+	if size, ok := db.Get(eachRowChunkSizeKey); ok {
+		if size, ok := size.(int); ok && size > 0 {
+			return size
+		}
+	}
+	return DefaultEachRowChunkSize
+}
@@ -0,0 +1,36 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ValidateEnum returns error if any of values isn't a member of enum
+// of field
+func ValidateEnum(field string, members []string, values ...string) error {
+	for _, v := range values {
+		if !isEnumMember(members, v) {
+			return fmt.Errorf("invalid value %q of enum %s: must be one of %q", v, field, members)
+		}
+	}
+	return nil
+}
+
+// CheckEnum is like ValidateEnum, but returns copy of db with error
+// instead of error: invalid values aren't sent to database
+func CheckEnum(db *gorm.DB, field string, members []string, values ...string) *gorm.DB {
+	if err := ValidateEnum(field, members, values...); err != nil {
+		return withError(db, err)
+	}
+	return db
+}
+
+func isEnumMember(members []string, v string) bool {
+	for _, m := range members {
+		if m == v {
+			return true
+		}
+	}
+	return false
+}
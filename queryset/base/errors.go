@@ -0,0 +1,45 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrorCode returns driver's code of error err: MySQL error number
+// (e.g. "1062") or PostgreSQL SQLSTATE (e.g. "23505"). It's empty for
+// errors without code. Drivers aren't imported, so PostgreSQL errors are
+// matched by SQLState method (lib/pq, pgx) and MySQL errors by text.
+// Errors wrapped by %w are unwrapped.
+func ErrorCode(err error) string {
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		var number int
+		if _, serr := fmt.Sscanf(err.Error(), "Error %d:", &number); serr == nil {
+			return strconv.Itoa(number)
+		}
+	}
+	return ""
+}
+
+// IsDuplicateKey returns true for errors of unique constraints violation,
+// e.g. of creation: MySQL error 1062, PostgreSQL SQLSTATE 23505 and
+// SQLite UNIQUE constraint errors
+func IsDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch ErrorCode(err) {
+	case "1062", "23505":
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "duplicate key value violates unique constraint") ||
+		strings.Contains(msg, "UNIQUE constraint failed")
+}
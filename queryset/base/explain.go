@@ -0,0 +1,74 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Explain returns plan of select of db model records: the same query
+// as db.Find would run is prefixed with EXPLAIN (EXPLAIN ANALYZE if analyze
+// is set, it executes query). Rows of plan are separated by newlines,
+// columns of multi-column plans (MySQL) are separated by tabs after
+// the line of columns names.
+func Explain(db *gorm.DB, analyze bool) (string, error) {
+	if db.Error != nil {
+		return "", db.Error
+	}
+
+	scope := db.NewScope(db.Value)
+	prefix := "EXPLAIN"
+	if analyze {
+		switch dialect := scope.Dialect().GetName(); dialect {
+		case "postgres", "mysql":
+			prefix = "EXPLAIN ANALYZE"
+		default:
+			return "", fmt.Errorf("EXPLAIN ANALYZE isn't supported by %s", dialect)
+		}
+	}
+
+	// conditions are bound to scope.SQLVars, Raw replaces placeholders
+	// of common dialect by "?"
+	scope.Raw(fmt.Sprintf("%s SELECT * FROM %s %s", prefix,
+		scope.QuotedTableName(), scope.CombinedConditionSql()))
+	rows, err := scope.SQLDB().Query(scope.SQL, scope.SQLVars...)
+	if err != nil {
+		return "", fmt.Errorf("can't explain query: %s", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("can't get columns of plan: %s", err)
+	}
+
+	lines := []string{}
+	if len(columns) > 1 {
+		lines = append(lines, strings.Join(columns, "\t"))
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return "", fmt.Errorf("can't scan row of plan: %s", err)
+		}
+
+		line := make([]string, len(values))
+		for i, v := range values {
+			line[i] = v.String
+		}
+		lines = append(lines, strings.Join(line, "\t"))
+	}
+	if err = rows.Err(); err != nil {
+		return "", fmt.Errorf("can't iterate rows of plan: %s", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
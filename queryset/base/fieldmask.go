@@ -0,0 +1,34 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ApplyFieldMask adds condition column = values[path] to db for every path of
+// mask. Path is converted to snake_case column name, it must be in columns.
+func ApplyFieldMask(db *gorm.DB, columns, mask []string,
+	values map[string]interface{}) (*gorm.DB, error) {
+
+	isColumn := map[string]bool{}
+	for _, c := range columns {
+		isColumn[c] = true
+	}
+
+	for _, path := range mask {
+		column := gorm.ToDBName(path)
+		if !isColumn[column] {
+			return nil, fmt.Errorf("invalid field mask path %q: no such column", path)
+		}
+
+		v, ok := values[path]
+		if !ok {
+			return nil, fmt.Errorf("no value for field mask path %q", path)
+		}
+
+		db = db.Where(fmt.Sprintf("%s = ?", column), v)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,23 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithinBoundingBox adds condition selecting records with point of latColumn
+// and lngColumn within bounding box [minLat, maxLat] x [minLng, maxLng].
+// Box with minLng > maxLng crosses the antimeridian: longitudes of points
+// are >= minLng or <= maxLng then.
+func WithinBoundingBox(db *gorm.DB, latColumn, lngColumn string,
+	minLat, minLng, maxLat, maxLng float64) *gorm.DB {
+
+	lngOp := "AND"
+	if minLng > maxLng {
+		lngOp = "OR"
+	}
+	return db.Where(fmt.Sprintf("%s >= ? AND %s <= ? AND (%s >= ? %s %s <= ?)",
+		latColumn, latColumn, lngColumn, lngOp, lngColumn),
+		minLat, maxLat, minLng, maxLng)
+}
@@ -0,0 +1,62 @@
+package base
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ResultHash returns hex-encoded SHA-256 of values of selected columns of all
+// records of db model. Records are ordered by primary key, so hash is stable
+// until any of them is changed. Records are streamed, not loaded into memory.
+func ResultHash(db *gorm.DB) (string, error) {
+	scope := db.NewScope(db.Value)
+	if pk := scope.PrimaryKey(); pk != "" {
+		db = db.Order(fmt.Sprintf("%s.%s", scope.QuotedTableName(), scope.Quote(pk)))
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return "", fmt.Errorf("can't select records to hash: %s", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("can't get columns to hash: %s", err)
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	h := sha256.New()
+	var lenBuf [8]byte
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return "", fmt.Errorf("can't scan record to hash: %s", err)
+		}
+
+		for _, v := range values {
+			// length prefix separates values and distinguishes NULL from ''
+			l := int64(len(v))
+			if v == nil {
+				l = -1
+			}
+			binary.BigEndian.PutUint64(lenBuf[:], uint64(l))
+			h.Write(lenBuf[:])
+			h.Write(v)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return "", fmt.Errorf("can't iterate records to hash: %s", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
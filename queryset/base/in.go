@@ -0,0 +1,72 @@
+package base
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+const maxInListSizeKey = "queryset:max_in_list_size"
+
+// DefaultMaxInListSize is a default max count of values in one IN list
+const DefaultMaxInListSize = 1000
+
+// WithMaxInListSize returns copy of db with max count of values in one IN
+// list of WhereIn
+func WithMaxInListSize(db *gorm.DB, size int) *gorm.DB {
+	return db.Set(maxInListSizeKey, size)
+}
+
+// MaxInListSize returns max count of values in one IN list of WhereIn: set
+// by WithMaxInListSize or DefaultMaxInListSize
+func MaxInListSize(db *gorm.DB) int {
+	if size, ok := db.Get(maxInListSizeKey); ok {
+		if size, ok := size.(int); ok && size > 0 {
+			return size
+		}
+	}
+	return DefaultMaxInListSize
+}
+
+// WhereIn adds condition column IN (values) for slice values. Some drivers
+// and databases can't handle huge count of placeholders, so values are split
+// into chunks of MaxInListSize values ORed together:
+// (column IN (?) OR column IN (?)). Empty values match no records.
+func WhereIn(db *gorm.DB, column string, values interface{}) *gorm.DB {
+	if reflect.ValueOf(values).Len() == 0 {
+		return db.Where("1 = 0")
+	}
+	return whereInChunks(db, column+" IN (?)", " OR ", values)
+}
+
+// WhereNotIn adds condition column NOT IN (values) for slice values: like in
+// WhereIn values are split into chunks, they are joined by AND. Empty values
+// match all records.
+func WhereNotIn(db *gorm.DB, column string, values interface{}) *gorm.DB {
+	if reflect.ValueOf(values).Len() == 0 {
+		return db
+	}
+	return whereInChunks(db, column+" NOT IN (?)", " AND ", values)
+}
+
+// whereInChunks adds condition pred for every chunk of values joined by sep
+func whereInChunks(db *gorm.DB, pred, sep string, values interface{}) *gorm.DB {
+	size := MaxInListSize(db)
+	v := reflect.ValueOf(values)
+	if v.Len() <= size {
+		return db.Where(pred, values)
+	}
+
+	preds := make([]string, 0, v.Len()/size+1)
+	args := make([]interface{}, 0, v.Len()/size+1)
+	for i := 0; i < v.Len(); i += size {
+		end := i + size
+		if end > v.Len() {
+			end = v.Len()
+		}
+		preds = append(preds, pred)
+		args = append(args, v.Slice(i, end).Interface())
+	}
+	return db.Where(strings.Join(preds, sep), args...)
+}
@@ -0,0 +1,43 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckIndex checks that records (slice of structs) can be indexed by field
+// fieldName into map pointed by dest: map must have key of type of field
+// and value of type of records elements
+func CheckIndex(records interface{}, fieldName string, dest interface{}) error {
+	elemType := reflect.TypeOf(records).Elem()
+	field, ok := elemType.FieldByName(fieldName)
+	if !ok || !field.Type.Comparable() {
+		return fmt.Errorf("can't index %s by field %q: it can't be map key", elemType, fieldName)
+	}
+
+	mapType := reflect.TypeOf(dest)
+	if mapType == nil || mapType.Kind() != reflect.Ptr || mapType.Elem().Kind() != reflect.Map ||
+		mapType.Elem().Key() != field.Type || mapType.Elem().Elem() != elemType {
+		return fmt.Errorf("can't index %s by field %s into %T: it must be *map[%s]%s",
+			elemType, fieldName, dest, field.Type, elemType)
+	}
+
+	return nil
+}
+
+// Index stores records (slice of structs) into map pointed by dest by values
+// of field fieldName: records with duplicate keys overwrite previous ones, so
+// the last one wins. Map is made if it's nil. Records and dest must be checked
+// by CheckIndex.
+func Index(records interface{}, fieldName string, dest interface{}) {
+	m := reflect.ValueOf(dest).Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+
+	rv := reflect.ValueOf(records)
+	for i := 0; i < rv.Len(); i++ {
+		r := rv.Index(i)
+		m.SetMapIndex(r.FieldByName(fieldName), r)
+	}
+}
@@ -0,0 +1,82 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// JoinScan joins table of association assoc (name of field) of db model
+// and scans every row of combined columns into dest (pointer to struct),
+// then calls fn: rows are streamed without loading all of them. Columns
+// of model are selected as is, columns of association are prefixed with
+// db name of assoc, e.g. user_name for name of User association, so flat
+// struct with fields like UserName can be dest. Belongs-to, has-one and
+// has-many associations are supported, soft deleted records
+// of association aren't joined. Conditions on columns present in both
+// tables are ambiguous: they must be qualified by table.
+func JoinScan(db *gorm.DB, assoc string, dest interface{}, fn func() error) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	scope := db.NewScope(db.Value)
+	field, ok := scope.FieldByName(assoc)
+	if !ok || field.Relationship == nil {
+		return fmt.Errorf("no association %s of %s", assoc, scope.GetModelStruct().ModelType)
+	}
+
+	assocType := field.Struct.Type
+	for assocType.Kind() == reflect.Slice || assocType.Kind() == reflect.Ptr {
+		assocType = assocType.Elem()
+	}
+	assocScope := db.NewScope(reflect.New(assocType).Interface())
+	table, assocTable := scope.QuotedTableName(), assocScope.QuotedTableName()
+
+	rel := field.Relationship
+	on := []string{}
+	for i, fk := range rel.ForeignDBNames {
+		switch rel.Kind {
+		case "belongs_to":
+			on = append(on, fmt.Sprintf("%s.%s = %s.%s", assocTable,
+				scope.Quote(rel.AssociationForeignDBNames[i]), table, scope.Quote(fk)))
+		case "has_one", "has_many":
+			on = append(on, fmt.Sprintf("%s.%s = %s.%s", assocTable,
+				scope.Quote(fk), table, scope.Quote(rel.AssociationForeignDBNames[i])))
+		default:
+			return fmt.Errorf("can't join %s association %s", rel.Kind, assoc)
+		}
+	}
+	if f, ok := assocScope.FieldByName("DeletedAt"); ok {
+		on = append(on, fmt.Sprintf("%s.%s IS NULL", assocTable, scope.Quote(f.DBName)))
+	}
+
+	columns := []string{table + ".*"}
+	prefix := gorm.ToDBName(assoc)
+	for _, f := range assocScope.Fields() {
+		if f.IsNormal && !f.IsIgnored {
+			columns = append(columns, fmt.Sprintf("%s.%s AS %s", assocTable,
+				scope.Quote(f.DBName), scope.Quote(prefix+"_"+f.DBName)))
+		}
+	}
+
+	rows, err := db.Select(strings.Join(columns, ", ")).
+		Joins(fmt.Sprintf("JOIN %s ON %s", assocTable, strings.Join(on, " AND "))).
+		Rows()
+	if err != nil {
+		return fmt.Errorf("can't select join with %s: %s", assoc, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = db.ScanRows(rows, dest); err != nil {
+			return fmt.Errorf("can't scan join with %s: %s", assoc, err)
+		}
+		if err = fn(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,24 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// JSONArrayContains adds condition selecting records with JSON array column
+// containing string value: JSON_CONTAINS in MySQL and @> in PostgreSQL
+// (column must be jsonb). Other dialects aren't supported: it's an error of db.
+func JSONArrayContains(db *gorm.DB, column, value string) *gorm.DB {
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "mysql":
+		doc, _ := json.Marshal(value)
+		return db.Where(fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), string(doc))
+	case "postgres":
+		doc, _ := json.Marshal([]string{value})
+		return db.Where(fmt.Sprintf("%s @> ?", column), string(doc))
+	default:
+		return withError(db, fmt.Errorf("JSON array containment isn't supported by %s", dialect))
+	}
+}
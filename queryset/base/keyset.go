@@ -0,0 +1,148 @@
+package base
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// keysetCursor is a continuation of records ordered by columns: it stores
+// values of them of the last record
+type keysetCursor struct {
+	Columns []string          `json:"c"`
+	Values  []json.RawMessage `json:"v"`
+	Desc    bool              `json:"d,omitempty"`
+}
+
+// EncodeKeysetCursor encodes values of columns of record (pointer to db model)
+// to opaque cursor for ContinueAfter: records must be ordered by columns
+// ascending or descending (if desc is set)
+func EncodeKeysetCursor(record interface{}, desc bool, columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("no columns of keyset cursor")
+	}
+
+	c := keysetCursor{Columns: columns, Desc: desc}
+	for _, column := range columns {
+		v, ok := fieldByColumn(reflect.ValueOf(record).Elem(), column)
+		if !ok {
+			return "", fmt.Errorf("no field of column %q for keyset cursor", column)
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", fmt.Errorf("can't encode value of %s for keyset cursor: %s", column, err)
+		}
+		c.Values = append(c.Values, b)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("can't encode keyset cursor: %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ContinueAfter adds lexicographic keyset condition selecting records after
+// the last record of cursor made by EncodeKeysetCursor: (a, b) > (?, ?) or
+// (a, b) < (?, ?) for descending order. Values are decoded into types of
+// fields of model. Columns of cursor must be from orderedColumns.
+func ContinueAfter(db *gorm.DB, model interface{}, cursor string, orderedColumns []string) (*gorm.DB, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return db, fmt.Errorf("invalid keyset cursor %q: %s", cursor, err)
+	}
+	var c keysetCursor
+	if err = json.Unmarshal(b, &c); err != nil {
+		return db, fmt.Errorf("invalid keyset cursor %q: %s", cursor, err)
+	}
+	if len(c.Columns) == 0 || len(c.Columns) != len(c.Values) {
+		return db, fmt.Errorf("invalid keyset cursor %q: %d values of %d columns",
+			cursor, len(c.Values), len(c.Columns))
+	}
+
+	values := make([]interface{}, 0, len(c.Values))
+	placeholders := make([]string, 0, len(c.Values))
+	for i, column := range c.Columns {
+		if !isColumnOf(column, orderedColumns) {
+			return db, fmt.Errorf("can't continue after keyset cursor by field %q: "+
+				"it isn't ordered field", column)
+		}
+		f, _ := fieldByColumn(reflect.ValueOf(model).Elem(), column)
+		v := reflect.New(f.Type())
+		if err = json.Unmarshal(c.Values[i], v.Interface()); err != nil {
+			return db, fmt.Errorf("invalid value of %s of keyset cursor: %s", column, err)
+		}
+		values = append(values, v.Elem().Interface())
+		placeholders = append(placeholders, "?")
+	}
+
+	op := ">"
+	if c.Desc {
+		op = "<"
+	}
+	return db.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(c.Columns, ", "), op,
+		strings.Join(placeholders, ", ")), values...), nil
+}
+
+// fieldByColumn returns field of struct v stored in column: fields of
+// embedded structs (e.g. gorm.Model) are searched too
+func fieldByColumn(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if fv, ok := fieldByColumn(v.Field(i), column); ok {
+				return fv, true
+			}
+			continue
+		}
+		if f.PkgPath == "" && gorm.ToDBName(f.Name) == column {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Neighbor finds record next (or previous) to current (pointer to struct) by
+// order of column into ret. Ties by column are resolved by comparison of
+// primary key pkColumn: (column, pk) > (?, ?) ORDER BY column, pk LIMIT 1.
+// Column must be from orderedColumns: it's an error otherwise. If there is
+// no such record gorm.ErrRecordNotFound is returned.
+func Neighbor(db *gorm.DB, current interface{}, column, pkColumn string,
+	orderedColumns []string, next bool, ret interface{}) error {
+
+	if !isColumnOf(column, orderedColumns) {
+		return fmt.Errorf("can't find neighbor by field %q: it isn't ordered field", column)
+	}
+
+	v := reflect.ValueOf(current).Elem()
+	value, ok := fieldByColumn(v, column)
+	if !ok {
+		return fmt.Errorf("can't find neighbor by field %q: no such field", column)
+	}
+	pk, ok := fieldByColumn(v, pkColumn)
+	if !ok {
+		return fmt.Errorf("can't find neighbor by primary key %q: no such field", pkColumn)
+	}
+
+	op, dir := ">", "ASC"
+	if !next {
+		op, dir = "<", "DESC"
+	}
+	if column == pkColumn {
+		db = db.Where(fmt.Sprintf("%s %s ?", column, op), pk.Interface())
+	} else {
+		db = db.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, pkColumn, op),
+			value.Interface(), pk.Interface()).
+			Order(column + " " + dir)
+	}
+
+	// GORM adds condition by primary key of not empty ret
+	r := reflect.ValueOf(ret).Elem()
+	r.Set(reflect.Zero(r.Type()))
+	return db.Order(pkColumn + " " + dir).Limit(1).Find(ret).Error
+}
@@ -0,0 +1,19 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// LatestPerField selects only the latest versions of records of model
+// grouped by key column: records having max value of version column among
+// records with the same key. It's a correlated subquery, so it works
+// in any dialect. Records with equal max versions are all selected.
+func LatestPerField(db *gorm.DB, model interface{}, key, version string) *gorm.DB {
+	scope := db.NewScope(model)
+	table := scope.QuotedTableName()
+	qk, qv := scope.Quote(key), scope.Quote(version)
+	return db.Where(fmt.Sprintf("%s.%s = (SELECT MAX(latest.%s) FROM %s AS latest WHERE latest.%s = %s.%s)",
+		table, qv, qv, table, qk, table, qk))
+}
@@ -0,0 +1,69 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrRowLocked is returned when selected row is locked by another
+// transaction and it can't be locked immediately
+var ErrRowLocked = errors.New("row is locked by another transaction")
+
+// OneForUpdateNoWait selects first record of db into ret with
+// SELECT ... FOR UPDATE NOWAIT. It returns ErrRowLocked if lock
+// can't be acquired immediately. Only PostgreSQL and MySQL 8 are supported.
+func OneForUpdateNoWait(db *gorm.DB, ret interface{}) error {
+	db, err := withLockOption(db, "FOR UPDATE NOWAIT")
+	if err != nil {
+		return err
+	}
+
+	err = db.First(ret).Error
+	if err != nil && isLockNotAvailableError(err) {
+		return ErrRowLocked
+	}
+
+	return err
+}
+
+// FindForUpdateSkipLocked selects records of db into ret with
+// SELECT ... FOR UPDATE SKIP LOCKED: records locked by other transactions
+// are skipped. Only PostgreSQL and MySQL 8 are supported.
+func FindForUpdateSkipLocked(db *gorm.DB, ret interface{}) error {
+	db, err := withLockOption(db, "FOR UPDATE SKIP LOCKED")
+	if err != nil {
+		return err
+	}
+
+	return db.Find(ret).Error
+}
+
+// withLockOption returns copy of db with locking clause lock of select,
+// e.g. FOR UPDATE NOWAIT, supported only by PostgreSQL and MySQL 8
+func withLockOption(db *gorm.DB, lock string) (*gorm.DB, error) {
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "postgres", "mysql":
+	default:
+		return nil, fmt.Errorf("%s isn't supported by %s", lock, dialect)
+	}
+
+	// locking clause must precede options already set, e.g. comments
+	if prev, ok := db.Get("gorm:query_option"); ok && fmt.Sprint(prev) != "" {
+		lock += " " + fmt.Sprint(prev)
+	}
+
+	return db.Set("gorm:query_option", lock), nil
+}
+
+// isLockNotAvailableError checks for PostgreSQL lock_not_available (55P03)
+// and MySQL ER_LOCK_NOWAIT (3572) errors. Drivers aren't imported,
+// so errors are matched by text.
+func isLockNotAvailableError(err error) bool {
+	s := err.Error()
+	return strings.Contains(s, "55P03") ||
+		strings.Contains(s, "could not obtain lock on row") ||
+		strings.HasPrefix(s, "Error 3572")
+}
@@ -0,0 +1,24 @@
+package base
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/jinzhu/gorm"
+)
+
+var collationRe = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// OrderByCollate orders db by column compared using collation:
+// ORDER BY column COLLATE collation dir. Collation can't be passed as
+// bind var, so it's validated and quoted. Invalid collation is returned
+// as an error of query execution.
+func OrderByCollate(db *gorm.DB, column, collation, dir string) *gorm.DB {
+	quoted := db.NewScope(nil).Quote(collation)
+	db = db.Order(fmt.Sprintf("%s COLLATE %s %s", column, quoted, dir))
+	if !collationRe.MatchString(collation) {
+		db.AddError(fmt.Errorf("invalid collation %q", collation))
+	}
+
+	return db
+}
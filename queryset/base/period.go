@@ -0,0 +1,32 @@
+package base
+
+import "time"
+
+// Period is a calendar period: day, week or month
+type Period int
+
+// Calendar periods
+const (
+	PeriodDay Period = iota
+	PeriodWeek
+	PeriodMonth
+)
+
+// PeriodBounds returns half-open range [from, to) of calendar period p
+// containing t in location loc, e.g. [00:00, 24:00) of the day of t.
+// Weeks start on Monday.
+func PeriodBounds(t time.Time, loc *time.Location, p Period) (from, to time.Time) {
+	y, m, d := t.In(loc).Date()
+	switch p {
+	case PeriodWeek:
+		from = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+		return from, from.AddDate(0, 0, 7)
+	case PeriodMonth:
+		from = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 1, 0)
+	default:
+		from = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,34 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SelectInto selects only fields of db model records and scans them into
+// slice pointed by dest: elements of slice are projection structs with
+// fields named like selected columns. Fields must be in columns.
+func SelectInto(db *gorm.DB, dest interface{}, columns, fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("no fields to select")
+	}
+
+	isColumn := map[string]bool{}
+	for _, c := range columns {
+		isColumn[c] = true
+	}
+	for _, f := range fields {
+		if !isColumn[f] {
+			return fmt.Errorf("can't select field %q: no such column", f)
+		}
+	}
+
+	if t := reflect.TypeOf(dest); t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to slice, not %T", dest)
+	}
+
+	return db.Select(fields).Scan(dest).Error
+}
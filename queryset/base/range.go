@@ -0,0 +1,30 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ValueInFieldRange adds condition selecting records with value within range
+// of their columns: lowColumn <= value AND highColumn >= value. Columns must
+// be keys of columnTypes (column name => type name) and have the same type:
+// it's an error otherwise.
+func ValueInFieldRange(db *gorm.DB, lowColumn, highColumn string, value interface{},
+	columnTypes map[string]string) *gorm.DB {
+
+	lowType, ok := columnTypes[lowColumn]
+	if !ok {
+		return withError(db, fmt.Errorf("can't select by range of field %q: it isn't ordered field", lowColumn))
+	}
+	highType, ok := columnTypes[highColumn]
+	if !ok {
+		return withError(db, fmt.Errorf("can't select by range of field %q: it isn't ordered field", highColumn))
+	}
+	if lowType != highType {
+		return withError(db, fmt.Errorf("can't select by range of fields %q and %q: "+
+			"their types %s and %s differ", lowColumn, highColumn, lowType, highType))
+	}
+
+	return db.Where(fmt.Sprintf("%s <= ? AND %s >= ?", lowColumn, highColumn), value, value)
+}
@@ -0,0 +1,34 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AllRanked selects records of db model with their row numbers by
+// descending values of column into ret: ROW_NUMBER() OVER (ORDER BY column DESC)
+// is selected as rank column and records are ordered by it. Window functions
+// are supported by PostgreSQL, MySQL 8, SQLite 3.25 and MS SQL.
+func AllRanked(db *gorm.DB, column string, ret interface{}) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	scope := db.NewScope(db.Value)
+	switch dialect := scope.Dialect().GetName(); dialect {
+	case "postgres", "mysql", "sqlite3", "mssql":
+	default:
+		return fmt.Errorf("window functions aren't supported by %s", dialect)
+	}
+
+	rank := scope.Quote("rank") // RANK is a reserved word of MySQL 8
+	err := db.Select(fmt.Sprintf("%s.*, ROW_NUMBER() OVER (ORDER BY %s DESC) AS %s",
+		scope.QuotedTableName(), column, rank)).
+		Order(rank).
+		Scan(ret).Error
+	if err != nil {
+		return fmt.Errorf("can't select records ranked by %s: %s", column, err)
+	}
+	return nil
+}
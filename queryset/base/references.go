@@ -0,0 +1,64 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ValidateReferences checks that records referenced by set foreign keys
+// of belongs-to associations of o (pointer to struct) exist, e.g. before
+// creating o to return error naming missing reference instead of foreign
+// key constraint violation. Soft deleted records are missing too.
+func ValidateReferences(db *gorm.DB, o interface{}) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	scope := db.NewScope(o)
+	for _, field := range scope.Fields() {
+		rel := field.Relationship
+		if rel == nil || rel.Kind != "belongs_to" {
+			continue
+		}
+
+		conds, args := []string{}, []interface{}{}
+		blank := true
+		for i, fkName := range rel.ForeignFieldNames {
+			fk, ok := scope.FieldByName(fkName)
+			if !ok {
+				return fmt.Errorf("no foreign key %s of association %s", fkName, field.Name)
+			}
+			blank = blank && fk.IsBlank
+			conds = append(conds, rel.AssociationForeignDBNames[i]+" = ?")
+			args = append(args, fk.Field.Interface())
+		}
+		if blank { // reference isn't set
+			continue
+		}
+
+		assocType := field.Struct.Type
+		for assocType.Kind() == reflect.Slice || assocType.Kind() == reflect.Ptr {
+			assocType = assocType.Elem()
+		}
+		var n int
+		err := db.New().Model(reflect.New(assocType).Interface()).
+			Where(strings.Join(conds, " AND "), args...).
+			Count(&n).Error
+		if err != nil {
+			return fmt.Errorf("can't check reference %s of %s: %s", field.Name, scope.GetModelStruct().ModelType, err)
+		}
+		if n == 0 {
+			keys := make([]string, 0, len(args))
+			for i, column := range rel.AssociationForeignDBNames {
+				keys = append(keys, fmt.Sprintf("%s = %v", column, args[i]))
+			}
+			return fmt.Errorf("invalid reference %s of %s: no %s with %s",
+				field.Name, scope.GetModelStruct().ModelType, assocType.Name(), strings.Join(keys, " and "))
+		}
+	}
+
+	return nil
+}
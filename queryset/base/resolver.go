@@ -0,0 +1,70 @@
+package base
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	resolverKey = "queryset:resolver"
+	replicaKey  = "queryset:replica"
+)
+
+// Resolver resolves handles of primary db (for writes) and of read replica
+// (for reads which can be stale)
+type Resolver interface {
+	Primary() *gorm.DB
+	Replica() *gorm.DB
+}
+
+// ErrNoResolver is returned by query sets routed by UseReplica or UsePrimary
+// if resolver wasn't set by WithResolver
+var ErrNoResolver = errors.New("no resolver of db: it must be set by WithResolver")
+
+// WithResolver returns copy of db with resolver of replica and primary handles
+// for UseReplica and UsePrimary
+func WithResolver(db *gorm.DB, r Resolver) *gorm.DB {
+	return db.Set(resolverKey, r)
+}
+
+// UseReplica returns replica handle of resolver of db. GORM can't move
+// conditions of one handle to another one, so replica must be selected before
+// adding of conditions: it's an error otherwise.
+func UseReplica(db *gorm.DB) *gorm.DB {
+	return useHandle(db, true)
+}
+
+// UsePrimary returns primary handle of resolver of db. Like with UseReplica,
+// it must be selected before adding of conditions.
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return useHandle(db, false)
+}
+
+func useHandle(db *gorm.DB, replica bool) *gorm.DB {
+	r, ok := db.Get(resolverKey)
+	if !ok || r == nil {
+		return withError(db, ErrNoResolver)
+	}
+
+	if sql, _ := groupConditions(db); sql != "" {
+		return withError(db, errors.New("replica or primary db must be selected before conditions"))
+	}
+
+	resolver := r.(Resolver)
+	if replica {
+		return WithResolver(resolver.Replica().Set(replicaKey, true), resolver)
+	}
+	return WithResolver(resolver.Primary(), resolver)
+}
+
+// Primary returns primary handle of resolver if db is replica handle selected
+// by UseReplica: writes are always made on primary. Otherwise db is returned.
+func Primary(db *gorm.DB) *gorm.DB {
+	if replica, ok := db.Get(replicaKey); !ok || replica != true {
+		return db
+	}
+
+	r, _ := db.Get(resolverKey)
+	return WithResolver(r.(Resolver).Primary(), r.(Resolver))
+}
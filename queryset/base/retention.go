@@ -0,0 +1,20 @@
+package base
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// OlderThan adds condition selecting records with time column older than
+// current time minus d: column < cutoff. Column must be from timeColumns:
+// it's an error otherwise.
+func OlderThan(db *gorm.DB, column string, timeColumns []string, d time.Duration) *gorm.DB {
+	if !isColumnOf(column, timeColumns) {
+		return withError(db, fmt.Errorf("can't select records older than %s by field %q: "+
+			"it isn't time field", d, column))
+	}
+
+	return db.Where(fmt.Sprintf("%s < ?", column), gorm.NowFunc().Add(-d))
+}
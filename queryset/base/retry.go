@@ -0,0 +1,69 @@
+package base
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const retryKey = "queryset:retry"
+
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry returns copy of db retrying operations run by Retry up to attempts
+// times on deadlocks and serialization failures. Retries are made after
+// backoff, it's doubled after every retry.
+func WithRetry(db *gorm.DB, attempts int, backoff time.Duration) *gorm.DB {
+	return db.Set(retryKey, retryPolicy{attempts: attempts, backoff: backoff})
+}
+
+// Retry calls f while it returns retryable error (see IsRetryableError) for
+// at most attempts set by WithRetry: f is called once without WithRetry.
+// Operations in transaction aren't retried: deadlock aborts the whole
+// transaction, so it must be retried instead. Waiting for retry is stopped
+// if context of db set by WithContext is done.
+func Retry(db *gorm.DB, f func() error) error {
+	v, ok := db.Get(retryKey)
+	if !ok || isInTransaction(db) {
+		return f()
+	}
+
+	p := v.(retryPolicy)
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= p.attempts || !IsRetryableError(db, err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-Context(db).Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// IsRetryableError returns true for errors of deadlocks and serialization
+// failures recognized for dialect of db: MySQL error 1213 and PostgreSQL
+// errors with SQLSTATE 40001 and 40P01
+func IsRetryableError(db *gorm.DB, err error) bool {
+	switch db.NewScope(nil).Dialect().GetName() {
+	case "mysql":
+		return ErrorCode(err) == "1213"
+	case "postgres":
+		if code := ErrorCode(err); code != "" {
+			return code == "40001" || code == "40P01"
+		}
+		msg := err.Error()
+		return strings.Contains(msg, "deadlock detected") ||
+			strings.Contains(msg, "could not serialize access")
+	default:
+		return false
+	}
+}
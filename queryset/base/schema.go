@@ -0,0 +1,109 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Kinds of columns of VerifySchema
+const (
+	ColumnKindString  = "string"
+	ColumnKindNumeric = "numeric"
+	ColumnKindBool    = "bool"
+	ColumnKindTime    = "time"
+)
+
+// VerifySchema compares columns of table of model in database with expected
+// columns: map of names of columns to their kinds (ColumnKind* or empty
+// string for any kind). All missing columns and columns of other kinds are
+// reported by one error. Extra columns of table aren't errors.
+// Only MySQL and PostgreSQL are supported.
+func VerifySchema(db *gorm.DB, model interface{}, columns map[string]string) error {
+	scope := db.NewScope(model)
+	table := scope.TableName()
+
+	var query string
+	switch dialect := scope.Dialect().GetName(); dialect {
+	case "mysql":
+		query = "SELECT column_name, data_type FROM information_schema.columns " +
+			"WHERE table_schema = DATABASE() AND table_name = ?"
+	case "postgres":
+		query = "SELECT column_name, data_type FROM information_schema.columns " +
+			"WHERE table_schema = current_schema() AND table_name = $1"
+	default:
+		return fmt.Errorf("schema verification isn't supported by %s", dialect)
+	}
+
+	rows, err := scope.SQLDB().Query(query, table)
+	if err != nil {
+		return fmt.Errorf("can't select columns of table %s: %s", table, err)
+	}
+	defer rows.Close()
+
+	dataTypes := map[string]string{}
+	for rows.Next() {
+		var name, dataType string
+		if err = rows.Scan(&name, &dataType); err != nil {
+			return fmt.Errorf("can't scan column of table %s: %s", table, err)
+		}
+		dataTypes[strings.ToLower(name)] = strings.ToLower(dataType)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("can't iterate columns of table %s: %s", table, err)
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := []string{}
+	for _, name := range names {
+		dataType, ok := dataTypes[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("no column %s", name))
+			continue
+		}
+
+		if kind := columns[name]; kind != "" && !isColumnOfKind(dataType, kind) {
+			problems = append(problems, fmt.Sprintf("column %s has type %s, expected %s",
+				name, dataType, kind))
+		}
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("schema of table %s differs from model: %s",
+			table, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isColumnOfKind checks that SQL type dataType is compatible with kind
+func isColumnOfKind(dataType, kind string) bool {
+	has := func(parts ...string) bool {
+		for _, p := range parts {
+			if strings.Contains(dataType, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch kind {
+	case ColumnKindString:
+		return has("char", "text", "enum", "uuid")
+	case ColumnKindNumeric:
+		return has("int", "dec", "numeric", "float", "double", "real", "serial")
+	case ColumnKindBool:
+		// MySQL stores bools as tinyint(1)
+		return has("bool", "tinyint", "bit")
+	case ColumnKindTime:
+		return has("time", "date")
+	default:
+		return true
+	}
+}
@@ -0,0 +1,52 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// LikeEscapeChar is the escape character of EscapeLike. Conditions must pass it
+// as a bind var of ESCAPE clause: LIKE ? ESCAPE ?. A backslash literal can't
+// be written portably: '\' is unterminated string in MySQL by default.
+const LikeEscapeChar = `\`
+
+// EscapeLike escapes wildcards % and _ of s by LikeEscapeChar to match s
+// literally in LIKE pattern with ESCAPE clause
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// Search adds condition matching records containing term in any of columns
+// case-insensitively: (LOWER(a) LIKE ? ESCAPE ? OR LOWER(b) LIKE ? ESCAPE ?). Wildcards of term
+// are escaped. Columns must be from stringColumns: it's an error otherwise.
+func Search(db *gorm.DB, term string, stringColumns, columns []string) *gorm.DB {
+	if len(columns) == 0 {
+		return withError(db, fmt.Errorf("no fields to search %q by", term))
+	}
+
+	pattern := "%" + EscapeLike(strings.ToLower(term)) + "%"
+	preds := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, 2*len(columns))
+	for _, c := range columns {
+		if !isColumnOf(c, stringColumns) {
+			return withError(db, fmt.Errorf("can't search by field %q: it isn't string field", c))
+		}
+		preds = append(preds, fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE ?", c))
+		args = append(args, pattern, LikeEscapeChar)
+	}
+
+	return db.Where(strings.Join(preds, " OR "), args...)
+}
+
+func isColumnOf(column string, columns []string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
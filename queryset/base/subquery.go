@@ -0,0 +1,58 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Aggregate is an aggregate function selecting value of scalar subquery
+type Aggregate string
+
+// Aggregates of scalar subqueries
+const (
+	AggregateAvg   Aggregate = "AVG"
+	AggregateMin   Aggregate = "MIN"
+	AggregateMax   Aggregate = "MAX"
+	AggregateSum   Aggregate = "SUM"
+	AggregateCount Aggregate = "COUNT"
+)
+
+// SubQuery is a scalar subquery selecting one value, it's built
+// by ScalarSubQuery and used in conditions by CompareScalarSubQuery
+type SubQuery struct {
+	sql  string
+	args []interface{}
+	err  error
+}
+
+// ScalarSubQuery returns subquery selecting aggregate agg of column
+// of records of model matching conditions of db,
+// e.g. SELECT AVG(age) FROM users WHERE ...
+func ScalarSubQuery(db *gorm.DB, model interface{}, agg Aggregate, column string) SubQuery {
+	switch agg {
+	case AggregateAvg, AggregateMin, AggregateMax, AggregateSum, AggregateCount:
+	default:
+		return SubQuery{err: fmt.Errorf("unknown aggregate %q of subquery", agg)}
+	}
+	if db.Error != nil {
+		return SubQuery{err: db.Error}
+	}
+
+	scope := db.NewScope(model)
+	sql := fmt.Sprintf("SELECT %s(%s) FROM %s", agg, column, scope.QuotedTableName())
+	if cond := scope.CombinedConditionSql(); cond != "" {
+		sql += " " + cond
+	}
+	return SubQuery{sql: toPlaceholders(scope, sql), args: scope.SQLVars}
+}
+
+// CompareScalarSubQuery adds condition comparing column by operator op
+// with value selected by scalar subquery sub: column op (SELECT ...)
+func CompareScalarSubQuery(db *gorm.DB, column, op string, sub SubQuery) *gorm.DB {
+	if sub.err != nil {
+		return withError(db, sub.err)
+	}
+
+	return db.Where(fmt.Sprintf("%s %s (%s)", column, op, sub.sql), sub.args...)
+}
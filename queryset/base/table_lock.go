@@ -0,0 +1,74 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+var postgresTableLockModes = []string{"ACCESS SHARE", "ROW SHARE", "ROW EXCLUSIVE",
+	"SHARE UPDATE EXCLUSIVE", "SHARE", "SHARE ROW EXCLUSIVE", "EXCLUSIVE", "ACCESS EXCLUSIVE"}
+
+// LockTable explicitly locks table of model in mode, e.g. for consistent
+// bulk operations: LOCK TABLES t mode in MySQL (READ or WRITE) and
+// LOCK TABLE t IN mode MODE in PostgreSQL. Lock is held by connection,
+// so db must be a transaction. PostgreSQL locks are released at the end
+// of transaction.
+//
+// WARNING: in MySQL LOCK TABLES and UNLOCK TABLES implicitly commit
+// transaction, so db only pins connection for MySQL locks: statements
+// before LockTable are committed by it, statements between LockTable and
+// UnlockTables are committed one by one and aren't rolled back with
+// transaction. MySQL locks are released by UnlockTables.
+func LockTable(db *gorm.DB, model interface{}, mode string) error {
+	if !isInTransaction(db) {
+		return errors.New("table must be locked in transaction")
+	}
+
+	table := db.NewScope(model).QuotedTableName()
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	var sql string
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "mysql":
+		if mode != "READ" && mode != "WRITE" {
+			return fmt.Errorf("invalid MySQL table lock mode %q: READ or WRITE are supported", mode)
+		}
+		sql = fmt.Sprintf("LOCK TABLES %s %s", table, mode)
+	case "postgres":
+		if !isColumnOf(mode, postgresTableLockModes) {
+			return fmt.Errorf("invalid PostgreSQL table lock mode %q: one of %s is expected",
+				mode, strings.Join(postgresTableLockModes, ", "))
+		}
+		sql = fmt.Sprintf("LOCK TABLE %s IN %s MODE", table, mode)
+	default:
+		return fmt.Errorf("table locks aren't supported by %s", dialect)
+	}
+
+	if err := db.Exec(sql).Error; err != nil {
+		return fmt.Errorf("can't lock table %s: %s", table, err)
+	}
+	return nil
+}
+
+// UnlockTables releases MySQL table locks of connection of transaction db
+// acquired by LockTable. PostgreSQL table locks can't be released explicitly:
+// they are released by commit or rollback of transaction.
+func UnlockTables(db *gorm.DB) error {
+	if !isInTransaction(db) {
+		return errors.New("tables must be unlocked in transaction of their lock")
+	}
+
+	switch dialect := db.NewScope(nil).Dialect().GetName(); dialect {
+	case "mysql":
+		if err := db.Exec("UNLOCK TABLES").Error; err != nil {
+			return fmt.Errorf("can't unlock tables: %s", err)
+		}
+		return nil
+	case "postgres":
+		return errors.New("PostgreSQL table locks are released at the end of transaction")
+	default:
+		return fmt.Errorf("table locks aren't supported by %s", dialect)
+	}
+}
@@ -0,0 +1,43 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IntoTempTable creates temporary table name from records of db model
+// selected with conditions of db: CREATE TEMPORARY TABLE name AS SELECT ...
+// (SELECT ... INTO #name in MS SQL). Temporary table is visible only
+// to connection, so db must be a transaction: queries joining it must
+// be made on the same transaction.
+func IntoTempTable(db *gorm.DB, name string) error {
+	if db.Error != nil {
+		return db.Error
+	}
+	if !isInTransaction(db) {
+		return errors.New("temporary table must be created in transaction")
+	}
+
+	scope := db.NewScope(db.Value)
+	var sql string
+	switch dialect := scope.Dialect().GetName(); dialect {
+	case "mssql":
+		sql = fmt.Sprintf("SELECT * INTO %s FROM %s %s", scope.Quote("#"+name),
+			scope.QuotedTableName(), scope.CombinedConditionSql())
+	case "mysql", "postgres", "sqlite3":
+		sql = fmt.Sprintf("CREATE TEMPORARY TABLE %s AS SELECT * FROM %s %s", scope.Quote(name),
+			scope.QuotedTableName(), scope.CombinedConditionSql())
+	default:
+		return fmt.Errorf("temporary tables aren't supported by %s", dialect)
+	}
+
+	// conditions are bound to scope.SQLVars, Raw replaces placeholders
+	// of common dialect by "?"
+	scope.Raw(sql)
+	if _, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); err != nil {
+		return fmt.Errorf("can't create temporary table %s: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package base
+
+import "github.com/jinzhu/gorm"
+
+const tracerKey = "queryset:tracer"
+
+// Tracer starts spans of terminal operations of query sets. It's a subset
+// of OpenTelemetry trace.Tracer: adapter to it is a few lines of code.
+type Tracer interface {
+	Start(name string) Span
+}
+
+// Span is a span of terminal operation: a subset of OpenTelemetry trace.Span
+type Span interface {
+	// SetAttribute sets attribute of span, e.g. executed SQL by "db.statement" key
+	SetAttribute(key, value string)
+
+	// SetError records error of operation and sets status of span to error
+	SetError(err error)
+
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key, value string) {}
+func (noopSpan) SetError(err error)             {}
+func (noopSpan) End()                           {}
+
+// WithTracer returns copy of db with tracer for spans of terminal operations
+func WithTracer(db *gorm.DB, tracer Tracer) *gorm.DB {
+	return db.Set(tracerKey, tracer)
+}
+
+// StartSpan starts span name by tracer of db (set by WithTracer) and returns
+// copy of db recording executed SQL as span "db.statement" attribute. If db
+// has no tracer span is no-op and db isn't changed.
+func StartSpan(db *gorm.DB, name string) (*gorm.DB, Span) {
+	t, ok := db.Get(tracerKey)
+	if !ok || t == nil {
+		return db, noopSpan{}
+	}
+
+	span := t.(Tracer).Start(name)
+
+	// SQL is available only by logger: it's set to copy of db, not to db
+	db = db.Set(tracerKey, t).LogMode(true)
+	db.SetLogger(spanLogger{span: span})
+	return db, span
+}
+
+// spanLogger is a GORM logger recording SQL to span
+type spanLogger struct {
+	span Span
+}
+
+func (l spanLogger) Print(v ...interface{}) {
+	// GORM prints SQL as "sql", file, duration, sql, vars, ...
+	if len(v) < 4 || v[0] != "sql" {
+		return
+	}
+
+	if sql, ok := v[3].(string); ok {
+		l.span.SetAttribute("db.statement", sql)
+	}
+}
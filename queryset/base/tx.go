@@ -0,0 +1,73 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// InTransaction calls f with transaction: if db is already a transaction
+// it's used as is, otherwise new transaction is started and committed
+// (or rolled back on error of f).
+func InTransaction(db *gorm.DB, f func(tx *gorm.DB) error) error {
+	if isInTransaction(db) {
+		return f(db)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("can't begin transaction: %s", tx.Error)
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err)
+	}
+	return nil
+}
+
+func isInTransaction(db *gorm.DB) bool {
+	_, ok := db.CommonDB().(*sql.Tx)
+	return ok
+}
+
+// Creator is a model with generated Create method
+type Creator interface {
+	Create(db *gorm.DB) error
+}
+
+// TxBuilder batches operations of models to run them in one transaction
+// by Commit. It's immutable: every method returns changed copy.
+type TxBuilder struct {
+	db  *gorm.DB
+	ops []func(tx *gorm.DB) error
+}
+
+// NewTx constructs new TxBuilder running operations on db
+func NewTx(db *gorm.DB) TxBuilder {
+	return TxBuilder{db: db}
+}
+
+// Create adds creation of model o by its Create method
+func (b TxBuilder) Create(o Creator) TxBuilder {
+	b.ops = append(b.ops[:len(b.ops):len(b.ops)], o.Create)
+	return b
+}
+
+// Commit runs operations in order of addition in one transaction (see
+// InTransaction): it's rolled back on the first error of operation.
+func (b TxBuilder) Commit() error {
+	return InTransaction(b.db, func(tx *gorm.DB) error {
+		for _, op := range b.ops {
+			if err := op(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
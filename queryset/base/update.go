@@ -0,0 +1,75 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+const allowGlobalUpdateKey = "queryset:allow_global_update"
+
+// ErrNoConditions is returned by bulk updates of query sets without
+// conditions: update of all records must be allowed explicitly
+var ErrNoConditions = errors.New("no conditions of bulk update: " +
+	"update of all records must be allowed by AllowGlobalUpdate")
+
+// AllowGlobalUpdate returns copy of db allowing bulk updates without conditions
+func AllowGlobalUpdate(db *gorm.DB) *gorm.DB {
+	return db.Set(allowGlobalUpdateKey, true)
+}
+
+// CheckConditions returns ErrNoConditions if db has no conditions
+// and bulk updates without conditions weren't allowed by AllowGlobalUpdate
+func CheckConditions(db *gorm.DB) error {
+	if allowed, ok := db.Get(allowGlobalUpdateKey); ok && allowed == true {
+		return nil
+	}
+
+	if sql, _ := groupConditions(db); sql == "" {
+		return ErrNoConditions
+	}
+	return nil
+}
+
+// UpdateColumnsInOrder sets columns to values for records of model matching
+// conditions of db by one UPDATE. Unlike UpdateColumns with map columns are
+// set in order of passed ones. Hooks aren't called and updated_at isn't changed.
+func UpdateColumnsInOrder(db *gorm.DB, model interface{}, columns []string,
+	values ...interface{}) (int64, error) {
+
+	if len(columns) != len(values) {
+		return 0, fmt.Errorf("%d values for %d columns", len(values), len(columns))
+	}
+
+	scope := db.NewScope(model)
+	sets := make([]string, 0, len(columns))
+	for i, c := range columns {
+		sets = append(sets, fmt.Sprintf("%s = %s", scope.Quote(c), scope.AddToVars(values[i])))
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s", scope.QuotedTableName(), strings.Join(sets, ", "))
+	if cond := scope.CombinedConditionSql(); cond != "" {
+		sql += " " + cond
+	}
+	res := scope.Raw(sql).Exec().DB()
+	return res.RowsAffected, res.Error
+}
+
+// CheckPrimaryKey returns error if primary key of model (all its fields for
+// composite key) isn't set: GORM updates and deletes all records then
+func CheckPrimaryKey(db *gorm.DB, model interface{}) error {
+	scope := db.NewScope(model)
+	fields := scope.PrimaryFields()
+	if len(fields) == 0 {
+		return fmt.Errorf("%s has no primary key", scope.TableName())
+	}
+
+	for _, f := range fields {
+		if f.IsBlank {
+			return fmt.Errorf("primary key field %s of %s isn't set", f.Name, scope.TableName())
+		}
+	}
+	return nil
+}
@@ -7,20 +7,27 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/jirfag/go-queryset/parser"
 	"golang.org/x/tools/go/loader"
 )
 
-// GenerateQuerySets generates output file with querysets
+// GenerateQuerySets generates output file with querysets. Input path is a file
+// or a directory of package: structs of all its files are used then.
 func GenerateQuerySets(inFilePath, outFilePath string) error {
-	pkgInfo, structs, err := parser.GetStructsInFile(inFilePath)
+	return GenerateQuerySetsWithBackend(GormBackend{}, inFilePath, outFilePath)
+}
+
+// GenerateQuerySetsWithBackend generates output file with querysets for backend b
+func GenerateQuerySetsWithBackend(b Backend, inFilePath, outFilePath string) error {
+	pkgInfo, structs, err := getStructs(inFilePath, outFilePath)
 	if err != nil {
-		return fmt.Errorf("can't parse file %s to get structs: %s", inFilePath, err)
+		return err
 	}
 
 	var r io.Reader
-	r, err = GenerateQuerySetsForStructs(pkgInfo, structs)
+	r, err = GenerateQuerySetsForStructsWithBackend(b, pkgInfo, structs)
 	if err != nil {
 		return fmt.Errorf("can't generate query sets: %s", err)
 	}
@@ -29,7 +36,8 @@ func GenerateQuerySets(inFilePath, outFilePath string) error {
 		return fmt.Errorf("no structs to generate query set in %s", inFilePath)
 	}
 
-	if err = writeQuerySetsToOutput(r, pkgInfo, outFilePath); err != nil {
+	hdr := getFileHeader(b, pkgInfo.Pkg.Name())
+	if err = writeQuerySetsToOutput(r, hdr, outFilePath); err != nil {
 		return fmt.Errorf("can't save query sets to out file %s: %s", outFilePath, err)
 	}
 
@@ -43,7 +51,40 @@ func GenerateQuerySets(inFilePath, outFilePath string) error {
 	return nil
 }
 
-func writeQuerySetsToOutput(r io.Reader, pkgInfo *loader.PackageInfo, outFile string) error {
+// getStructs returns structs of input file or of all files of input
+// directory except output file
+func getStructs(inPath, outFilePath string) (*loader.PackageInfo, parser.ParsedStructs, error) {
+	fi, err := os.Stat(inPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't stat %s: %s", inPath, err)
+	}
+
+	if fi.IsDir() {
+		pkgInfo, structs, err := parser.GetStructsInDir(inPath, outFilePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't parse files of %s to get structs: %s", inPath, err)
+		}
+		return pkgInfo, structs, nil
+	}
+
+	pkgInfo, structs, err := parser.GetStructsInFile(inPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't parse file %s to get structs: %s", inPath, err)
+	}
+	return pkgInfo, structs, nil
+}
+
+// getFileHeader returns package clause and imports of backend b
+// for generated file of package pkgName
+func getFileHeader(b Backend, pkgName string) string {
+	hdr := fmt.Sprintf("package %s\n", pkgName)
+	if imports := b.GetImports(); len(imports) != 0 {
+		hdr += fmt.Sprintf("import (\n\t%s\n)\n", strings.Join(imports, "\n\t"))
+	}
+	return hdr
+}
+
+func writeQuerySetsToOutput(r io.Reader, hdr, outFile string) error {
 	var outF *os.File
 	outF, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
 	if err != nil {
@@ -55,13 +96,7 @@ func writeQuerySetsToOutput(r io.Reader, pkgInfo *loader.PackageInfo, outFile st
 		}
 	}()
 
-	const hdrTmpl = `package %s
-  import (
-    "github.com/jinzhu/gorm"
-    "github.com/jirfag/go-queryset/queryset/base"
-  )
-`
-	_, err = outF.WriteString(fmt.Sprintf(hdrTmpl, pkgInfo.Pkg.Name()))
+	_, err = outF.WriteString(hdr)
 	if err != nil {
 		return fmt.Errorf("can't write hdr string into out file: %s", err)
 	}
@@ -0,0 +1,992 @@
+package queryset
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jirfag/go-queryset/queryset/methods"
+)
+
+var qsTmpl = template.Must(
+	template.New("generator").
+		Funcs(template.FuncMap{
+			"lcf":                  methods.LowercaseFirstRune,
+			"todbname":             gorm.ToDBName,
+			"autoUpdateTimeFields": getAutoUpdateTimeFieldNames,
+			"rangeFilterFields":    getRangeFilterFields,
+			"filterInputFields":    getFilterInputFields,
+			"enumFields":           getEnumFields,
+			"enumMembers":          FieldInfo.getEnumMembers,
+			"enumMembersVar":       methods.GetEnumMembersVarName,
+			"activeFlagColumn":     getActiveFlagColumn,
+			"tenantColumn":         getTenantColumn,
+		}).
+		Parse(qsCode),
+)
+
+// GormBackend is a default backend: generated code uses GORM
+type GormBackend struct{}
+
+// GetMethods returns methods of query set and updater for struct
+func (b GormBackend) GetMethods(s StructInfo) []methods.Method {
+	return getMethodsForStruct(s)
+}
+
+// GetDeclarationsTemplate returns template of GORM query set, updater
+// and other types of struct
+func (b GormBackend) GetDeclarationsTemplate() *template.Template {
+	return qsTmpl
+}
+
+// GetImports returns imports of GORM query sets
+func (b GormBackend) GetImports() []string {
+	return []string{
+		`"github.com/jinzhu/gorm"`,
+		`"github.com/jirfag/go-queryset/queryset/base"`,
+	}
+}
+
+// getQuerySetMethodsForField returns methods of query set for field f:
+// conditions of filters of described field are described for Describe method,
+// isPK is set for field of primary key
+func getQuerySetMethodsForField(f FieldInfo, structTypeName, qsTypeName string,
+	described, isPK bool) []methods.Method {
+
+	truncate := f.getTruncateTimePrecision()
+	newBinaryFilterMethod := func(name string) methods.Method {
+		m := methods.NewBinaryFilterMethod(name, f.Name, f.TypeName, qsTypeName)
+		if truncate != "" {
+			m = methods.NewTruncatedTimeFilterMethod(name, f.Name, f.TypeName, qsTypeName, truncate)
+		}
+		if described && name != "like" {
+			return m.Described()
+		}
+		return m
+	}
+
+	basicTypeMethods := []methods.Method{
+		newBinaryFilterMethod("eq"),
+		newBinaryFilterMethod("ne"),
+	}
+	numericMethods := []methods.Method{
+		newBinaryFilterMethod("lt"),
+		newBinaryFilterMethod("gt"),
+		newBinaryFilterMethod("lte"),
+		newBinaryFilterMethod("gte"),
+		methods.NewOrderAscByMethod(f.Name, qsTypeName),
+		methods.NewOrderDescByMethod(f.Name, qsTypeName),
+		methods.NewMinMaxMethod(f.Name, f.TypeName, qsTypeName, structTypeName),
+	}
+	if !f.IsTime {
+		numericMethods = append(numericMethods,
+			methods.NewInFilterMethod(f.Name, f.TypeName, qsTypeName),
+			methods.NewNotInFilterMethod(f.Name, f.TypeName, qsTypeName))
+	}
+
+	// aggregates of primary keys are meaningless
+	if !f.IsTime && !isPK {
+		for _, agg := range []string{"Sum", "Avg", "Max", "Min"} {
+			// integer sums and bounds are exact in type of field
+			retTypeName := "float64"
+			if agg == "Max" || agg == "Min" || agg == "Sum" && f.IsInteger {
+				retTypeName = f.TypeName
+			}
+			numericMethods = append(numericMethods,
+				methods.NewAggregateMethod(agg, f.Name, retTypeName, qsTypeName, structTypeName))
+		}
+	}
+
+	if f.IsTime {
+		numericMethods = append(numericMethods,
+			methods.NewDateInLocationFilterMethod(f.Name, qsTypeName),
+			methods.NewCurrentPeriodFilterMethod(f.Name, "Today", "PeriodDay", qsTypeName),
+			methods.NewCurrentPeriodFilterMethod(f.Name, "ThisWeek", "PeriodWeek", qsTypeName),
+			methods.NewCurrentPeriodFilterMethod(f.Name, "ThisMonth", "PeriodMonth", qsTypeName))
+	}
+
+	if f.isBitmaskField() {
+		numericMethods = append(numericMethods,
+			methods.NewHasFlagFilterMethod(f.Name, f.TypeName, qsTypeName),
+			methods.NewLacksFlagFilterMethod(f.Name, f.TypeName, qsTypeName))
+	}
+
+	if f.IsNumeric {
+		return append(basicTypeMethods, numericMethods...)
+	}
+
+	if f.IsStruct {
+		// Association was found (any struct or struct pointer)
+		return []methods.Method{methods.NewPreloadMethod(f.Name, qsTypeName)}
+	}
+
+	if f.IsPointer {
+		ptrMethods := getQuerySetMethodsForField(f.GetPointed(), structTypeName, qsTypeName, false, false)
+		ptrMethods = append(ptrMethods, methods.NewIsNullMethod(f.Name, qsTypeName))
+		if p := f.GetPointed(); methods.GetNullTypeName(p.TypeName) != "" {
+			ptrMethods = append(ptrMethods, methods.NewEqNullableMethod(f.Name, p.TypeName, qsTypeName))
+		}
+		return ptrMethods
+	}
+
+	if f.isJSONArrayField() {
+		// JSON documents aren't ordered or matched by patterns as strings
+		return append(basicTypeMethods, methods.NewJSONArrayContainsMethod(f.Name, qsTypeName))
+	}
+
+	if f.IsString && f.getEnumMembers() != nil {
+		basicTypeMethods = []methods.Method{
+			methods.NewEnumEqFilterMethod(structTypeName, f.Name, f.TypeName, qsTypeName, described),
+			methods.NewEnumNeFilterMethod(structTypeName, f.Name, f.TypeName, qsTypeName, described),
+			methods.NewEnumInFilterMethod(structTypeName, f.Name, f.TypeName, qsTypeName),
+		}
+	} else if f.IsString {
+		// strings are ordered by collation of column
+		basicTypeMethods = append(basicTypeMethods,
+			methods.NewInFilterMethod(f.Name, f.TypeName, qsTypeName),
+			methods.NewNotInFilterMethod(f.Name, f.TypeName, qsTypeName),
+			newBinaryFilterMethod("lt"),
+			newBinaryFilterMethod("gt"),
+			newBinaryFilterMethod("lte"),
+			newBinaryFilterMethod("gte"),
+			newBinaryFilterMethod("like"),
+			methods.NewContainsFilterMethod(f.Name, f.TypeName, qsTypeName),
+			methods.NewStartsWithFilterMethod(f.Name, f.TypeName, qsTypeName),
+			methods.NewEndsWithFilterMethod(f.Name, f.TypeName, qsTypeName))
+	}
+
+	if f.IsString {
+		return append(basicTypeMethods,
+			methods.NewOrderAscByMethod(f.Name, qsTypeName),
+			methods.NewOrderDescByMethod(f.Name, qsTypeName),
+			methods.NewOrderAscByCollateMethod(f.Name, qsTypeName),
+			methods.NewOrderDescByCollateMethod(f.Name, qsTypeName))
+	}
+
+	// it's a bool
+	return basicTypeMethods
+}
+
+func getQuerySetFieldMethods(fields []FieldInfo, structTypeName, qsTypeName string) []methods.Method {
+	ret := []methods.Method{}
+	for _, f := range fields {
+		// described fields are fields of FromDescription
+		described := !f.IsStruct && !f.IsPointer
+		methods := getQuerySetMethodsForField(f, structTypeName, qsTypeName, described,
+			isPrimaryKeyField(f, fields))
+		ret = append(ret, methods...)
+	}
+
+	return ret
+}
+
+func getUpdaterTypeName(structTypeName string) string {
+	return structTypeName + "Updater"
+}
+
+func getDBSchemaFieldTypeName(structTypeName string) string {
+	return methods.LowercaseFirstRune(structTypeName + "DBSchemaField")
+}
+
+func getUpdaterMethods(s StructInfo) []methods.Method {
+	fields, structTypeName := s.Fields, s.Name
+	updaterTypeName := getUpdaterTypeName(structTypeName)
+	dbSchemaTypeName := structTypeName + "DBSchema"
+
+	autoTimeFieldNames := []string{}
+	for _, f := range fields {
+		if f.isAutoUpdateTimeField() {
+			autoTimeFieldNames = append(autoTimeFieldNames, f.Name)
+		}
+	}
+
+	ret := []methods.Method{
+		methods.NewUpdaterUpdateMethod(updaterTypeName, dbSchemaTypeName, autoTimeFieldNames),
+		methods.NewUpdaterUpdateNumMethod(updaterTypeName, dbSchemaTypeName, autoTimeFieldNames),
+	}
+	for _, name := range methods.FilterOperatorNames {
+		ret = append(ret, methods.NewUpdaterWhereMethod(updaterTypeName,
+			getDBSchemaFieldTypeName(structTypeName), name))
+	}
+	for _, f := range fields {
+		if f.IsPointer {
+			// TODO
+			continue
+		}
+		if f.isReadOnlyField() {
+			continue
+		}
+		if s.TenantField != nil && f.Name == s.TenantField.Name {
+			continue // records can't be moved to other tenant
+		}
+		if f.getEnumMembers() != nil {
+			ret = append(ret,
+				methods.NewEnumUpdaterSetMethod(structTypeName, f.Name, f.TypeName,
+					updaterTypeName, dbSchemaTypeName))
+			continue
+		}
+		ret = append(ret,
+			methods.NewUpdaterSetMethod(f.Name, f.TypeName, updaterTypeName,
+				dbSchemaTypeName))
+		if f.IsNumeric && !f.IsTime && !isPrimaryKeyField(f, fields) {
+			ret = append(ret,
+				methods.NewUpdaterIncrementMethod(f.Name, f.TypeName, updaterTypeName,
+					dbSchemaTypeName, false),
+				methods.NewUpdaterIncrementMethod(f.Name, f.TypeName, updaterTypeName,
+					dbSchemaTypeName, true))
+		}
+	}
+	return ret
+}
+
+// isSoftDeleteStruct checks for deleted_at column: GORM doesn't delete
+// records having it, but sets it to deletion time
+func isSoftDeleteStruct(fields []FieldInfo) bool {
+	for _, f := range fields {
+		if gorm.ToDBName(f.Name) == "deleted_at" {
+			return true
+		}
+	}
+	return false
+}
+
+// getRangeFilterFields returns ordered fields to filter by ranges
+func getRangeFilterFields(fields []FieldInfo) []FieldInfo {
+	ret := []FieldInfo{}
+	for _, f := range fields {
+		if f.IsNumeric {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// getFilterInputFields returns ordered fields of GraphQL-style filter input
+func getFilterInputFields(fields []FieldInfo) []FieldInfo {
+	ret := []FieldInfo{}
+	for _, f := range fields {
+		if !f.IsStruct && !f.IsPointer {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// getColumnFieldNames returns names of fields stored in columns
+func getColumnFieldNames(fields []FieldInfo) []string {
+	fieldNames := []string{}
+	for _, f := range fields {
+		if f.IsStruct || f.IsPointer && f.GetPointed().IsStruct {
+			continue // associations aren't columns
+		}
+		fieldNames = append(fieldNames, f.Name)
+	}
+	return fieldNames
+}
+
+// getColumnKind returns kind of column of field for schema verification:
+// empty kind for fields of other types
+func getColumnKind(f FieldInfo) string {
+	if f.IsPointer {
+		return getColumnKind(f.GetPointed())
+	}
+
+	switch {
+	case f.IsTime:
+		return "time"
+	case f.IsString:
+		return "string"
+	case f.IsNumeric:
+		return "numeric"
+	case f.TypeName == "bool":
+		return "bool"
+	}
+	return ""
+}
+
+func getVerifySchemaMethod(structTypeName string, fields []FieldInfo) methods.Method {
+	columnNames, columnKinds := []string{}, []string{}
+	for _, f := range fields {
+		if f.IsStruct || f.IsPointer && f.GetPointed().IsStruct {
+			continue // associations aren't columns
+		}
+		columnNames = append(columnNames, gorm.ToDBName(f.Name))
+		columnKinds = append(columnKinds, getColumnKind(f))
+	}
+	return methods.NewVerifySchemaMethod(structTypeName, columnNames, columnKinds)
+}
+
+// schemaField is a field of model in JSON of {Struct}SchemaJSON func
+type schemaField struct {
+	Name       string `json:"name"`
+	Column     string `json:"column"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primaryKey"`
+}
+
+func getSchemaJSONMethod(structTypeName string, fields []FieldInfo) methods.Method {
+	schema := struct {
+		Model  string        `json:"model"`
+		Fields []schemaField `json:"fields"`
+	}{
+		Model:  structTypeName,
+		Fields: []schemaField{},
+	}
+	for _, f := range fields {
+		if f.IsStruct || f.IsPointer && f.GetPointed().IsStruct {
+			continue // associations aren't columns
+		}
+		schema.Fields = append(schema.Fields, schemaField{
+			Name:       f.Name,
+			Column:     gorm.ToDBName(f.Name),
+			Type:       f.TypeName,
+			Nullable:   f.IsPointer || strings.HasPrefix(f.TypeName, "sql.Null"),
+			PrimaryKey: isPrimaryKeyField(f, fields),
+		})
+	}
+
+	schemaJSON, err := json.MarshalIndent(schema, "", "\t")
+	if err != nil {
+		log.Fatalf("can't marshal schema of %s: %s", structTypeName, err)
+	}
+	return methods.NewSchemaJSONMethod(structTypeName, string(schemaJSON))
+}
+
+func getDiffFromDBMethod(structTypeName string, fields []FieldInfo) methods.Method {
+	return methods.NewDiffFromDBMethod(structTypeName,
+		getDBSchemaFieldTypeName(structTypeName), getColumnFieldNames(fields))
+}
+
+func getPreloadForMethods(s StructInfo) []methods.Method {
+	ret := []methods.Method{}
+	for _, f := range s.Fields {
+		if f.Name != "ID" {
+			continue
+		}
+		for _, hm := range s.HasMany {
+			ret = append(ret, methods.NewPreloadForMethod(s.Name, f.TypeName,
+				hm.Name, hm.ElemTypeName))
+		}
+	}
+	return ret
+}
+
+// getActiveFlagColumn returns column of active flag of struct with
+// activeFlag option or empty string
+func getActiveFlagColumn(s StructInfo) string {
+	if s.ActiveFlagField == nil {
+		return ""
+	}
+	return gorm.ToDBName(s.ActiveFlagField.Name)
+}
+
+// getTenantColumn returns column of tenant of struct with tenantColumn
+// option or empty string
+func getTenantColumn(s StructInfo) string {
+	if s.TenantField == nil {
+		return ""
+	}
+	return gorm.ToDBName(s.TenantField.Name)
+}
+
+// getTenant returns tenant of struct with tenantColumn option
+func getTenant(s StructInfo) methods.Tenant {
+	if s.TenantField == nil {
+		return methods.Tenant{}
+	}
+	return methods.Tenant{
+		Column:    getTenantColumn(s),
+		FieldName: s.TenantField.Name,
+		TypeName:  s.TenantField.TypeName,
+	}
+}
+
+// getNewUpdaterFuncName returns name of func constructing updater: it isn't
+// exported for tenant models, their updaters are got only from query sets
+func getNewUpdaterFuncName(s StructInfo) string {
+	name := "New" + getUpdaterTypeName(s.Name)
+	if s.TenantField != nil {
+		return methods.LowercaseFirstRune(name)
+	}
+	return name
+}
+
+// getCreatePreparation returns preparation of struct records before creation
+func getCreatePreparation(s StructInfo) methods.CreatePreparation {
+	p := methods.CreatePreparation{
+		Validate: len(getEnumFields(s.Fields)) != 0,
+	}
+	if s.ActiveFlagField != nil {
+		p.ActiveFlagFieldName = s.ActiveFlagField.Name
+	}
+	p.Tenant = getTenant(s)
+	for _, f := range s.Fields {
+		if f.isAutoCreateTimeField() {
+			p.AutoTimeFieldNames = append(p.AutoTimeFieldNames, f.Name)
+		}
+	}
+	return p
+}
+
+func getMethodsForStruct(s StructInfo) []methods.Method {
+	structTypeName := s.Name
+	qsTypeName := structTypeName + "QuerySet"
+	activeFlag := getActiveFlagColumn(s)
+
+	ret := []methods.Method{
+		methods.NewLimitMethod(qsTypeName),
+		methods.NewOffsetMethod(qsTypeName),
+		methods.NewAllMethod(structTypeName, qsTypeName),
+		methods.NewAllCachedMethod(structTypeName, qsTypeName),
+		methods.NewAllWithHasMoreMethod(qsTypeName, structTypeName),
+		methods.NewOneMethod(structTypeName, qsTypeName),
+		methods.NewFindDuplicatesMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewCountByTwoFieldsMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewCountByFieldWithRollupMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewFacetFieldMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewAllRankedMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewDeferMethod(qsTypeName),
+		methods.NewWithAdvisoryLockMethod(qsTypeName),
+		methods.NewWithTracerMethod(qsTypeName),
+		methods.NewWithContextMethod(qsTypeName),
+		methods.NewWithRetryMethod(qsTypeName),
+		methods.NewInTransactionMethod(qsTypeName),
+		methods.NewLockTableMethod(qsTypeName, structTypeName),
+		methods.NewUnlockTablesMethod(qsTypeName),
+		methods.NewIntoTempTableMethod(qsTypeName, structTypeName),
+		methods.NewUseReplicaMethod(qsTypeName),
+		methods.NewUsePrimaryMethod(qsTypeName),
+		methods.NewResultHashMethod(qsTypeName, structTypeName),
+		methods.NewIsEmptyMethod(qsTypeName, structTypeName),
+		methods.NewCountMethod(qsTypeName, structTypeName),
+		methods.NewCountApproxMethod(qsTypeName, structTypeName),
+		methods.NewExplainMethod(qsTypeName, structTypeName),
+		methods.NewExplainAnalyzeMethod(qsTypeName, structTypeName),
+		methods.NewNotMethod(qsTypeName),
+		methods.NewAndMethod(qsTypeName),
+		methods.NewOrMethod(qsTypeName),
+		methods.NewAsScopeMethod(qsTypeName),
+		methods.NewGetDBMethod(qsTypeName),
+		methods.NewScopesMethod(qsTypeName),
+		methods.NewSelectMethod(qsTypeName, getDBSchemaFieldTypeName(structTypeName)),
+		methods.NewWhereMapMethod(qsTypeName, structTypeName),
+		methods.NewFilterFromStructMethod(qsTypeName, structTypeName),
+		methods.NewScalarSubQueryMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName)),
+	}
+	for _, name := range methods.FilterOperatorNames {
+		ret = append(ret, methods.NewCompareScalarSubQueryMethod(qsTypeName,
+			getDBSchemaFieldTypeName(structTypeName), name))
+		ret = append(ret, methods.NewCompareExprMethod(qsTypeName,
+			getDBSchemaFieldTypeName(structTypeName), name))
+	}
+
+	fieldMethods := getQuerySetFieldMethods(s.Fields, structTypeName, qsTypeName)
+	ret = append(ret, fieldMethods...)
+	hasAssociations := len(s.SliceAssociations) != 0
+	for _, name := range s.SliceAssociations {
+		ret = append(ret, methods.NewPreloadMethod(name, qsTypeName))
+	}
+	for _, f := range s.Fields {
+		if f.IsStruct && !f.IsTime {
+			hasAssociations = true
+		}
+	}
+	if hasAssociations {
+		ret = append(ret, methods.NewJoinScanMethod(qsTypeName, structTypeName))
+	}
+
+	for _, f := range s.Fields {
+		if f.Name == "ID" && f.IsNumeric {
+			ret = append(ret,
+				methods.NewPageCursorMethod(qsTypeName, structTypeName, f.TypeName),
+				methods.NewEachRowMethod(qsTypeName, structTypeName),
+				methods.NewWriteNDJSONMethod(qsTypeName, structTypeName))
+		}
+	}
+
+	for _, f := range s.Fields {
+		if f.Name == "UpdatedAt" && f.IsTime {
+			ret = append(ret, methods.NewLatestPerFieldMethod(qsTypeName, structTypeName,
+				getDBSchemaFieldTypeName(structTypeName), f.Name))
+		}
+	}
+
+	if lat, lng, _ := getPointFields(s.Fields); lat != nil {
+		ret = append(ret, methods.NewWithinBoundingBoxMethod(qsTypeName,
+			gorm.ToDBName(lat.Name), gorm.ToDBName(lng.Name)))
+	}
+	orderedFieldNames, orderedTypeNames := []string{}, []string{}
+	for _, f := range s.Fields {
+		if (f.IsNumeric || f.IsString) && !f.IsPointer {
+			orderedFieldNames = append(orderedFieldNames, f.Name)
+			orderedTypeNames = append(orderedTypeNames, f.TypeName)
+		}
+	}
+	if len(orderedFieldNames) != 0 {
+		ret = append(ret, methods.NewValueInFieldRangeMethod(qsTypeName,
+			getDBSchemaFieldTypeName(structTypeName), orderedFieldNames, orderedTypeNames),
+			methods.NewContinueAfterMethod(qsTypeName, structTypeName, orderedFieldNames),
+			methods.NewKeysetCursorMethod(structTypeName, getDBSchemaFieldTypeName(structTypeName)))
+		if pk := getPrimaryKeyFields(s.Fields); len(pk) == 1 {
+			ret = append(ret,
+				methods.NewNeighborByMethod(qsTypeName, structTypeName,
+					getDBSchemaFieldTypeName(structTypeName), pk[0].Name, orderedFieldNames, true),
+				methods.NewNeighborByMethod(qsTypeName, structTypeName,
+					getDBSchemaFieldTypeName(structTypeName), pk[0].Name, orderedFieldNames, false))
+		}
+	}
+	if start, end, _ := getRangeFields(s.Fields); start != nil {
+		ret = append(ret, methods.NewOverlapsRangeMethod(qsTypeName, start.Name,
+			end.Name, start.TypeName))
+	}
+
+	if rangeFields := getRangeFilterFields(s.Fields); len(rangeFields) != 0 {
+		fieldNames := []string{}
+		for _, f := range rangeFields {
+			fieldNames = append(fieldNames, f.Name)
+		}
+		ret = append(ret, methods.NewApplyRangeFilterMethod(qsTypeName,
+			structTypeName+"RangeFilter", fieldNames))
+	}
+
+	if inputFields := getFilterInputFields(s.Fields); len(inputFields) != 0 {
+		fields := []methods.FilterInputField{}
+		for _, f := range inputFields {
+			fields = append(fields, methods.FilterInputField{
+				Name:      f.Name,
+				IsNumeric: f.IsNumeric,
+				IsString:  f.IsString,
+				IsOrdered: f.IsNumeric ||
+					f.IsString && f.getEnumMembers() == nil && !f.isJSONArrayField(),
+				HasIn: f.IsNumeric && !f.IsTime || f.IsString && !f.isJSONArrayField(),
+			})
+		}
+		ret = append(ret, methods.NewApplyFilterInputMethod(qsTypeName,
+			structTypeName+"FilterInput", fields))
+
+		typeNames := []string{}
+		for _, f := range inputFields {
+			typeNames = append(typeNames, f.TypeName)
+		}
+		ret = append(ret,
+			methods.NewDescribeMethod(qsTypeName),
+			methods.NewFromDescriptionMethod(qsTypeName, fields, typeNames))
+	}
+
+	numericFieldNames := []string{}
+	for _, f := range s.Fields {
+		if f.IsNumeric && !f.IsTime {
+			numericFieldNames = append(numericFieldNames, f.Name)
+		}
+	}
+	if len(numericFieldNames) != 0 {
+		ret = append(ret,
+			methods.NewPercentileMethod(qsTypeName, structTypeName,
+				getDBSchemaFieldTypeName(structTypeName), numericFieldNames),
+			methods.NewHistogramFieldMethod(qsTypeName, structTypeName,
+				getDBSchemaFieldTypeName(structTypeName), numericFieldNames))
+	}
+
+	timeFieldNames := []string{}
+	for _, f := range s.Fields {
+		if f.IsTime {
+			timeFieldNames = append(timeFieldNames, f.Name)
+		}
+	}
+	if len(timeFieldNames) != 0 {
+		ret = append(ret, methods.NewCountByHourMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName), timeFieldNames))
+	}
+
+	apiFields := []methods.APIField{}
+	for _, f := range s.Fields {
+		if name := f.getAPIName(); name != "" {
+			apiFields = append(apiFields, methods.APIField{Name: f.Name, APIName: name})
+		}
+	}
+	if len(apiFields) != 0 {
+		ret = append(ret, methods.NewOrderByAPIFieldMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName), apiFields))
+	}
+
+	keyFieldNames := []string{}
+	for _, f := range s.Fields {
+		if f.IsString || f.IsNumeric && !f.IsTime {
+			keyFieldNames = append(keyFieldNames, f.Name)
+		}
+	}
+	if len(keyFieldNames) != 0 {
+		ret = append(ret, methods.NewAllIndexedByMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName), keyFieldNames))
+	}
+
+	stringFieldNames := []string{}
+	for _, f := range s.Fields {
+		if f.IsString {
+			stringFieldNames = append(stringFieldNames, f.Name)
+		}
+	}
+	if len(stringFieldNames) != 0 {
+		ret = append(ret, methods.NewSearchMethod(qsTypeName,
+			getDBSchemaFieldTypeName(structTypeName), stringFieldNames))
+	}
+
+	if enumFields := getEnumFields(s.Fields); len(enumFields) != 0 {
+		fields := []methods.EnumField{}
+		for _, f := range enumFields {
+			fields = append(fields, methods.EnumField{
+				Name:      f.Name,
+				IsPointer: f.IsPointer,
+			})
+		}
+		ret = append(ret, methods.NewValidateMethod(structTypeName, fields))
+	}
+
+	ret = append(ret,
+		methods.NewApplyFieldMaskMethod(qsTypeName, getColumnFieldNames(s.Fields)),
+		methods.NewAllIntoMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName), getColumnFieldNames(s.Fields)),
+		getVerifySchemaMethod(structTypeName, s.Fields),
+		getSchemaJSONMethod(structTypeName, s.Fields))
+
+	softDelete := isSoftDeleteStruct(s.Fields)
+	if activeFlag != "" {
+		ret = append(ret,
+			methods.NewActiveFlagUnscopedMethod(qsTypeName),
+			methods.NewActiveFlagOnlyDeletedMethod(qsTypeName, activeFlag))
+	} else if softDelete {
+		ret = append(ret,
+			methods.NewUnscopedMethod(qsTypeName),
+			methods.NewOnlyDeletedMethod(qsTypeName))
+	}
+	if activeFlag != "" || softDelete {
+		ret = append(ret,
+			methods.NewCountUnscopedMethod(qsTypeName),
+			methods.NewCountDeletedMethod(qsTypeName))
+	}
+
+	if s.ReadOnly {
+		return ret
+	}
+
+	if activeFlag != "" {
+		ret = append(ret,
+			methods.NewActiveFlagRestoreMethod(qsTypeName, structTypeName, activeFlag),
+			methods.NewActiveFlagDeleteMethod(qsTypeName, structTypeName, activeFlag),
+			methods.NewActiveFlagStructDeleteMethod(structTypeName, activeFlag,
+				s.ActiveFlagField.Name, getTenant(s)))
+	} else {
+		ret = append(ret,
+			methods.NewDeleteMethod(qsTypeName, structTypeName),
+			methods.NewStructDeleteMethod(structTypeName, getTenant(s)))
+		if softDelete {
+			ret = append(ret, methods.NewRestoreMethod(qsTypeName, structTypeName))
+		}
+		if by, reason, _ := getDeleteAuditFields(s.Fields); by != nil {
+			ret = append(ret, methods.NewDeleteWithAuditMethod(qsTypeName, structTypeName,
+				gorm.ToDBName(by.Name), gorm.ToDBName(reason.Name)))
+		}
+	}
+	ret = append(ret, methods.NewDeleteHardMethod(qsTypeName, structTypeName, activeFlag != ""))
+
+	if len(timeFieldNames) != 0 {
+		dbSchemaFieldTypeName := getDBSchemaFieldTypeName(structTypeName)
+		if activeFlag != "" {
+			ret = append(ret, methods.NewActiveFlagDeleteOlderThanMethod(qsTypeName, structTypeName,
+				dbSchemaFieldTypeName, timeFieldNames, activeFlag))
+		} else {
+			ret = append(ret, methods.NewDeleteOlderThanMethod(qsTypeName, structTypeName,
+				dbSchemaFieldTypeName, timeFieldNames))
+		}
+	}
+
+	ret = append(ret, getDiffFromDBMethod(structTypeName, s.Fields))
+	ret = append(ret, getPreloadForMethods(s)...)
+	for _, f := range s.Fields {
+		if f.IsStruct && !f.IsTime {
+			ret = append(ret, methods.NewValidateReferencesMethod(structTypeName))
+			break
+		}
+	}
+
+	ret = append(ret,
+		methods.NewGetUpdaterMethod(qsTypeName, getUpdaterTypeName(structTypeName),
+			getNewUpdaterFuncName(s)),
+		methods.NewCreateMethod(structTypeName, getCreatePreparation(s)),
+		methods.NewOneForUpdateNoWaitMethod(structTypeName, qsTypeName),
+		methods.NewCreateIfNotMatchedMethod(qsTypeName, structTypeName, s.TenantField != nil),
+		methods.NewGetOrCreateMethod(qsTypeName, structTypeName, s.TenantField != nil),
+		methods.NewCreateBatchMethod(structTypeName, getCreatePreparation(s)),
+		methods.NewCreateFromChanMethod(structTypeName, getCreatePreparation(s)),
+		methods.NewSetFieldForAllMethod(qsTypeName, structTypeName,
+			getDBSchemaFieldTypeName(structTypeName), getTenant(s)),
+		methods.NewAllowGlobalUpdateMethod(qsTypeName),
+	)
+	if by, at, _ := getClaimFields(s.Fields); by != nil {
+		pk := getPrimaryKeyFields(s.Fields)[0]
+		ret = append(ret, methods.NewClaimBatchMethod(qsTypeName, structTypeName,
+			pk.Name, pk.TypeName, by.Name, at.Name))
+	}
+	if priority, readyAt, _ := getScheduleFields(s.Fields); priority != nil {
+		ret = append(ret, methods.NewNextReadyMethod(qsTypeName, structTypeName,
+			priority.Name, readyAt.Name))
+	}
+	ret = append(ret, getUpdaterMethods(s)...)
+
+	return ret
+}
+
+const qsCode = `
+  // ===== BEGIN of query set {{ .Name }}
+
+	// {{ .Name }} is an queryset type for {{ .StructName }}
+  type {{ .Name }} struct {
+	  db *gorm.DB
+	  deferred []func({{ .Name }}) {{ .Name }}
+	  {{- if .Info.ActiveFlagField }}
+	  unscoped bool
+	  {{- end }}
+	  {{- if .Info.TenantField }}
+	  tenantID {{ .Info.TenantField.TypeName }}
+	  {{- end }}
+  }
+
+  {{- if .Info.TenantField }}
+
+  // New{{ .Name }}ForTenant constructs new {{ .Name }} for records of tenant
+  // tenantID: all queries, updates and deletes have condition {{ tenantColumn .Info }} = tenantID
+  func New{{ .Name }}ForTenant(db *gorm.DB, tenantID {{ .Info.TenantField.TypeName }}) {{ .Name }} {
+	  return {{ .Name }}{
+		  db: db,
+		  tenantID: tenantID,
+	  }
+  }
+  {{- else }}
+
+  // New{{ .Name }} constructs new {{ .Name }}
+  func New{{ .Name }}(db *gorm.DB) {{ .Name }} {
+	  return {{ .Name }}{
+		  db: db,
+	  }
+  }
+  {{- end }}
+
+	func (qs {{ .Name }}) w(db *gorm.DB) {{ .Name }} {
+	  qs.db = db
+	  return qs
+  }
+
+	// prepare applies deferred transformations of query set
+	func (qs {{ .Name }}) prepare() {{ .Name }} {
+		for len(qs.deferred) != 0 {
+			deferred := qs.deferred
+			qs.deferred = nil
+			for _, fn := range deferred {
+				qs = fn(qs)
+			}
+		}
+		return qs
+	}
+
+	// scopedDB returns db of prepared query set with implicit conditions
+	func (qs {{ .Name }}) scopedDB() *gorm.DB {
+		qs = qs.prepare()
+		{{- if .Info.TenantField }}
+		qs.db = qs.db.Where("{{ tenantColumn .Info }} = ?", qs.tenantID)
+		{{- end }}
+		{{- if .Info.ActiveFlagField }}
+		if !qs.unscoped {
+			return qs.db.Where("{{ activeFlagColumn .Info }} = ?", true)
+		}
+		{{- end }}
+		return qs.db
+	}
+
+	// exec executes terminal operation op by f on prepared query set db
+	// in span of tracer set by WithTracer
+	func (qs {{ .Name }}) exec(op string, f func(db *gorm.DB) error) error {
+		db := qs.scopedDB()
+		{{- if .Info.SQLComments }}
+		db = base.WithSQLComment(db, "{{ .Name }}."+op)
+		{{- end }}
+		db, span := base.StartSpan(db, "{{ .Name }}."+op)
+		defer span.End()
+		err := base.CheckContext(db)
+		if err == nil {
+			err = base.Retry(db, func() error {
+				return f(db)
+			})
+		}
+		if err != nil {
+			span.SetError(err)
+			{{- if .Info.WrapErrors }}
+			return fmt.Errorf("{{ .Name }}.%s: %w", op, err)
+			{{- else }}
+			return err
+			{{- end }}
+		}
+		return nil
+	}
+
+	// {{ .StructName }}Conditions are values of {{ .StructName }} fields
+	// for equality conditions of WhereMap
+	type {{ .StructName }}Conditions map[{{ printf "%s%s" .StructName "DBSchemaField" | lcf }}]interface{}
+
+	// Ranked{{ .StructName }} is a {{ .StructName }} with its rank selected by AllRanked
+	type Ranked{{ .StructName }} struct {
+		{{ .StructName }}
+		Rank int
+	}
+
+	{{- $rangeFields := rangeFilterFields .Info.Fields }}
+	{{- if $rangeFields }}
+	// {{ .StructName }}RangeFilter is a filter by ranges of {{ .StructName }} fields
+	// values: [Min, Max]. Nil bounds aren't applied.
+	type {{ .StructName }}RangeFilter struct {
+		{{- range $rangeFields }}
+			{{ .Name }}Min *{{ .TypeName }}
+			{{ .Name }}Max *{{ .TypeName }}
+		{{- end }}
+	}
+	{{- end }}
+
+	{{- $sn := .StructName }}
+	{{- range enumFields .Info.Fields }}
+	// {{ enumMembersVar $sn .Name }} are members of enum of {{ $sn }}.{{ .Name }}
+	var {{ enumMembersVar $sn .Name }} = []string{
+		{{- range enumMembers . }}{{ printf "%q" . }}, {{ end -}}
+	}
+	{{- end }}
+
+	{{- $inputFields := filterInputFields .Info.Fields }}
+	{{- if $inputFields }}
+	// {{ .StructName }}FilterInput is a GraphQL-style filter by {{ .StructName }} fields:
+	// nil fields and operators aren't applied
+	type {{ .StructName }}FilterInput struct {
+		{{- range $inputFields }}
+			{{ .Name }} *{{ $sn }}{{ .Name }}Filter
+		{{- end }}
+	}
+	{{ range $inputFields }}
+	// {{ $sn }}{{ .Name }}Filter is a set of operators of {{ $sn }}FilterInput.{{ .Name }}
+	type {{ $sn }}{{ .Name }}Filter struct {
+		Eq   *{{ .TypeName }}
+		Ne   *{{ .TypeName }}
+		In   []{{ .TypeName }}
+		Gt   *{{ .TypeName }}
+		Gte  *{{ .TypeName }}
+		Lt   *{{ .TypeName }}
+		Lte  *{{ .TypeName }}
+		Like *string
+	}
+	{{ end }}
+	{{- end }}
+
+  // ===== END of query set {{ .Name }}
+
+	// ===== BEGIN of {{ .StructName }} modifiers
+
+	{{ $ft := printf "%s%s" .StructName "DBSchemaField" | lcf }}
+	type {{ $ft }} string
+
+	// {{ .StructName }}DBSchema stores db field names of {{ .StructName }}
+	var {{ .StructName }}DBSchema = struct {
+		{{ range .Fields }}
+			{{ .Name }} {{ $ft }}
+		{{- end }}
+	}{
+		{{ range .Fields }}
+			{{ .Name }}: {{ $ft }}("{{ .Name | todbname }}"),
+		{{- end }}
+	}
+	{{ if not .Info.ReadOnly }}
+	{{- if .Info.TenantField }}
+	// Update updates {{ .StructName }} fields by primary key and tenant:
+	// tenant column can't be updated
+	func (o *{{ .StructName }}) Update(db *gorm.DB, tenantID {{ .Info.TenantField.TypeName }}, fields ...{{ $ft }}) error {
+	{{- else }}
+	// Update updates {{ .StructName }} fields by primary key
+	func (o *{{ .StructName }}) Update(db *gorm.DB, fields ...{{ $ft }}) error {
+	{{- end }}
+		if err := base.CheckContext(db); err != nil {
+			return err
+		}
+		if err := base.CheckPrimaryKey(db, o); err != nil {
+			return err
+		}
+		{{- if enumFields .Info.Fields }}
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		{{- end }}
+		{{- $autoTimeFields := autoUpdateTimeFields .Fields }}
+		{{- if $autoTimeFields }}
+			now := gorm.NowFunc()
+			{{- $sn := .StructName }}
+			{{- range $autoTimeFields }}
+				o.{{ . }} = now
+				fields = append(fields, {{ $sn }}DBSchema.{{ . }})
+			{{- end }}
+		{{ end }}
+		dbNameToFieldName := map[string]interface{}{
+			{{- range .Fields }}
+				"{{ .Name | todbname }}": o.{{ .Name }},
+			{{- end }}
+		}
+		u := map[string]interface{}{}
+		for _, f := range fields {
+			fs := string(f)
+			{{- if .Info.TenantField }}
+			if fs == "{{ tenantColumn .Info }}" {
+				return fmt.Errorf("can't update tenant column %s of {{ .StructName }}", fs)
+			}
+			{{- end }}
+			u[fs] = dbNameToFieldName[fs]
+		}
+		{{- if .Info.TenantField }}
+		db = db.Where("{{ tenantColumn .Info }} = ?", tenantID)
+		{{- end }}
+		if err := base.Primary(db).Model(o).Updates(u).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return err
+			}
+
+			return fmt.Errorf("can't update {{ .StructName }} %v fields %v: %s",
+				o, fields, err)
+		}
+
+		return nil
+	}
+
+	// {{ .StructName }}Updater is an {{ .StructName }} updates manager
+	type {{ .StructName }}Updater struct {
+		fields map[string]interface{}
+		db *gorm.DB
+	}
+
+	{{- if .Info.TenantField }}
+	// new{{ .StructName }}Updater creates new {{ .StructName }} updater: it's got
+	// only by GetUpdater of query set having tenant condition
+	func new{{ .StructName }}Updater(db *gorm.DB) {{ .StructName }}Updater {
+	{{- else }}
+	// New{{ .StructName }}Updater creates new {{ .StructName }} updater
+	func New{{ .StructName }}Updater(db *gorm.DB) {{ .StructName }}Updater {
+	{{- end }}
+		return {{ .StructName }}Updater{
+			fields: map[string]interface{}{},
+			db: db.Model(&{{ .StructName }}{}),
+		}
+	}
+	{{ end }}
+
+	// ===== END of {{ .StructName }} modifiers
+`
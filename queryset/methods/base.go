@@ -99,6 +99,23 @@ func newOneArgMethod(argName, argTypeName string) oneArgMethod {
 	}
 }
 
+// constArgsMethod
+
+type constArgsMethod struct {
+	args string
+}
+
+// GetArgsDeclaration returns declaration of arguments list for func decl
+func (m constArgsMethod) GetArgsDeclaration() string {
+	return m.args
+}
+
+func newConstArgsMethod(args string) constArgsMethod {
+	return constArgsMethod{
+		args: args,
+	}
+}
+
 // noArgsMethod
 
 type noArgsMethod struct{}
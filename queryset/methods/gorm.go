@@ -7,6 +7,26 @@ func wrapToGormScope(code string) string {
 	return fmt.Sprintf(tmpl, code)
 }
 
+// wrapToTerminal wraps code, returning error, to execution of terminal
+// operation (method) name on prepared query set db
+func wrapToTerminal(name, code string) string {
+	const tmpl = `return qs.exec(%q, func(db *gorm.DB) error {
+		%s
+	})`
+	return fmt.Sprintf(tmpl, name, code)
+}
+
+// wrapToValueTerminal is like wrapToTerminal, but code assigns
+// named results ret and err of method
+func wrapToValueTerminal(name, code string) string {
+	const tmpl = `err = qs.exec(%q, func(db *gorm.DB) error {
+		%s
+		return err
+	})
+	return`
+	return fmt.Sprintf(tmpl, name, code)
+}
+
 // callGormMethod
 type callGormMethod struct {
 	gormMethodName string
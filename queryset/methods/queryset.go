@@ -132,6 +132,9 @@ type BinaryFilterMethod struct {
 	fieldOperationOneArgMethod
 	baseQuerySetMethod
 	retQuerySetMethod
+
+	truncate  string // duration to truncate time arg to
+	described bool   // condition is described for Describe method
 }
 
 // NewBinaryFilterMethod create new binary filter method
@@ -144,22 +147,54 @@ func NewBinaryFilterMethod(name, fieldName, argTypeName, qsTypeName string) Bina
 	}
 }
 
+// NewTruncatedTimeFilterMethod creates binary filter method for time field:
+// arg is truncated to duration truncate (Go expression, e.g. "time.Second")
+// to match precision of column
+func NewTruncatedTimeFilterMethod(name, fieldName, argTypeName, qsTypeName,
+	truncate string) BinaryFilterMethod {
+
+	r := NewBinaryFilterMethod(name, fieldName, argTypeName, qsTypeName)
+	r.truncate = truncate
+	return r
+}
+
+// Described returns copy of m adding condition by base.DescribedWhere:
+// such conditions are described by Describe method of query set
+func (m BinaryFilterMethod) Described() BinaryFilterMethod {
+	m.described = true
+	return m
+}
+
 // GetBody returns method's code
 func (m BinaryFilterMethod) GetBody() string {
+	arg := m.getArgName()
+	if m.truncate != "" {
+		arg = fmt.Sprintf("%s.Truncate(%s)", arg, m.truncate)
+	}
+	if m.described {
+		return wrapToGormScope(fmt.Sprintf(`base.DescribedWhere(qs.db, "%s", "%s", %s, "%s %s", %s)`,
+			m.fieldName, m.name, m.getArgName(), gorm.ToDBName(m.fieldName), m.getWhereCondition(), arg))
+	}
 	return wrapToGormScope(fmt.Sprintf(`qs.db.Where("%s %s", %s)`,
-		gorm.ToDBName(m.fieldName), m.getWhereCondition(), m.getArgName()))
+		gorm.ToDBName(m.fieldName), m.getWhereCondition(), arg))
+}
+
+// filterOperators are SQL operators of binary filters by their names
+var filterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "!=",
+	"lt":   "<",
+	"lte":  "<=",
+	"gt":   ">",
+	"gte":  ">=",
+	"like": "LIKE",
 }
 
+// FilterOperatorNames are names of binary filters in order of generation
+var FilterOperatorNames = []string{"eq", "ne", "lt", "lte", "gt", "gte"}
+
 func (m BinaryFilterMethod) getWhereCondition() string {
-	nameToOp := map[string]string{
-		"eq":  "=",
-		"ne":  "!=",
-		"lt":  "<",
-		"lte": "<=",
-		"gt":  ">",
-		"gte": ">=",
-	}
-	op := nameToOp[m.name]
+	op := filterOperators[m.name]
 	if op == "" {
 		log.Fatalf("no operation for filter %q", m.name)
 	}
@@ -167,6 +202,98 @@ func (m BinaryFilterMethod) getWhereCondition() string {
 	return fmt.Sprintf("%s ?", op)
 }
 
+// InFilterMethod creates {FieldName}In or {FieldName}NotIn method
+type InFilterMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+func newInFilterMethod(name, baseFunc, fieldName, argTypeName, qsTypeName string) InFilterMethod {
+	argName := fieldNameToArgName(fieldName)
+	return InFilterMethod{
+		onFieldMethod:      newOnFieldMethod(name, fieldName),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("%s ...%s", argName, argTypeName)),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.%s(qs.db, "%s", %s)`, baseFunc, gorm.ToDBName(fieldName), argName))),
+	}
+}
+
+// NewInFilterMethod creates {FieldName}In method: long lists of values are
+// split into chunks by base.WhereIn
+func NewInFilterMethod(fieldName, argTypeName, qsTypeName string) InFilterMethod {
+	r := newInFilterMethod("In", "WhereIn", fieldName, argTypeName, qsTypeName)
+	r.setDoc(fmt.Sprintf(`// %s filters by %s IN (values): more than base.MaxInListSize
+	// values are split into IN lists ORed together. No values match no records.`,
+		r.GetMethodName(), gorm.ToDBName(fieldName)))
+	return r
+}
+
+// NewNotInFilterMethod creates {FieldName}NotIn method: long lists of values
+// are split into chunks by base.WhereNotIn
+func NewNotInFilterMethod(fieldName, argTypeName, qsTypeName string) InFilterMethod {
+	r := newInFilterMethod("NotIn", "WhereNotIn", fieldName, argTypeName, qsTypeName)
+	r.setDoc(fmt.Sprintf(`// %s filters by %s NOT IN (values): more than base.MaxInListSize
+	// values are split into NOT IN lists joined by AND. No values match all records.`,
+		r.GetMethodName(), gorm.ToDBName(fieldName)))
+	return r
+}
+
+// LikeFilterMethod creates {FieldName}Contains, {FieldName}StartsWith
+// or {FieldName}EndsWith method
+type LikeFilterMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewContainsFilterMethod creates {FieldName}Contains method
+func NewContainsFilterMethod(fieldName, argTypeName, qsTypeName string) LikeFilterMethod {
+	return newLikeFilterMethod("Contains", "%", "%", fieldName, argTypeName, qsTypeName)
+}
+
+// NewStartsWithFilterMethod creates {FieldName}StartsWith method
+func NewStartsWithFilterMethod(fieldName, argTypeName, qsTypeName string) LikeFilterMethod {
+	return newLikeFilterMethod("StartsWith", "", "%", fieldName, argTypeName, qsTypeName)
+}
+
+// NewEndsWithFilterMethod creates {FieldName}EndsWith method
+func NewEndsWithFilterMethod(fieldName, argTypeName, qsTypeName string) LikeFilterMethod {
+	return newLikeFilterMethod("EndsWith", "%", "", fieldName, argTypeName, qsTypeName)
+}
+
+// newLikeFilterMethod creates method filtering by LIKE pattern of escaped arg
+// between prefix and suffix: wildcards of arg are matched literally
+func newLikeFilterMethod(name, prefix, suffix, fieldName, argTypeName, qsTypeName string) LikeFilterMethod {
+	argName := fieldNameToArgName(fieldName)
+	pattern := fmt.Sprintf("base.EscapeLike(string(%s))", argName)
+	if prefix != "" {
+		pattern = fmt.Sprintf("%q+%s", prefix, pattern)
+	}
+	if suffix != "" {
+		pattern = fmt.Sprintf("%s+%q", pattern, suffix)
+	}
+
+	r := LikeFilterMethod{
+		onFieldMethod:      newOnFieldMethod(name, fieldName),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("%s %s", argName, argTypeName)),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`qs.db.Where("%s LIKE ? ESCAPE ?", %s, base.LikeEscapeChar)`, gorm.ToDBName(fieldName), pattern))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s filters by %s LIKE '%s%s%s': wildcards %% and _
+	// of %s are escaped by base.LikeEscapeChar and matched literally`, r.GetMethodName(),
+		gorm.ToDBName(fieldName), prefix, argName, suffix, argName))
+	return r
+}
+
 // UnaryFilterMethod represents unary filter
 type UnaryFilterMethod struct {
 	onFieldMethod
@@ -195,6 +322,57 @@ func (m UnaryFilterMethod) GetBody() string {
 
 // unaryFilerMethod
 
+// DateInLocationFilterMethod filters time field by calendar day in location
+type DateInLocationFilterMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewDateInLocationFilterMethod creates {FieldName}OnDateInLocation method
+func NewDateInLocationFilterMethod(fieldName, qsTypeName string) DateInLocationFilterMethod {
+	body := fmt.Sprintf(`y, m, d := date.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	%s`, wrapToGormScope(fmt.Sprintf(`qs.db.Where("%s >= ? AND %s < ?", from.UTC(), to.UTC())`,
+		gorm.ToDBName(fieldName), gorm.ToDBName(fieldName))))
+
+	r := DateInLocationFilterMethod{
+		onFieldMethod:      newOnFieldMethod("OnDateInLocation", fieldName),
+		constArgsMethod:    newConstArgsMethod("date time.Time, loc *time.Location"),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", body),
+	}
+	r.setDoc(fmt.Sprintf(`// %s filters records by calendar day of date in location loc.
+	// Boundaries of this day are converted to UTC: [00:00, 24:00) of the day in loc`,
+		r.GetMethodName()))
+	return r
+}
+
+// NewCurrentPeriodFilterMethod creates {FieldName}{suffix} method, e.g.
+// CreatedAtToday, filtering time field by calendar period (base.PeriodDay,
+// etc) containing current time
+func NewCurrentPeriodFilterMethod(fieldName, suffix, period, qsTypeName string) DateInLocationFilterMethod {
+	body := fmt.Sprintf(`from, to := base.PeriodBounds(gorm.NowFunc(), loc, base.%s)
+	%s`, period, wrapToGormScope(fmt.Sprintf(`qs.db.Where("%s >= ? AND %s < ?", from.UTC(), to.UTC())`,
+		gorm.ToDBName(fieldName), gorm.ToDBName(fieldName))))
+
+	r := DateInLocationFilterMethod{
+		onFieldMethod:      newOnFieldMethod(suffix, fieldName),
+		constArgsMethod:    newConstArgsMethod("loc *time.Location"),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", body),
+	}
+	r.setDoc(fmt.Sprintf(`// %s filters records by current calendar period in location loc
+	// (weeks start on Monday): its boundaries are computed at call time and
+	// converted to UTC, range is half-open [from, to)`, r.GetMethodName()))
+	return r
+}
+
 // SelectMethod is a select field (all, one, etc)
 type SelectMethod struct {
 	namedMethod
@@ -208,10 +386,15 @@ func newSelectMethod(name, gormName, argTypeName, qsTypeName string) SelectMetho
 		namedMethod:        newNamedMethod(name),
 		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
 		oneArgMethod:       newOneArgMethod("ret", argTypeName),
-		gormErroredMethod:  newGormErroredMethod(gormName, "ret", "qs.db"),
+		gormErroredMethod:  newGormErroredMethod(gormName, "ret", "db"),
 	}
 }
 
+// GetBody returns method's code
+func (m SelectMethod) GetBody() string {
+	return wrapToTerminal(m.name, m.gormErroredMethod.GetBody())
+}
+
 // GetUpdaterMethod creates GetUpdater method
 type GetUpdaterMethod struct {
 	baseQuerySetMethod
@@ -221,13 +404,14 @@ type GetUpdaterMethod struct {
 	constBodyMethod
 }
 
-// NewGetUpdaterMethod creates GetUpdaterMethod
-func NewGetUpdaterMethod(qsTypeName, updaterTypeMethod string) GetUpdaterMethod {
+// NewGetUpdaterMethod creates GetUpdaterMethod. Updater is constructed
+// by func newUpdaterFuncName
+func NewGetUpdaterMethod(qsTypeName, updaterTypeMethod, newUpdaterFuncName string) GetUpdaterMethod {
 	return GetUpdaterMethod{
 		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
 		namedMethod:        newNamedMethod("GetUpdater"),
 		constRetMethod:     newConstRetMethod(updaterTypeMethod),
-		constBodyMethod:    newConstBodyMethod("return New%s(qs.db)", updaterTypeMethod),
+		constBodyMethod:    newConstBodyMethod("return %s(qs.scopedDB())", newUpdaterFuncName),
 	}
 }
 
@@ -242,7 +426,8 @@ type DeleteMethod struct {
 
 // NewDeleteMethod creates Delete method
 func NewDeleteMethod(qsTypeName, structTypeName string) DeleteMethod {
-	cbm := newConstBodyMethod("return qs.db.Delete(%s{}).Error", structTypeName)
+	cbm := newConstBodyMethod("%s",
+		wrapToTerminal("Delete", fmt.Sprintf("return db.Delete(%s{}).Error", structTypeName)))
 	return DeleteMethod{
 		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
 		namedMethod:        newNamedMethod("Delete"),
@@ -250,6 +435,88 @@ func NewDeleteMethod(qsTypeName, structTypeName string) DeleteMethod {
 	}
 }
 
+// DeleteHardMethod creates DeleteHard method
+type DeleteHardMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewDeleteHardMethod creates DeleteHard method: records of struct with
+// active flag are selected regardless of it
+func NewDeleteHardMethod(qsTypeName, structTypeName string, hasActiveFlag bool) DeleteHardMethod {
+	body := wrapToValueTerminal("DeleteHard", fmt.Sprintf(
+		`res := db.Unscoped().Delete(&%s{})
+		ret, err = res.RowsAffected, res.Error`, structTypeName))
+	if hasActiveFlag {
+		body = "qs.unscoped = true\n" + body
+	}
+
+	r := DeleteHardMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("DeleteHard"),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod:    newConstBodyMethod("%s", body),
+	}
+	r.setDoc(`// DeleteHard physically deletes records matching query set by DELETE
+	// even for soft delete models, soft deleted records are deleted too.
+	// It returns count of deleted records.`)
+	return r
+}
+
+// FindDuplicatesMethod creates FindDuplicates method
+type FindDuplicatesMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewFindDuplicatesMethod creates FindDuplicates method
+func NewFindDuplicatesMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) FindDuplicatesMethod {
+	r := FindDuplicatesMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("FindDuplicates"),
+		oneArgMethod:       newOneArgMethod("field", dbSchemaFieldTypeName),
+		constRetMethod:     newConstRetMethod("(ret []base.DuplicateGroup, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("FindDuplicates", fmt.Sprintf(
+			"ret, err = base.FindDuplicates(db.Model(&%s{}), string(field))", structTypeName))),
+	}
+	r.setDoc(`// FindDuplicates returns values of field met in more than one record
+	// and counts of these records`)
+	return r
+}
+
+// DeferMethod creates Defer method
+type DeferMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewDeferMethod creates Defer method
+func NewDeferMethod(qsTypeName string) DeferMethod {
+	r := DeferMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Defer"),
+		oneArgMethod: newOneArgMethod("fn",
+			fmt.Sprintf("func(qs %s) %s", qsTypeName, qsTypeName)),
+		retQuerySetMethod: newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`n := len(qs.deferred)
+		qs.deferred = append(qs.deferred[:n:n], fn)
+		return qs`),
+	}
+	r.setDoc(`// Defer registers transformation fn of query set. It's applied
+	// just before execution of terminal method (All, One, Delete, etc).
+	// Deferred transformations are applied in order of registration.`)
+	return r
+}
+
 // Concrete methods
 
 // NewPreloadMethod creates new Preload method
@@ -274,11 +541,59 @@ func NewOrderDescByMethod(fieldName, qsTypeName string) FieldOperationNoArgsMeth
 	return r
 }
 
+// MinMaxMethod creates MinMax{Field} method
+type MinMaxMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewMinMaxMethod creates MinMax{Field} method for ordered field of type fieldTypeName
+func NewMinMaxMethod(fieldName, fieldTypeName, qsTypeName, structTypeName string) MinMaxMethod {
+	name := "MinMax" + fieldName
+	r := MinMaxMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(name),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(min, max %s, err error)", fieldTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal(name, fmt.Sprintf(
+			`err = base.MinMax(db.Model(&%s{}), "%s", &min, &max)`,
+			structTypeName, gorm.ToDBName(fieldName)))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s returns minimal and maximal values of field %s of matching
+	// records by one query: zero values are returned if there are no records`, name, fieldName))
+	return r
+}
+
+// NewAggregateMethod creates {Agg}{Field} method (e.g. SumAge) for numeric
+// field: agg is one of Sum, Avg, Max and Min. Aggregate is returned
+// as value of type retTypeName.
+func NewAggregateMethod(agg, fieldName, retTypeName, qsTypeName, structTypeName string) MinMaxMethod {
+	name := agg + fieldName
+	r := MinMaxMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(name),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(ret %s, err error)", retTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal(name, fmt.Sprintf(
+			`err = base.SelectAggregate(db.Model(&%s{}), base.Aggregate%s, "%s", &ret)`,
+			structTypeName, agg, gorm.ToDBName(fieldName)))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s returns %s of field %s of matching records:
+	// zero is returned if there are no records`, name, strings.ToUpper(agg), fieldName))
+	return r
+}
+
 // NewLimitMethod creates Limit method
 func NewLimitMethod(qsTypeName string) StructOperationOneArgMethod {
 	return newStructOperationOneArgMethod("Limit", "int", qsTypeName)
 }
 
+// NewOffsetMethod creates Offset method
+func NewOffsetMethod(qsTypeName string) StructOperationOneArgMethod {
+	return newStructOperationOneArgMethod("Offset", "int", qsTypeName)
+}
+
 // NewAllMethod creates All method
 func NewAllMethod(structName, qsTypeName string) SelectMethod {
 	return newSelectMethod("All", "Find", fmt.Sprintf("*[]%s", structName), qsTypeName)
@@ -293,7 +608,2328 @@ func NewOneMethod(structName, qsTypeName string) SelectMethod {
 	return r
 }
 
+// EqNullableMethod creates {Field}EqNullable method
+type EqNullableMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// nullTypeValueFields are value fields of database/sql null types by types
+// of values
+var nullTypeValueFields = map[string]string{
+	"string":    "String",
+	"int64":     "Int64",
+	"int32":     "Int32",
+	"int16":     "Int16",
+	"byte":      "Byte",
+	"uint8":     "Byte",
+	"float64":   "Float64",
+	"bool":      "Bool",
+	"time.Time": "Time",
+}
+
+// GetNullTypeName returns name of database/sql null type (e.g. sql.NullString)
+// for values of type typeName or empty string if there is no such type
+func GetNullTypeName(typeName string) string {
+	if f, ok := nullTypeValueFields[typeName]; ok {
+		return "sql.Null" + f
+	}
+	return ""
+}
+
+// NewEqNullableMethod creates {Field}EqNullable method for nullable field
+// with values of type typeName which has database/sql null type
+func NewEqNullableMethod(fieldName, typeName, qsTypeName string) EqNullableMethod {
+	dbName := gorm.ToDBName(fieldName)
+	r := EqNullableMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(fieldName + "EqNullable"),
+		oneArgMethod:       newOneArgMethod("v", GetNullTypeName(typeName)),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`if !v.Valid {
+			return qs.w(qs.db.Where("%s IS NULL"))
+		}
+		return qs.w(qs.db.Where("%s = ?", v.%s))`, dbName, dbName, nullTypeValueFields[typeName]),
+	}
+	r.setDoc(fmt.Sprintf(`// %sEqNullable selects records with %s = v if v is valid
+	// or with %s IS NULL otherwise`, fieldName, dbName, dbName))
+	return r
+}
+
 // NewIsNullMethod create IsNull method
 func NewIsNullMethod(fieldName, qsTypeName string) UnaryFilterMethod {
 	return newUnaryFilterMethod("IsNull", fieldName, "IS NULL", qsTypeName)
 }
+
+// ResultHashMethod creates ResultHash method
+type ResultHashMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewResultHashMethod creates ResultHash method
+func NewResultHashMethod(qsTypeName, structTypeName string) ResultHashMethod {
+	r := ResultHashMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ResultHash"),
+		constRetMethod:     newConstRetMethod("(ret string, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("ResultHash", fmt.Sprintf(
+			"ret, err = base.ResultHash(db.Model(&%s{}))", structTypeName))),
+	}
+	r.setDoc(`// ResultHash returns stable hash of matching records values:
+	// it changes only if any of these records is changed, added or removed`)
+	return r
+}
+
+// NotMethod creates Not method
+type NotMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewNotMethod creates Not method
+func NewNotMethod(qsTypeName string) NotMethod {
+	r := NotMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Not"),
+		oneArgMethod: newOneArgMethod("fn",
+			fmt.Sprintf("func(qs %s) %s", qsTypeName, qsTypeName)),
+		retQuerySetMethod: newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(
+			"group := fn(%s{db: qs.db.New()}).prepare()\nreturn qs.w(base.Not(qs.db, group.db))",
+			qsTypeName),
+	}
+	r.setDoc(`// Not adds negation of conditions added by fn: NOT (...).
+	// fn must add only conditions to passed empty query set`)
+	return r
+}
+
+// AndMethod creates And method: it has the same argument as Not method
+type AndMethod struct {
+	NotMethod
+}
+
+// NewAndMethod creates And method
+func NewAndMethod(qsTypeName string) AndMethod {
+	r := AndMethod{NotMethod: NewNotMethod(qsTypeName)}
+	r.namedMethod = newNamedMethod("And")
+	r.constBodyMethod = newConstBodyMethod(
+		"group := fn(%s{db: qs.db.New()}).prepare()\nreturn qs.w(base.And(qs.db, group.db))",
+		qsTypeName)
+	r.setDoc(`// And adds conditions added by fn as one group: (...), e.g.
+	// to nest groups of Or. fn must add only conditions to passed empty query set`)
+	return r
+}
+
+// OrMethod creates Or method
+type OrMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewOrMethod creates Or method
+func NewOrMethod(qsTypeName string) OrMethod {
+	r := OrMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Or"),
+		constArgsMethod: newConstArgsMethod(
+			fmt.Sprintf("fns ...func(qs %s) %s", qsTypeName, qsTypeName)),
+		retQuerySetMethod: newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`groups := make([]*gorm.DB, 0, len(fns))
+			for _, fn := range fns {
+				groups = append(groups, fn(%s{db: qs.db.New()}).prepare().db)
+			}
+			return qs.w(base.Or(qs.db, groups...))`, qsTypeName),
+	}
+	r.setDoc(`// Or adds conditions added by fns ORed together: (...) OR (...).
+	// Conditions added by one fn are ANDed. fns must add only conditions
+	// to passed empty query sets`)
+	return r
+}
+
+// OneForUpdateNoWaitMethod creates OneForUpdateNoWait method
+type OneForUpdateNoWaitMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewOneForUpdateNoWaitMethod creates OneForUpdateNoWait method
+func NewOneForUpdateNoWaitMethod(structName, qsTypeName string) OneForUpdateNoWaitMethod {
+	r := OneForUpdateNoWaitMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("OneForUpdateNoWait"),
+		oneArgMethod:       newOneArgMethod("ret", "*"+structName),
+		constBodyMethod: newConstBodyMethod("%s",
+			wrapToTerminal("OneForUpdateNoWait", "return base.OneForUpdateNoWait(db, ret)")),
+	}
+	r.setDoc(`// OneForUpdateNoWait is like One, but locks fetched row by FOR UPDATE NOWAIT.
+	// It returns base.ErrRowLocked if row is already locked by another transaction`)
+	return r
+}
+
+// CountByTwoFieldsMethod creates CountByTwoFields method
+type CountByTwoFieldsMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCountByTwoFieldsMethod creates CountByTwoFields method
+func NewCountByTwoFieldsMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) CountByTwoFieldsMethod {
+	r := CountByTwoFieldsMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountByTwoFields"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("a, b %s", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret []base.TwoFieldCount, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("CountByTwoFields", fmt.Sprintf(
+			"ret, err = base.CountByTwoFields(db.Model(&%s{}), string(a), string(b))", structTypeName))),
+	}
+	r.setDoc(`// CountByTwoFields returns counts of records grouped by values of fields a and b`)
+	return r
+}
+
+// FacetFieldMethod creates FacetField method
+type FacetFieldMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewFacetFieldMethod creates FacetField method
+func NewFacetFieldMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) FacetFieldMethod {
+	r := FacetFieldMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("FacetField"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret []base.Facet, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("FacetField", fmt.Sprintf(
+			"ret, err = base.FacetField(db.Model(&%s{}), string(field))", structTypeName))),
+	}
+	r.setDoc(`// FacetField returns distinct values of field of matching records with
+	// their counts, e.g. for faceted search sidebars`)
+	return r
+}
+
+// AllRankedMethod creates AllRanked method
+type AllRankedMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewAllRankedMethod creates AllRanked method
+func NewAllRankedMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) AllRankedMethod {
+	r := AllRankedMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllRanked"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("orderField %s", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(ret []Ranked%s, err error)", structTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("AllRanked", fmt.Sprintf(
+			"err = base.AllRanked(db.Model(&%s{}), string(orderField), &ret)", structTypeName))),
+	}
+	r.setDoc(`// AllRanked returns matching records with their ranks, e.g. for
+	// leaderboards: row numbers by descending values of orderField are selected
+	// by ROW_NUMBER() OVER (ORDER BY orderField DESC), records are ordered
+	// by them. It's supported by dialects with window functions.`)
+	return r
+}
+
+// CountByFieldWithRollupMethod creates CountByFieldWithRollup method
+type CountByFieldWithRollupMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCountByFieldWithRollupMethod creates CountByFieldWithRollup method
+func NewCountByFieldWithRollupMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) CountByFieldWithRollupMethod {
+	r := CountByFieldWithRollupMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountByFieldWithRollup"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret []base.RollupEntry, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("CountByFieldWithRollup", fmt.Sprintf(
+			"ret, err = base.CountByFieldWithRollup(db.Model(&%s{}), string(field))", structTypeName))),
+	}
+	r.setDoc(`// CountByFieldWithRollup returns counts of records grouped by values
+	// of field and the last summary row with grand total marked by Total:
+	// WITH ROLLUP in MySQL, GROUPING SETS in PostgreSQL`)
+	return r
+}
+
+// OnlyDeletedMethod creates OnlyDeleted method
+type OnlyDeletedMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewOnlyDeletedMethod creates OnlyDeleted method
+func NewOnlyDeletedMethod(qsTypeName string) OnlyDeletedMethod {
+	r := OnlyDeletedMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("OnlyDeleted"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s",
+			wrapToGormScope(`qs.db.Unscoped().Where("deleted_at IS NOT NULL")`)),
+	}
+	r.setDoc(`// OnlyDeleted selects only soft deleted records`)
+	return r
+}
+
+// RestoreMethod creates Restore method
+type RestoreMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewRestoreMethod creates Restore method
+func NewRestoreMethod(qsTypeName, structTypeName string) RestoreMethod {
+	r := RestoreMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Restore"),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("Restore", fmt.Sprintf(
+			`res := db.Unscoped().Model(&%s{}).
+				Where("deleted_at IS NOT NULL").
+				UpdateColumn("deleted_at", gorm.Expr("NULL"))
+			ret, err = res.RowsAffected, res.Error`, structTypeName))),
+	}
+	r.setDoc(`// Restore restores soft deleted records matching query set
+	// and returns count of restored records`)
+	return r
+}
+
+// DeleteWithAuditMethod creates DeleteWithAudit method
+type DeleteWithAuditMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewDeleteWithAuditMethod creates DeleteWithAudit method for soft delete struct
+// with audit columns deletedByColumn and reasonColumn
+func NewDeleteWithAuditMethod(qsTypeName, structTypeName,
+	deletedByColumn, reasonColumn string) DeleteWithAuditMethod {
+
+	r := DeleteWithAuditMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("DeleteWithAudit"),
+		constArgsMethod:    newConstArgsMethod("actorID, reason string"),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("DeleteWithAudit", fmt.Sprintf(
+			`ret, err = base.UpdateColumnsInOrder(db, &%s{},
+				[]string{"deleted_at", %q, %q}, gorm.NowFunc(), actorID, reason)`,
+			structTypeName, deletedByColumn, reasonColumn))),
+	}
+	r.setDoc(`// DeleteWithAudit soft deletes records matching query set by one UPDATE
+	// setting deleted_at together with actor and reason of deletion and
+	// returns count of deleted records`)
+	return r
+}
+
+// OrderByCollateMethod creates OrderAscBy{Field}Collate and
+// OrderDescBy{Field}Collate methods
+type OrderByCollateMethod struct {
+	namedMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+func newOrderByCollateMethod(name, dir, fieldName, qsTypeName string) OrderByCollateMethod {
+	r := OrderByCollateMethod{
+		namedMethod:        newNamedMethod(name + fieldName + "Collate"),
+		constArgsMethod:    newConstArgsMethod("collation string"),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.OrderByCollate(qs.db, "%s", collation, "%s")`, gorm.ToDBName(fieldName), dir))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s orders by %s compared using collation,
+	// e.g. "utf8mb4_unicode_ci" in MySQL or "en_US" in PostgreSQL`, r.GetMethodName(), fieldName))
+	return r
+}
+
+// NewOrderAscByCollateMethod creates OrderAscBy{Field}Collate method
+func NewOrderAscByCollateMethod(fieldName, qsTypeName string) OrderByCollateMethod {
+	return newOrderByCollateMethod("OrderAscBy", "ASC", fieldName, qsTypeName)
+}
+
+// NewOrderDescByCollateMethod creates OrderDescBy{Field}Collate method
+func NewOrderDescByCollateMethod(fieldName, qsTypeName string) OrderByCollateMethod {
+	return newOrderByCollateMethod("OrderDescBy", "DESC", fieldName, qsTypeName)
+}
+
+// ApplyRangeFilterMethod creates ApplyRangeFilter method
+type ApplyRangeFilterMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewApplyRangeFilterMethod creates ApplyRangeFilter method. Range filter
+// has {Field}Min and {Field}Max pointers for every field from fieldNames
+func NewApplyRangeFilterMethod(qsTypeName, rangeFilterTypeName string,
+	fieldNames []string) ApplyRangeFilterMethod {
+
+	body := []string{}
+	for _, f := range fieldNames {
+		body = append(body, fmt.Sprintf(`if f.%sMin != nil {
+			qs = qs.%sGte(*f.%sMin)
+		}
+		if f.%sMax != nil {
+			qs = qs.%sLte(*f.%sMax)
+		}`, f, f, f, f, f, f))
+	}
+	body = append(body, "return qs")
+
+	r := ApplyRangeFilterMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ApplyRangeFilter"),
+		oneArgMethod:       newOneArgMethod("f", rangeFilterTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", strings.Join(body, "\n")),
+	}
+	r.setDoc(`// ApplyRangeFilter adds conditions min <= field <= max
+	// for every not nil bound of range filter f`)
+	return r
+}
+
+// FlagFilterMethod filters bitmask field by flag
+type FlagFilterMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+func newFlagFilterMethod(name, fieldName, argTypeName, qsTypeName, cond string) FlagFilterMethod {
+	return FlagFilterMethod{
+		onFieldMethod:      newOnFieldMethod(name, fieldName),
+		constArgsMethod:    newConstArgsMethod("flag " + argTypeName),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(cond,
+			gorm.ToDBName(fieldName)))),
+	}
+}
+
+// NewHasFlagFilterMethod creates {FieldName}HasFlag method: all bits of flag are set
+func NewHasFlagFilterMethod(fieldName, argTypeName, qsTypeName string) FlagFilterMethod {
+	return newFlagFilterMethod("HasFlag", fieldName, argTypeName, qsTypeName,
+		`qs.db.Where("%s & ? = ?", flag, flag)`)
+}
+
+// NewLacksFlagFilterMethod creates {FieldName}LacksFlag method: no bits of flag are set
+func NewLacksFlagFilterMethod(fieldName, argTypeName, qsTypeName string) FlagFilterMethod {
+	return newFlagFilterMethod("LacksFlag", fieldName, argTypeName, qsTypeName,
+		`qs.db.Where("%s & ? = 0", flag)`)
+}
+
+// PageCursorMethod creates PageCursor method
+type PageCursorMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewPageCursorMethod creates PageCursor method for struct with numeric ID
+// primary key of type pkTypeName
+func NewPageCursorMethod(qsTypeName, structTypeName, pkTypeName string) PageCursorMethod {
+	r := PageCursorMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("PageCursor"),
+		constArgsMethod:    newConstArgsMethod("after string, size int"),
+		constRetMethod: newConstRetMethod(fmt.Sprintf("(ret []%s, nextCursor string, err error)",
+			structTypeName)),
+		constBodyMethod: newConstBodyMethod(`if size <= 0 {
+			return nil, "", fmt.Errorf("invalid page size %%d: it must be positive", size)
+		}
+		if err = base.CheckNoLimitOffset(qs.db); err != nil {
+			return nil, "", err
+		}
+
+		if after != "" {
+			var afterID %s
+			if err = base.DecodeCursor(after, &afterID); err != nil {
+				return nil, "", err
+			}
+			qs = qs.IDGt(afterID)
+		}
+
+		// order of query set is replaced: pages are continued by ID
+		qs = qs.w(qs.db.Order("id ASC", true))
+		if err = qs.Limit(size).All(&ret); err != nil {
+			return nil, "", err
+		}
+
+		if len(ret) != 0 && len(ret) == size {
+			nextCursor = base.EncodeCursor(ret[len(ret)-1].ID)
+		}
+		return ret, nextCursor, nil`, pkTypeName),
+	}
+	r.setDoc(`// PageCursor returns page of records ordered by ID after opaque cursor
+	// (empty for first page) and cursor of next page (empty for last page).
+	// Order of query set is replaced by ID order. Not positive size and
+	// Limit or Offset of query set are errors.`)
+	return r
+}
+
+// NewAllWithHasMoreMethod creates AllWithHasMore method
+func NewAllWithHasMoreMethod(qsTypeName, structTypeName string) PageCursorMethod {
+	r := PageCursorMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllWithHasMore"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("size int, ret *[]%s", structTypeName)),
+		constRetMethod:     newConstRetMethod("(hasMore bool, err error)"),
+		constBodyMethod: newConstBodyMethod(`if size < 0 {
+			return false, fmt.Errorf("invalid page size %%d", size)
+		}
+
+		if err = qs.Limit(size + 1).All(ret); err != nil {
+			return false, err
+		}
+
+		if len(*ret) > size {
+			*ret = (*ret)[:size]
+			return true, nil
+		}
+		return false, nil`),
+	}
+	r.setDoc(`// AllWithHasMore selects up to size matching records into ret and reports
+	// whether there are more of them: size + 1 records are selected for it
+	// instead of counting, e.g. for infinite scroll`)
+	return r
+}
+
+// EachRowMethod creates EachRow method
+type EachRowMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// WriteNDJSONMethod creates WriteNDJSON method
+type WriteNDJSONMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewWriteNDJSONMethod creates WriteNDJSON method for struct with EachRow method
+func NewWriteNDJSONMethod(qsTypeName, structTypeName string) WriteNDJSONMethod {
+	r := WriteNDJSONMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WriteNDJSON"),
+		constArgsMethod:    newConstArgsMethod("w io.Writer"),
+		constBodyMethod: newConstBodyMethod(`enc := json.NewEncoder(w)
+		return qs.EachRow(func(o %s) error {
+			return enc.Encode(o)
+		})`, structTypeName),
+	}
+	r.setDoc(`// WriteNDJSON writes every matching record to w as JSON object on its own
+	// line (newline-delimited JSON). Records are loaded by chunks by EachRow.`)
+	return r
+}
+
+// InTransactionMethod creates InTransaction method
+type InTransactionMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewInTransactionMethod creates InTransaction method
+func NewInTransactionMethod(qsTypeName string) InTransactionMethod {
+	r := InTransactionMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("InTransaction"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("fn func(tx *gorm.DB, qs %s) error", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`return base.InTransaction(qs.db, func(tx *gorm.DB) error {
+			return fn(tx.New(), qs.w(tx))
+		})`),
+	}
+	r.setDoc(`// InTransaction calls fn in transaction: it's committed if fn returns nil
+	// and rolled back otherwise. Query set qs passed to fn has the same conditions
+	// and is bound to transaction, tx is the same transaction without conditions
+	// for model methods, e.g. o.Create(tx). If query set is made on transaction
+	// it's used as is.`)
+	return r
+}
+
+// LockTableMethod creates LockTable method
+type LockTableMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewLockTableMethod creates LockTable method
+func NewLockTableMethod(qsTypeName, structTypeName string) LockTableMethod {
+	r := LockTableMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("LockTable"),
+		constArgsMethod:    newConstArgsMethod("mode string"),
+		constBodyMethod:    newConstBodyMethod("return base.LockTable(qs.db, &%s{}, mode)", structTypeName),
+	}
+	r.setDoc(`// LockTable explicitly locks table for bulk operations: LOCK TABLES t WRITE
+	// (or READ) in MySQL, LOCK TABLE t IN mode MODE in PostgreSQL. Query set
+	// must be made on transaction: lock is held by its connection.
+	// Other dialects are errors. WARNING: MySQL commits transaction implicitly
+	// by LOCK TABLES and UNLOCK TABLES, statements between them aren't rolled
+	// back with transaction (see base.LockTable).`)
+	return r
+}
+
+// UnlockTablesMethod creates UnlockTables method
+type UnlockTablesMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewUnlockTablesMethod creates UnlockTables method
+func NewUnlockTablesMethod(qsTypeName string) UnlockTablesMethod {
+	r := UnlockTablesMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("UnlockTables"),
+		constBodyMethod:    newConstBodyMethod("return base.UnlockTables(qs.db)"),
+	}
+	r.setDoc(`// UnlockTables releases MySQL table locks of transaction of query set
+	// acquired by LockTable. PostgreSQL locks are released at the end
+	// of transaction: it's an error.`)
+	return r
+}
+
+// IntoTempTableMethod creates IntoTempTable method
+type IntoTempTableMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewIntoTempTableMethod creates IntoTempTable method
+func NewIntoTempTableMethod(qsTypeName, structTypeName string) IntoTempTableMethod {
+	r := IntoTempTableMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("IntoTempTable"),
+		constArgsMethod:    newConstArgsMethod("name string"),
+		constBodyMethod: newConstBodyMethod(`return qs.exec("IntoTempTable", func(db *gorm.DB) error {
+			return base.IntoTempTable(db.Model(&%s{}), name)
+		})`, structTypeName),
+	}
+	r.setDoc(`// IntoTempTable creates temporary table name from matching records:
+	// CREATE TEMPORARY TABLE name AS SELECT ... Temporary table is visible
+	// only to connection, so query set must be made on transaction and
+	// queries joining the table must be made on it.`)
+	return r
+}
+
+// JoinScanMethod creates JoinScan method
+type JoinScanMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewJoinScanMethod creates JoinScan method
+func NewJoinScanMethod(qsTypeName, structTypeName string) JoinScanMethod {
+	r := JoinScanMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("JoinScan"),
+		constArgsMethod:    newConstArgsMethod("assoc string, dest interface{}, fn func() error"),
+		constBodyMethod: newConstBodyMethod(`return qs.exec("JoinScan", func(db *gorm.DB) error {
+			return base.JoinScan(db.Model(&%s{}), assoc, dest, fn)
+		})`, structTypeName),
+	}
+	r.setDoc(`// JoinScan joins table of association assoc (name of field) and streams
+	// rows of combined columns: every row is scanned into flat struct dest
+	// (pointer) and fn is called. Columns of association are prefixed with
+	// its db name, e.g. user_name for name of User, columns of model aren't.`)
+	return r
+}
+
+// NewEachRowMethod creates EachRow method for struct with numeric ID primary key
+func NewEachRowMethod(qsTypeName, structTypeName string) EachRowMethod {
+	r := EachRowMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("EachRow"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("fn func(%s) error", structTypeName)),
+		constBodyMethod: newConstBodyMethod(`if err := base.CheckNoLimitOffset(qs.db); err != nil {
+			return err
+		}
+
+		// order of query set is replaced: chunks are continued by ID
+		qs = qs.w(qs.db.Order("id ASC", true))
+		size := base.EachRowChunkSize(qs.db)
+		chunk := qs
+		for {
+			var rows []%s
+			if err := chunk.Limit(size).All(&rows); err != nil {
+				return err
+			}
+
+			for _, o := range rows {
+				if err := fn(o); err != nil {
+					return err
+				}
+			}
+
+			if len(rows) < size {
+				return nil
+			}
+			chunk = qs.IDGt(rows[len(rows)-1].ID)
+		}`, structTypeName),
+	}
+	r.setDoc(`// EachRow calls fn for every matching record: records are loaded by chunks
+	// of base.EachRowChunkSize records ordered by ID (order of query set is
+	// replaced). It stops on first error of fn. Limit or Offset of query set
+	// is an error.`)
+	return r
+}
+
+// UnscopedMethod creates Unscoped method
+type UnscopedMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewUnscopedMethod creates Unscoped method
+func NewUnscopedMethod(qsTypeName string) UnscopedMethod {
+	r := UnscopedMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Unscoped"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("qs.db.Unscoped()")),
+	}
+	r.setDoc(`// Unscoped selects both soft deleted and not deleted records`)
+	return r
+}
+
+// NewActiveFlagUnscopedMethod creates Unscoped method for struct with active flag
+func NewActiveFlagUnscopedMethod(qsTypeName string) UnscopedMethod {
+	r := NewUnscopedMethod(qsTypeName)
+	r.constBodyMethod = newConstBodyMethod("qs.unscoped = true\n%s",
+		wrapToGormScope("qs.db.Unscoped()"))
+	return r
+}
+
+// NewActiveFlagOnlyDeletedMethod creates OnlyDeleted method for struct
+// with active flag column activeFlag
+func NewActiveFlagOnlyDeletedMethod(qsTypeName, activeFlag string) OnlyDeletedMethod {
+	r := NewOnlyDeletedMethod(qsTypeName)
+	r.constBodyMethod = newConstBodyMethod("qs.unscoped = true\n%s",
+		wrapToGormScope(fmt.Sprintf(`qs.db.Where("%s = ?", false)`, activeFlag)))
+	return r
+}
+
+// NewActiveFlagRestoreMethod creates Restore method for struct
+// with active flag column activeFlag
+func NewActiveFlagRestoreMethod(qsTypeName, structTypeName, activeFlag string) RestoreMethod {
+	r := NewRestoreMethod(qsTypeName, structTypeName)
+	r.constBodyMethod = newConstBodyMethod("qs.unscoped = true\n%s", wrapToValueTerminal("Restore", fmt.Sprintf(
+		`res := db.Model(&%s{}).
+			Where("%s = ?", false).
+			UpdateColumn("%s", true)
+		ret, err = res.RowsAffected, res.Error`, structTypeName, activeFlag, activeFlag)))
+	return r
+}
+
+// DeleteOlderThanMethod creates DeleteOlderThan method
+type DeleteOlderThanMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewDeleteOlderThanMethod creates DeleteOlderThan method by time fields timeFieldNames
+func NewDeleteOlderThanMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	timeFieldNames []string) DeleteOlderThanMethod {
+
+	return newDeleteOlderThanMethod(qsTypeName, dbSchemaFieldTypeName, timeFieldNames,
+		fmt.Sprintf("res := db.Delete(%s{})", structTypeName))
+}
+
+// NewActiveFlagDeleteOlderThanMethod creates DeleteOlderThan method for struct
+// with active flag column activeFlag: records are deleted by setting it to false
+func NewActiveFlagDeleteOlderThanMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	timeFieldNames []string, activeFlag string) DeleteOlderThanMethod {
+
+	return newDeleteOlderThanMethod(qsTypeName, dbSchemaFieldTypeName, timeFieldNames,
+		fmt.Sprintf(`res := db.Model(&%s{}).UpdateColumn("%s", false)`, structTypeName, activeFlag))
+}
+
+func newDeleteOlderThanMethod(qsTypeName, dbSchemaFieldTypeName string,
+	timeFieldNames []string, deleteCode string) DeleteOlderThanMethod {
+
+	columns := []string{}
+	for _, f := range timeFieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := DeleteOlderThanMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("DeleteOlderThan"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, d time.Duration", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod: newConstBodyMethod(`timeColumns := []string{%s}
+		qs = qs.w(base.OlderThan(qs.db, string(field), timeColumns, d))
+		%s`, strings.Join(columns, ", "), wrapToValueTerminal("DeleteOlderThan", deleteCode+`
+			ret, err = res.RowsAffected, res.Error`)),
+	}
+	r.setDoc(`// DeleteOlderThan deletes matching records with time field older than
+	// current time minus d (field < cutoff) and returns count of deleted records.
+	// Records are deleted like by Delete. Not time fields are errors.`)
+	return r
+}
+
+// NewActiveFlagDeleteMethod creates Delete method for struct with active
+// flag column activeFlag: records are deleted by setting it to false
+func NewActiveFlagDeleteMethod(qsTypeName, structTypeName, activeFlag string) DeleteMethod {
+	r := NewDeleteMethod(qsTypeName, structTypeName)
+	r.constBodyMethod = newConstBodyMethod("%s", wrapToTerminal("Delete", fmt.Sprintf(
+		`return db.Model(&%s{}).UpdateColumn("%s", false).Error`, structTypeName, activeFlag)))
+	return r
+}
+
+// AllCachedMethod creates AllCached method
+type AllCachedMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewAllCachedMethod creates AllCached method
+func NewAllCachedMethod(structName, qsTypeName string) AllCachedMethod {
+	r := AllCachedMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllCached"),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf(
+			"cache base.Cache, key string, ttl time.Duration, ret *[]%s", structName)),
+		constBodyMethod: newConstBodyMethod(`return base.Cached(cache, key, ttl, ret, func() error {
+			return qs.All(ret)
+		})`),
+	}
+	r.setDoc(`// AllCached is like All, but results are loaded from cache by key.
+	// On cache miss results are selected and stored in cache for ttl`)
+	return r
+}
+
+// ApplyFieldMaskMethod creates ApplyFieldMask method
+type ApplyFieldMaskMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewApplyFieldMaskMethod creates ApplyFieldMask method allowing
+// conditions on columns of fields fieldNames
+func NewApplyFieldMaskMethod(qsTypeName string, fieldNames []string) ApplyFieldMaskMethod {
+	columns := []string{}
+	for _, f := range fieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := ApplyFieldMaskMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ApplyFieldMask"),
+		constArgsMethod:    newConstArgsMethod("mask []string, values map[string]interface{}"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`columns := []string{%s}
+		db, err := base.ApplyFieldMask(qs.db, columns, mask, values)
+		if err != nil {
+			return qs, err
+		}
+		return qs.w(db), nil`, strings.Join(columns, ", ")),
+	}
+	r.setDoc(`// ApplyFieldMask adds equality conditions for every path of field mask
+	// (e.g. of protobuf FieldMask): column = values[path]. Paths are converted
+	// to snake_case and must be columns of model.`)
+	return r
+}
+
+// SearchMethod creates Search method
+type SearchMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewSearchMethod creates Search method by string fields stringFieldNames
+func NewSearchMethod(qsTypeName, dbSchemaFieldTypeName string, stringFieldNames []string) SearchMethod {
+	columns := []string{}
+	for _, f := range stringFieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := SearchMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Search"),
+		constArgsMethod:    newConstArgsMethod("term string, fields ..." + dbSchemaFieldTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`stringColumns := []string{%s}
+		columns := make([]string, 0, len(fields))
+		for _, f := range fields {
+			columns = append(columns, string(f))
+		}
+		return qs.w(base.Search(qs.db, term, stringColumns, columns))`, strings.Join(columns, ", ")),
+	}
+	r.setDoc(`// Search selects records containing term in any of string fields
+	// case-insensitively: (LOWER(a) LIKE ? OR LOWER(b) LIKE ?).
+	// Not string fields are errors of query.`)
+	return r
+}
+
+// AllIndexedByMethod creates AllIndexedBy method
+type AllIndexedByMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewAllIndexedByMethod creates AllIndexedBy method by fields keyFieldNames
+// which types can be map keys
+func NewAllIndexedByMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	keyFieldNames []string) AllIndexedByMethod {
+
+	fields := []string{}
+	for _, f := range keyFieldNames {
+		fields = append(fields, fmt.Sprintf("%q: %q,", gorm.ToDBName(f), f))
+	}
+
+	r := AllIndexedByMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllIndexedBy"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, dest interface{}", dbSchemaFieldTypeName)),
+		constBodyMethod: newConstBodyMethod(`keyFields := map[string]string{
+			%s
+		}
+		keyField, ok := keyFields[string(field)]
+		if !ok {
+			return fmt.Errorf("can't index %s by field %%q: it can't be map key", field)
+		}
+
+		var ret []%s
+		if err := base.CheckIndex(ret, keyField, dest); err != nil {
+			return err
+		}
+		if err := qs.All(&ret); err != nil {
+			return err
+		}
+
+		base.Index(ret, keyField, dest)
+		return nil`, strings.Join(fields, "\n"), structTypeName, structTypeName),
+	}
+	r.setDoc(fmt.Sprintf(`// AllIndexedBy selects all matching records into map pointed by dest
+	// indexed by field, e.g. *map[string]%s for string field. Records with
+	// duplicate values of field overwrite previous ones: the last one wins.`, structTypeName))
+	return r
+}
+
+// OrderByAPIFieldMethod creates OrderByAPIField method
+type OrderByAPIFieldMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// APIField is a field with name in API
+type APIField struct {
+	Name    string
+	APIName string
+}
+
+// NewOrderByAPIFieldMethod creates OrderByAPIField method ordering by fields
+// with names in API
+func NewOrderByAPIFieldMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	fields []APIField) OrderByAPIFieldMethod {
+
+	apiFields := []string{}
+	for _, f := range fields {
+		apiFields = append(apiFields, fmt.Sprintf("%q: %sDBSchema.%s,", f.APIName, structTypeName, f.Name))
+	}
+
+	r := OrderByAPIFieldMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("OrderByAPIField"),
+		constArgsMethod:    newConstArgsMethod("apiKey string, desc bool"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`apiFields := map[string]%s{
+			%s
+		}
+		field, ok := apiFields[apiKey]
+		if !ok {
+			return qs, fmt.Errorf("can't order %s by unknown API field %%q", apiKey)
+		}
+
+		if desc {
+			return qs.w(qs.db.Order(string(field) + " DESC")), nil
+		}
+		return qs.w(qs.db.Order(string(field) + " ASC")), nil`,
+			dbSchemaFieldTypeName, strings.Join(apiFields, "\n"), structTypeName),
+	}
+	r.setDoc(`// OrderByAPIField orders by field with name apiKey in API set by api
+	// setting of queryset tag, e.g. queryset:"api:createdAt". Only such
+	// fields can be used: unknown keys are errors.`)
+	return r
+}
+
+// WhereMapMethod creates WhereMap method
+type WhereMapMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewWhereMapMethod creates WhereMap method
+func NewWhereMapMethod(qsTypeName, structTypeName string) WhereMapMethod {
+	r := WhereMapMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WhereMap"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("conditions %sConditions", structTypeName)),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`columns := make(map[string]interface{}, len(conditions))
+		for f, v := range conditions {
+			columns[string(f)] = v
+		}
+		db, err := base.WhereMap(qs.db, &%s{}, columns)
+		if err != nil {
+			return qs, err
+		}
+		return qs.w(db), nil`, structTypeName),
+	}
+	r.setDoc(fmt.Sprintf(`// WhereMap selects records with fields equal to values of conditions
+	// by fields of %sDBSchema. Unknown fields and values of types different
+	// from types of fields are errors with field: they are checked before
+	// querying. Nil is IS NULL for pointer fields.`, structTypeName))
+	return r
+}
+
+// FilterFromStructMethod creates FilterFromStruct method
+type FilterFromStructMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewFilterFromStructMethod creates FilterFromStruct method
+func NewFilterFromStructMethod(qsTypeName, structTypeName string) FilterFromStructMethod {
+	r := FilterFromStructMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("FilterFromStruct"),
+		constArgsMethod:    newConstArgsMethod("v interface{}"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`db, err := base.FilterFromStruct(qs.db, &%s{}, v)
+		if err != nil {
+			return qs, err
+		}
+		return qs.w(db), nil`, structTypeName),
+	}
+	r.setDoc(fmt.Sprintf(`// FilterFromStruct selects records with fields equal to not zero fields
+	// of struct v (or pointer to it), e.g. of admin search form. Fields of v are
+	// matched to fields of %s by gorm column tag or by name, types must match.
+	// Fields tagged with queryset:"-" are skipped, other unknown fields are errors.`, structTypeName))
+	return r
+}
+
+// CountByHourMethod creates CountByHour method
+type CountByHourMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCountByHourMethod creates CountByHour method by time fields timeFieldNames
+func NewCountByHourMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	timeFieldNames []string) CountByHourMethod {
+
+	columns := []string{}
+	for _, f := range timeFieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := CountByHourMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountByHour"),
+		oneArgMethod:       newOneArgMethod("field", dbSchemaFieldTypeName),
+		constRetMethod:     newConstRetMethod("(ret []base.HourCount, err error)"),
+		constBodyMethod: newConstBodyMethod(`timeColumns := []string{%s}
+		%s`, strings.Join(columns, ", "), wrapToValueTerminal("CountByHour", fmt.Sprintf(
+			"ret, err = base.CountByHour(db.Model(&%s{}), string(field), timeColumns)", structTypeName))),
+	}
+	r.setDoc(`// CountByHour returns counts of matching records grouped by hour of time
+	// field ordered chronologically. Not time fields are errors.`)
+	return r
+}
+
+// PercentileMethod creates Percentile method
+type PercentileMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewPercentileMethod creates Percentile method by numeric fields numericFieldNames
+func NewPercentileMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	numericFieldNames []string) PercentileMethod {
+
+	columns := []string{}
+	for _, f := range numericFieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := PercentileMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Percentile"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, p float64", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret float64, err error)"),
+		constBodyMethod: newConstBodyMethod(`numericColumns := []string{%s}
+		%s`, strings.Join(columns, ", "), wrapToValueTerminal("Percentile", fmt.Sprintf(
+			"ret, err = base.Percentile(db.Model(&%s{}), string(field), numericColumns, p)", structTypeName))),
+	}
+	r.setDoc(`// Percentile returns percentile p (0 <= p <= 1) of numeric field values
+	// of matching records: PERCENTILE_CONT in PostgreSQL, approximation by
+	// nearest rank in MySQL. Not numeric fields and other dialects are errors.`)
+	return r
+}
+
+// NewHistogramFieldMethod creates HistogramField method by numeric fields numericFieldNames
+func NewHistogramFieldMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	numericFieldNames []string) PercentileMethod {
+
+	columns := []string{}
+	for _, f := range numericFieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := PercentileMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("HistogramField"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, bucketSize float64", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret []base.Bucket, err error)"),
+		constBodyMethod: newConstBodyMethod(`numericColumns := []string{%s}
+		%s`, strings.Join(columns, ", "), wrapToValueTerminal("HistogramField", fmt.Sprintf(
+			"ret, err = base.Histogram(db.Model(&%s{}), string(field), numericColumns, bucketSize)", structTypeName))),
+	}
+	r.setDoc(`// HistogramField returns counts of matching records by buckets [From, To)
+	// of size bucketSize of numeric field values, e.g. for histogram charts:
+	// GROUP BY FLOOR(field / bucketSize). Not numeric fields are errors.`)
+	return r
+}
+
+// IsEmptyMethod creates IsEmpty method
+type IsEmptyMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewIsEmptyMethod creates IsEmpty method
+func NewIsEmptyMethod(qsTypeName, structTypeName string) IsEmptyMethod {
+	r := IsEmptyMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("IsEmpty"),
+		constRetMethod:     newConstRetMethod("(ret bool, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("IsEmpty", fmt.Sprintf(
+			"ret, err = base.IsEmpty(db.Model(&%s{}))", structTypeName))),
+	}
+	r.setDoc(`// IsEmpty returns true if there are no matching records.
+	// It selects at most one record: LIMIT 1`)
+	return r
+}
+
+// WithAdvisoryLockMethod creates WithAdvisoryLock method
+type WithAdvisoryLockMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewWithAdvisoryLockMethod creates WithAdvisoryLock method
+func NewWithAdvisoryLockMethod(qsTypeName string) WithAdvisoryLockMethod {
+	r := WithAdvisoryLockMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WithAdvisoryLock"),
+		oneArgMethod:       newOneArgMethod("key", "int64"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`return qs.Defer(func(qs %s) %s {
+			return qs.w(base.AdvisoryXactLock(qs.db, key))
+		})`, qsTypeName, qsTypeName),
+	}
+	r.setDoc(`// WithAdvisoryLock acquires PostgreSQL advisory lock key by pg_advisory_xact_lock
+	// just before execution of query. Query set must be made on transaction:
+	// lock is released at the end of it.`)
+	return r
+}
+
+// FilterInputField is a field of filter input: its operators
+// are allowed by kind of field
+type FilterInputField struct {
+	Name      string
+	IsNumeric bool
+	IsString  bool
+
+	// IsOrdered is set for fields having Lt, Gt, Lte and Gte filter methods
+	IsOrdered bool
+
+	// HasIn is set for fields having In filter method: it validates values
+	// (e.g. members of enum), other fields are matched by base.WhereIn
+	HasIn bool
+}
+
+// ApplyFilterInputMethod creates ApplyFilterInput method
+type ApplyFilterInputMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewApplyFilterInputMethod creates ApplyFilterInput method. Filter input
+// has pointer to operators struct {Eq, Ne, In, Gt, Gte, Lt, Lte, Like}
+// for every field from fields
+func NewApplyFilterInputMethod(qsTypeName, filterInputTypeName string,
+	fields []FilterInputField) ApplyFilterInputMethod {
+
+	body := []string{}
+	for _, f := range fields {
+		in := fmt.Sprintf(`qs.w(base.WhereIn(qs.db, "%s", f.In))`, gorm.ToDBName(f.Name))
+		if f.HasIn {
+			in = fmt.Sprintf("qs.%sIn(f.In...)", f.Name)
+		}
+		cond := []string{
+			fmt.Sprintf(`if f.Eq != nil {
+				qs = qs.%sEq(*f.Eq)
+			}
+			if f.Ne != nil {
+				qs = qs.%sNe(*f.Ne)
+			}
+			if f.In != nil {
+				qs = %s
+			}`, f.Name, f.Name, in),
+		}
+
+		if f.IsOrdered {
+			for _, op := range []string{"Gt", "Gte", "Lt", "Lte"} {
+				cond = append(cond, fmt.Sprintf(`if f.%s != nil {
+					qs = qs.%s%s(*f.%s)
+				}`, op, f.Name, op, op))
+			}
+		} else {
+			cond = append(cond, fmt.Sprintf(`if f.Gt != nil || f.Gte != nil || f.Lt != nil || f.Lte != nil {
+				return qs, errors.New("%s.%s: Gt, Gte, Lt and Lte are supported only by ordered fields")
+			}`, filterInputTypeName, f.Name))
+		}
+
+		if f.IsString {
+			cond = append(cond, fmt.Sprintf(`if f.Like != nil {
+				qs = qs.w(qs.db.Where("%s LIKE ?", *f.Like))
+			}`, gorm.ToDBName(f.Name)))
+		} else {
+			cond = append(cond, fmt.Sprintf(`if f.Like != nil {
+				return qs, errors.New("%s.%s: Like is supported only by string fields")
+			}`, filterInputTypeName, f.Name))
+		}
+
+		body = append(body, fmt.Sprintf(`if f := input.%s; f != nil {
+			%s
+		}`, f.Name, strings.Join(cond, "\n")))
+	}
+	body = append(body, "return qs, nil")
+
+	r := ApplyFilterInputMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ApplyFilterInput"),
+		oneArgMethod:       newOneArgMethod("input", filterInputTypeName),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod:    newConstBodyMethod("%s", strings.Join(body, "\n")),
+	}
+	r.setDoc(`// ApplyFilterInput adds conditions for every not nil operator of every
+	// not nil field of GraphQL-style filter input. Operators not supported
+	// by kind of field (e.g. Like for numeric field) are errors. In is split
+	// into chunks like {Field}In, empty not nil In matches no records.`)
+	return r
+}
+
+// ExplainMethod creates Explain and ExplainAnalyze methods
+type ExplainMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+func newExplainMethod(name string, analyze bool, qsTypeName, structTypeName string) ExplainMethod {
+	return ExplainMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(name),
+		constRetMethod:     newConstRetMethod("(ret string, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal(name, fmt.Sprintf(
+			"ret, err = base.Explain(db.Model(&%s{}), %t)", structTypeName, analyze))),
+	}
+}
+
+// NewExplainMethod creates Explain method
+func NewExplainMethod(qsTypeName, structTypeName string) ExplainMethod {
+	r := newExplainMethod("Explain", false, qsTypeName, structTypeName)
+	r.setDoc(`// Explain returns plan of query selecting records by All:
+	// query with the same conditions is prefixed by EXPLAIN`)
+	return r
+}
+
+// NewExplainAnalyzeMethod creates ExplainAnalyze method
+func NewExplainAnalyzeMethod(qsTypeName, structTypeName string) ExplainMethod {
+	r := newExplainMethod("ExplainAnalyze", true, qsTypeName, structTypeName)
+	r.setDoc(`// ExplainAnalyze is like Explain, but query is executed by EXPLAIN ANALYZE
+	// to get actual times. It's supported only by PostgreSQL and MySQL.`)
+	return r
+}
+
+// CountMethod creates Count, CountUnscoped and CountDeleted methods
+type CountMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCountMethod creates Count method
+func NewCountMethod(qsTypeName, structTypeName string) CountMethod {
+	r := CountMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Count"),
+		constRetMethod:     newConstRetMethod("(ret int, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("Count", fmt.Sprintf(
+			"err = db.Model(&%s{}).Count(&ret).Error", structTypeName))),
+	}
+	r.setDoc(`// Count returns count of matching records, soft deleted records
+	// aren't counted`)
+	return r
+}
+
+// NewCountUnscopedMethod creates CountUnscoped method
+func NewCountUnscopedMethod(qsTypeName string) CountMethod {
+	r := CountMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountUnscoped"),
+		constRetMethod:     newConstRetMethod("(int, error)"),
+		constBodyMethod:    newConstBodyMethod("return qs.Unscoped().Count()"),
+	}
+	r.setDoc(`// CountUnscoped returns count of matching records including soft deleted`)
+	return r
+}
+
+// NewCountDeletedMethod creates CountDeleted method
+func NewCountDeletedMethod(qsTypeName string) CountMethod {
+	r := CountMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountDeleted"),
+		constRetMethod:     newConstRetMethod("(int, error)"),
+		constBodyMethod:    newConstBodyMethod("return qs.OnlyDeleted().Count()"),
+	}
+	r.setDoc(`// CountDeleted returns count of matching soft deleted records`)
+	return r
+}
+
+// EnumFilterMethod filters enum field by validated values
+type EnumFilterMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// getEnumFieldLabel returns name of enum field for validation errors
+func getEnumFieldLabel(structTypeName, fieldName string) string {
+	return structTypeName + "." + fieldName
+}
+
+// GetEnumMembersVarName returns name of var with members of enum field
+func GetEnumMembersVarName(structTypeName, fieldName string) string {
+	return structTypeName + fieldName + "Members"
+}
+
+func newEnumBinaryFilterMethod(name, op, structTypeName, fieldName, argTypeName,
+	qsTypeName string, described bool) EnumFilterMethod {
+
+	argName := fieldNameToArgName(fieldName)
+	checked := fmt.Sprintf(`base.CheckEnum(qs.db, "%s", %s, string(%s))`,
+		getEnumFieldLabel(structTypeName, fieldName),
+		GetEnumMembersVarName(structTypeName, fieldName), argName)
+	where := fmt.Sprintf(`%s.Where("%s %s ?", %s)`, checked, gorm.ToDBName(fieldName), op, argName)
+	if described {
+		where = fmt.Sprintf(`base.DescribedWhere(%s, "%s", "%s", %s, "%s %s ?", %s)`, checked,
+			fieldName, strings.ToLower(name), argName, gorm.ToDBName(fieldName), op, argName)
+	}
+
+	return EnumFilterMethod{
+		onFieldMethod:      newOnFieldMethod(name, fieldName),
+		constArgsMethod:    newConstArgsMethod(argName + " " + argTypeName),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope(where)),
+	}
+}
+
+// NewEnumEqFilterMethod creates {FieldName}Eq method for enum field:
+// value must be a member of enum. Described condition is described
+// for Describe method of query set.
+func NewEnumEqFilterMethod(structTypeName, fieldName, argTypeName, qsTypeName string,
+	described bool) EnumFilterMethod {
+
+	return newEnumBinaryFilterMethod("Eq", "=", structTypeName, fieldName, argTypeName,
+		qsTypeName, described)
+}
+
+// NewEnumNeFilterMethod creates {FieldName}Ne method for enum field:
+// value must be a member of enum. Described condition is described
+// for Describe method of query set.
+func NewEnumNeFilterMethod(structTypeName, fieldName, argTypeName, qsTypeName string,
+	described bool) EnumFilterMethod {
+
+	return newEnumBinaryFilterMethod("Ne", "!=", structTypeName, fieldName, argTypeName,
+		qsTypeName, described)
+}
+
+// NewEnumInFilterMethod creates {FieldName}In method for enum field:
+// all values must be members of enum
+func NewEnumInFilterMethod(structTypeName, fieldName, argTypeName, qsTypeName string) EnumFilterMethod {
+	argName := fieldNameToArgName(fieldName)
+	r := EnumFilterMethod{
+		onFieldMethod:      newOnFieldMethod("In", fieldName),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("%s ...%s", argName, argTypeName)),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`values := make([]string, 0, len(%s))
+		for _, v := range %s {
+			values = append(values, string(v))
+		}
+		%s`, argName, argName, wrapToGormScope(fmt.Sprintf(
+			`base.WhereIn(base.CheckEnum(qs.db, "%s", %s, values...), "%s", %s)`,
+			getEnumFieldLabel(structTypeName, fieldName),
+			GetEnumMembersVarName(structTypeName, fieldName),
+			gorm.ToDBName(fieldName), argName))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s filters by values of enum: all of them must be members
+	// of %s`, r.GetMethodName(), GetEnumMembersVarName(structTypeName, fieldName)))
+	return r
+}
+
+// AllIntoMethod creates AllInto method
+type AllIntoMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewAllIntoMethod creates AllInto method selecting only columns of fields
+// from fieldNames
+func NewAllIntoMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	fieldNames []string) AllIntoMethod {
+
+	columns := []string{}
+	for _, f := range fieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := AllIntoMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllInto"),
+		constArgsMethod:    newConstArgsMethod("dest interface{}, fields ..." + dbSchemaFieldTypeName),
+		constBodyMethod: newConstBodyMethod(`columns := []string{%s}
+		selected := make([]string, 0, len(fields))
+		for _, f := range fields {
+			selected = append(selected, string(f))
+		}
+		%s`, strings.Join(columns, ", "), wrapToTerminal("AllInto", fmt.Sprintf(
+			"return base.SelectInto(db.Model(&%s{}), dest, columns, selected)", structTypeName))),
+	}
+	r.setDoc(`// AllInto selects only columns of fields of matching records and scans
+	// them into slice of projection structs pointed by dest, e.g. *[]UserListItem`)
+	return r
+}
+
+// NewCountApproxMethod creates CountApprox method
+func NewCountApproxMethod(qsTypeName, structTypeName string) CountMethod {
+	r := CountMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CountApprox"),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("CountApprox", fmt.Sprintf(
+			"ret, err = base.CountApprox(db.Model(&%s{}))", structTypeName))),
+	}
+	r.setDoc(`// CountApprox returns approximate count of records from table statistics
+	// if query set has no conditions, otherwise it's an exact count. Soft delete
+	// condition isn't taken into account: estimate includes soft deleted records.`)
+	return r
+}
+
+// LatestPerFieldMethod creates LatestPerField method
+type LatestPerFieldMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewLatestPerFieldMethod creates LatestPerField method selecting records
+// with max value of versionFieldName per key
+func NewLatestPerFieldMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName,
+	versionFieldName string) LatestPerFieldMethod {
+
+	r := LatestPerFieldMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("LatestPerField"),
+		oneArgMethod:       newOneArgMethod("key", dbSchemaFieldTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.LatestPerField(qs.db, &%s{}, string(key), "%s")`,
+			structTypeName, gorm.ToDBName(versionFieldName)))),
+	}
+	r.setDoc(fmt.Sprintf(`// LatestPerField selects only the latest version of records
+	// with the same value of key field: records with max %s`, versionFieldName))
+	return r
+}
+
+// WithTracerMethod creates WithTracer method
+type WithTracerMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewWithTracerMethod creates WithTracer method
+func NewWithTracerMethod(qsTypeName string) WithTracerMethod {
+	r := WithTracerMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WithTracer"),
+		oneArgMethod:       newOneArgMethod("tracer", "base.Tracer"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("base.WithTracer(qs.db, tracer)")),
+	}
+	r.setDoc(`// WithTracer sets tracer of terminal operations: every operation is executed
+	// in span named {QuerySet}.{Operation} with executed SQL as attribute`)
+	return r
+}
+
+// WithContextMethod creates WithContext method
+type WithContextMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewWithContextMethod creates WithContext method
+func NewWithContextMethod(qsTypeName string) WithContextMethod {
+	r := WithContextMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WithContext"),
+		oneArgMethod:       newOneArgMethod("ctx", "context.Context"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("base.WithContext(qs.db, ctx)")),
+	}
+	r.setDoc(`// WithContext sets context of terminal operations and updater: they return
+	// its error without executing queries if it's done. Already executing
+	// query isn't cancelled: GORM v1 has no context API`)
+	return r
+}
+
+// WithRetryMethod creates WithRetry method
+type WithRetryMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewWithRetryMethod creates WithRetry method
+func NewWithRetryMethod(qsTypeName string) WithRetryMethod {
+	r := WithRetryMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WithRetry"),
+		constArgsMethod:    newConstArgsMethod("attempts int, backoff time.Duration"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(
+			"base.WithRetry(qs.db, attempts, backoff)")),
+	}
+	r.setDoc(`// WithRetry makes terminal operations and updater retry up to attempts
+	// times on deadlocks and serialization failures after backoff doubled
+	// by every retry. Other errors are returned immediately. Operations in
+	// transactions aren't retried: the whole transaction must be retried`)
+	return r
+}
+
+// UseHandleMethod creates UseReplica or UsePrimary method
+type UseHandleMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewUseReplicaMethod creates UseReplica method
+func NewUseReplicaMethod(qsTypeName string) UseHandleMethod {
+	r := UseHandleMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("UseReplica"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("base.UseReplica(qs.db)")),
+	}
+	r.setDoc(`// UseReplica routes query set to replica handle of resolver set by
+	// base.WithResolver. It must be called before adding of conditions.
+	// Writes of structs by replica handle (e.g. Create) are routed to primary.`)
+	return r
+}
+
+// NewUsePrimaryMethod creates UsePrimary method
+func NewUsePrimaryMethod(qsTypeName string) UseHandleMethod {
+	r := UseHandleMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("UsePrimary"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("base.UsePrimary(qs.db)")),
+	}
+	r.setDoc(`// UsePrimary routes query set to primary handle of resolver set by
+	// base.WithResolver, e.g. to read own writes. It must be called before
+	// adding of conditions.`)
+	return r
+}
+
+// CreateIfNotMatchedMethod creates CreateIfNotMatched method
+type CreateIfNotMatchedMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// getCreateFunc returns func creating record o by its Create method:
+// records of tenant models are created for tenant of query set
+func getCreateFunc(o string, tenant bool) string {
+	if !tenant {
+		return o + ".Create"
+	}
+	return fmt.Sprintf(`func(db *gorm.DB) error {
+		return %s.Create(db, qs.tenantID)
+	}`, o)
+}
+
+// NewCreateIfNotMatchedMethod creates CreateIfNotMatched method, tenant
+// is set for query sets of tenant models
+func NewCreateIfNotMatchedMethod(qsTypeName, structTypeName string, tenant bool) CreateIfNotMatchedMethod {
+	r := CreateIfNotMatchedMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("CreateIfNotMatched"),
+		oneArgMethod:       newOneArgMethod("o", "*"+structTypeName),
+		constRetMethod:     newConstRetMethod("(created bool, err error)"),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("CreateIfNotMatched", fmt.Sprintf(
+			`created, err = base.CreateIfNotMatched(db.Model(&%s{}), %s)`,
+			structTypeName, getCreateFunc("o", tenant)))),
+	}
+	r.setDoc(`// CreateIfNotMatched creates o only if there are no matching records in one
+	// transaction: existence is checked by locking read SELECT ... FOR UPDATE.
+	// PostgreSQL doesn't lock absent rows, so unique constraint is needed there
+	// to prevent races. Only PostgreSQL and MySQL are supported.`)
+	return r
+}
+
+// GetOrCreateMethod creates GetOrCreate method
+type GetOrCreateMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	oneArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewGetOrCreateMethod creates GetOrCreate method, tenant is set
+// for query sets of tenant models
+func NewGetOrCreateMethod(qsTypeName, structTypeName string, tenant bool) GetOrCreateMethod {
+	r := GetOrCreateMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("GetOrCreate"),
+		oneArgMethod:       newOneArgMethod("attrs", "*"+structTypeName),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(ret %s, created bool, err error)", structTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("GetOrCreate", fmt.Sprintf(
+			`created, err = base.GetOrCreate(db, &ret, attrs, %s)
+			if created {
+				ret = *attrs
+			}`, getCreateFunc("attrs", tenant)))),
+	}
+	r.setDoc(`// GetOrCreate returns first record matching query set or creates attrs
+	// if there are no such records: created is true then. Values of {Field}Eq
+	// conditions are assigned to fields of attrs before create, so created record
+	// matches query set. Soft delete condition is applied only to lookup.
+	// Lookup and create aren't atomic: if create fails by duplicate key of
+	// concurrently created record, it's selected again. Use unique constraint
+	// or CreateIfNotMatched to prevent duplicates by races.`)
+	return r
+}
+
+// ClaimBatchMethod creates ClaimBatch method
+type ClaimBatchMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewClaimBatchMethod creates ClaimBatch method for struct with primary key
+// field pkFieldName of type pkTypeName, claimedBy string and claimedAt
+// *time.Time fields
+func NewClaimBatchMethod(qsTypeName, structTypeName, pkFieldName, pkTypeName,
+	claimedByFieldName, claimedAtFieldName string) ClaimBatchMethod {
+
+	claimedAt := gorm.ToDBName(claimedAtFieldName)
+	r := ClaimBatchMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ClaimBatch"),
+		constArgsMethod:    newConstArgsMethod("workerID string, n int"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(ret []%s, err error)", structTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("ClaimBatch", fmt.Sprintf(
+			`err = base.InTransaction(db, func(tx *gorm.DB) error {
+				err := base.FindForUpdateSkipLocked(tx.Where("%s IS NULL").Limit(n), &ret)
+				if err != nil || len(ret) == 0 {
+					return err
+				}
+
+				ids := make([]%s, 0, len(ret))
+				for _, o := range ret {
+					ids = append(ids, o.%s)
+				}
+				now := gorm.NowFunc()
+				err = base.SetClaimed(tx, &%s{}, "%s", "%s", ids, workerID, now)
+				if err != nil {
+					return err
+				}
+
+				for i := range ret {
+					ret[i].%s = workerID
+					ret[i].%s = &now
+				}
+				return nil
+			})`, claimedAt, pkTypeName, pkFieldName, structTypeName,
+			gorm.ToDBName(claimedByFieldName), claimedAt, claimedByFieldName, claimedAtFieldName))),
+	}
+	r.setDoc(fmt.Sprintf(`// ClaimBatch claims up to n not claimed (%s IS NULL) matching records
+	// for worker workerID in one transaction: records are selected by
+	// FOR UPDATE SKIP LOCKED and their %s and %s are set.
+	// Only PostgreSQL and MySQL 8 are supported.`, claimedAt, gorm.ToDBName(claimedByFieldName), claimedAt))
+	return r
+}
+
+// NextReadyMethod creates NextReady method
+type NextReadyMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewNextReadyMethod creates NextReady method for struct with
+// numeric priority and readyAt time.Time fields
+func NewNextReadyMethod(qsTypeName, structTypeName, priorityFieldName, readyAtFieldName string) NextReadyMethod {
+	priority, readyAt := gorm.ToDBName(priorityFieldName), gorm.ToDBName(readyAtFieldName)
+	r := NextReadyMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("NextReady"),
+		constArgsMethod:    newConstArgsMethod("n int"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(ret []%s, err error)", structTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", wrapToValueTerminal("NextReady", fmt.Sprintf(
+			`err = base.FindForUpdateSkipLocked(db.Where("%s <= ?", gorm.NowFunc()).
+				Order("%s DESC").Order("%s ASC").Limit(n), &ret)`, readyAt, priority, readyAt))),
+	}
+	r.setDoc(fmt.Sprintf(`// NextReady selects up to n matching records ready to run (%s <= now)
+	// ordered by %s, higher first, and then by %s. Records are
+	// selected by FOR UPDATE SKIP LOCKED: locks are held only in transaction,
+	// e.g. of base.InTransaction. Only PostgreSQL and MySQL 8 are supported.`, readyAt, priority, readyAt))
+	return r
+}
+
+// OverlapsRangeMethod creates OverlapsRange method
+type OverlapsRangeMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewOverlapsRangeMethod creates OverlapsRange method for range
+// [startFieldName, endFieldName) of type argTypeName
+func NewOverlapsRangeMethod(qsTypeName, startFieldName, endFieldName,
+	argTypeName string) OverlapsRangeMethod {
+
+	r := OverlapsRangeMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("OverlapsRange"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("from, to %s", argTypeName)),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`qs.db.Where("%s < ? AND %s > ?", to, from)`,
+			gorm.ToDBName(startFieldName), gorm.ToDBName(endFieldName)))),
+	}
+	r.setDoc(fmt.Sprintf(`// OverlapsRange selects records with range [%s, %s) overlapping
+	// half-open range [from, to)`, startFieldName, endFieldName))
+	return r
+}
+
+// NewValueInFieldRangeMethod creates ValueInFieldRange method by ordered
+// fields fieldNames of types fieldTypeNames
+func NewValueInFieldRangeMethod(qsTypeName, dbSchemaFieldTypeName string,
+	fieldNames, fieldTypeNames []string) OverlapsRangeMethod {
+
+	columnTypes := []string{}
+	for i, f := range fieldNames {
+		columnTypes = append(columnTypes, fmt.Sprintf("%q: %q", gorm.ToDBName(f), fieldTypeNames[i]))
+	}
+
+	r := OverlapsRangeMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ValueInFieldRange"),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf("lowField, highField %s, value interface{}",
+			dbSchemaFieldTypeName)),
+		retQuerySetMethod: newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`columnTypes := map[string]string{%s}
+		%s`, strings.Join(columnTypes, ", "), wrapToGormScope(
+			"base.ValueInFieldRange(qs.db, string(lowField), string(highField), value, columnTypes)")),
+	}
+	r.setDoc(`// ValueInFieldRange selects records with value within range of their
+	// fields: lowField <= value AND highField >= value, e.g. pricing tier
+	// of amount. Fields of different types are errors.`)
+	return r
+}
+
+// ContinueAfterMethod creates ContinueAfter method
+type ContinueAfterMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewContinueAfterMethod creates ContinueAfter method by ordered
+// fields fieldNames
+func NewContinueAfterMethod(qsTypeName, structTypeName string, fieldNames []string) ContinueAfterMethod {
+	columns := []string{}
+	for _, f := range fieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	r := ContinueAfterMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ContinueAfter"),
+		constArgsMethod:    newConstArgsMethod("cursor string"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`orderedColumns := []string{%s}
+		db, err := base.ContinueAfter(qs.db, &%s{}, cursor, orderedColumns)
+		if err != nil {
+			return qs, err
+		}
+		return qs.w(db), nil`, strings.Join(columns, ", "), structTypeName),
+	}
+	r.setDoc(fmt.Sprintf(`// ContinueAfter selects records after the last record of cursor made
+	// by %s.KeysetCursor by lexicographic comparison of sort keys, e.g.
+	// (name, id) > (?, ?). Records must be ordered by the same fields
+	// in the same direction.`, structTypeName))
+	return r
+}
+
+// NewNeighborByMethod creates NextBy or PrevBy method finding neighbor
+// record by ordered fields fieldNames
+func NewNeighborByMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName, pkFieldName string,
+	fieldNames []string, next bool) EachRowMethod {
+
+	columns := []string{}
+	for _, f := range fieldNames {
+		columns = append(columns, fmt.Sprintf("%q", gorm.ToDBName(f)))
+	}
+
+	name, doc := "NextBy", "next to current by ascending"
+	if !next {
+		name, doc = "PrevBy", "previous to current by descending"
+	}
+	r := EachRowMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(name),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf("field %s, current, ret *%s",
+			dbSchemaFieldTypeName, structTypeName)),
+		constBodyMethod: newConstBodyMethod(`orderedColumns := []string{%s}
+		return qs.exec(%q, func(db *gorm.DB) error {
+			return base.Neighbor(db, current, string(field), %q, orderedColumns, %t, ret)
+		})`, strings.Join(columns, ", "), name, gorm.ToDBName(pkFieldName), next),
+	}
+	r.setDoc(fmt.Sprintf(`// %s finds matching record %s order of field
+	// into ret, e.g. for navigation on detail page. Ties are resolved by %s.
+	// gorm.ErrRecordNotFound is returned if there is no such record.`, name, doc, pkFieldName))
+	return r
+}
+
+// SelectFieldsMethod creates Select method restricting selected fields
+type SelectFieldsMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewSelectMethod creates Select method restricting selected columns
+func NewSelectMethod(qsTypeName, dbSchemaFieldTypeName string) SelectFieldsMethod {
+	r := SelectFieldsMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Select"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("fields ...%s", dbSchemaFieldTypeName)),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod(`if len(fields) == 0 {
+			return qs
+		}
+
+		columns := make([]string, 0, len(fields))
+		for _, f := range fields {
+			columns = append(columns, string(f))
+		}
+		return qs.w(qs.db.Select(columns))`),
+	}
+	r.setDoc(`// Select restricts columns selected by All, One, etc to fields,
+	// other fields of records are zero. Without fields all columns are selected.`)
+	return r
+}
+
+// WithinBoundingBoxMethod creates WithinBoundingBox method
+type WithinBoundingBoxMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewWithinBoundingBoxMethod creates WithinBoundingBox method
+// for point of latColumn and lngColumn
+func NewWithinBoundingBoxMethod(qsTypeName, latColumn, lngColumn string) WithinBoundingBoxMethod {
+	r := WithinBoundingBoxMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("WithinBoundingBox"),
+		constArgsMethod:    newConstArgsMethod("minLat, minLng, maxLat, maxLng float64"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.WithinBoundingBox(qs.db, "%s", "%s", minLat, minLng, maxLat, maxLng)`,
+			latColumn, lngColumn))),
+	}
+	r.setDoc(fmt.Sprintf(`// WithinBoundingBox selects records with point (%s, %s) within
+	// bounding box: boxes with minLng > maxLng cross the antimeridian`, latColumn, lngColumn))
+	return r
+}
+
+// JSONArrayContainsMethod filters JSON array field by containing value
+type JSONArrayContainsMethod struct {
+	onFieldMethod
+	constArgsMethod
+	baseQuerySetMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewJSONArrayContainsMethod creates {FieldName}Contains method for JSON array field
+func NewJSONArrayContainsMethod(fieldName, qsTypeName string) JSONArrayContainsMethod {
+	return JSONArrayContainsMethod{
+		onFieldMethod:      newOnFieldMethod("Contains", fieldName),
+		constArgsMethod:    newConstArgsMethod("value string"),
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.JSONArrayContains(qs.db, "%s", value)`, gorm.ToDBName(fieldName)))),
+	}
+}
+
+// AsScopeMethod creates AsScope method
+type AsScopeMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewAsScopeMethod creates AsScope method
+func NewAsScopeMethod(qsTypeName string) AsScopeMethod {
+	r := AsScopeMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AsScope"),
+		constRetMethod:     newConstRetMethod("func(*gorm.DB) *gorm.DB"),
+		constBodyMethod:    newConstBodyMethod("return base.AsScope(qs.scopedDB())"),
+	}
+	r.setDoc(`// AsScope returns GORM scope adding conditions of query set, e.g. for
+	// db.Scopes(...) or preloads. Query set must have only conditions`)
+	return r
+}
+
+// ScalarSubQueryMethod creates ScalarSubQuery method
+type ScalarSubQueryMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewScalarSubQueryMethod creates ScalarSubQuery method
+func NewScalarSubQueryMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string) ScalarSubQueryMethod {
+	r := ScalarSubQueryMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("ScalarSubQuery"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("agg base.Aggregate, field %s", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("base.SubQuery"),
+		constBodyMethod: newConstBodyMethod("return base.ScalarSubQuery(qs.scopedDB(), &%s{}, agg, string(field))",
+			structTypeName),
+	}
+	r.setDoc(`// ScalarSubQuery returns subquery selecting aggregate agg of field
+	// of matching records, e.g. SELECT AVG(age) FROM users WHERE ...,
+	// to compare fields with it by Field{Op}ScalarSubQuery methods`)
+	return r
+}
+
+// CompareScalarSubQueryMethod creates Field{Op}ScalarSubQuery method
+type CompareScalarSubQueryMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewCompareScalarSubQueryMethod creates Field{Op}ScalarSubQuery method
+// for binary filter operator name, e.g. "gt"
+func NewCompareScalarSubQueryMethod(qsTypeName, dbSchemaFieldTypeName, name string) CompareScalarSubQueryMethod {
+	op := filterOperators[name]
+	if op == "" {
+		log.Fatalf("no operation for filter %q", name)
+	}
+
+	methodName := "Field" + strings.Title(name) + "ScalarSubQuery"
+	r := CompareScalarSubQueryMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(methodName),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, sub base.SubQuery", dbSchemaFieldTypeName)),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`base.CompareScalarSubQuery(qs.db, string(field), "%s", sub)`, op))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s selects records with field %s value selected
+	// by scalar subquery sub of ScalarSubQuery: field %s (SELECT ...)`, methodName, op, op))
+	return r
+}
+
+// NewCompareExprMethod creates Field{Op}Expr method for binary
+// filter operator name, e.g. "lt"
+func NewCompareExprMethod(qsTypeName, dbSchemaFieldTypeName, name string) CompareScalarSubQueryMethod {
+	op := filterOperators[name]
+	if op == "" {
+		log.Fatalf("no operation for filter %q", name)
+	}
+
+	methodName := "Field" + strings.Title(name) + "Expr"
+	r := CompareScalarSubQueryMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod(methodName),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf("field %s, expr string, args ...interface{}",
+			dbSchemaFieldTypeName)),
+		retQuerySetMethod: newRetQuerySetMethod(qsTypeName),
+		constBodyMethod: newConstBodyMethod("%s", wrapToGormScope(fmt.Sprintf(
+			`qs.db.Where(string(field)+" %s ("+expr+")", args...)`, op))),
+	}
+	r.setDoc(fmt.Sprintf(`// %s selects records with field %s computed SQL expression expr,
+	// e.g. "cost * ?": field %s (expr). Values of placeholders ? of expr are
+	// args and are bound safely, but expr itself is inserted into query as is:
+	// it must never be built from user input.`, methodName, op, op))
+	return r
+}
+
+// GetDBMethod creates GetDB method
+type GetDBMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewGetDBMethod creates GetDB method
+func NewGetDBMethod(qsTypeName string) GetDBMethod {
+	r := GetDBMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("GetDB"),
+		constRetMethod:     newConstRetMethod("*gorm.DB"),
+		constBodyMethod:    newConstBodyMethod("return qs.scopedDB()"),
+	}
+	r.setDoc(`// GetDB returns db of query set with all conditions, orders and limits
+	// added so far, e.g. to add clause which can't be expressed by query set`)
+	return r
+}
+
+// ScopesMethod creates Scopes method
+type ScopesMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewScopesMethod creates Scopes method
+func NewScopesMethod(qsTypeName string) ScopesMethod {
+	r := ScopesMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Scopes"),
+		constArgsMethod:    newConstArgsMethod("fns ...func(*gorm.DB) *gorm.DB"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("qs.db.Scopes(fns...)")),
+	}
+	r.setDoc(`// Scopes applies GORM scopes fns to db of query set, e.g. to add raw
+	// clause which can't be expressed by query set and continue the chain`)
+	return r
+}
+
+// SetFieldForAllMethod creates SetFieldForAll method
+type SetFieldForAllMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewSetFieldForAllMethod creates SetFieldForAll method: tenant column
+// can't be set
+func NewSetFieldForAllMethod(qsTypeName, structTypeName, dbSchemaFieldTypeName string,
+	tenant Tenant) SetFieldForAllMethod {
+
+	checkTenant := ""
+	if tenant.Column != "" {
+		// records can't be moved to other tenant
+		checkTenant = fmt.Sprintf(`if field == %sDBSchema.%s {
+			return 0, fmt.Errorf("can't set tenant column %%s", field)
+		}
+		`, structTypeName, tenant.FieldName)
+	}
+
+	r := SetFieldForAllMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("SetFieldForAll"),
+		constArgsMethod:    newConstArgsMethod(fmt.Sprintf("field %s, value interface{}", dbSchemaFieldTypeName)),
+		constRetMethod:     newConstRetMethod("(ret int64, err error)"),
+		constBodyMethod: newConstBodyMethod(`%sqs = qs.prepare()
+		if err = base.CheckConditions(qs.db); err != nil {
+			return 0, err
+		}
+
+		%s`, checkTenant, wrapToValueTerminal("SetFieldForAll", fmt.Sprintf(
+			`res := db.Model(&%s{}).UpdateColumn(string(field), value)
+			ret, err = res.RowsAffected, res.Error`, structTypeName))),
+	}
+	r.setDoc(`// SetFieldForAll sets field to value for all matching records by one UPDATE
+	// and returns count of updated records. Query set must have conditions
+	// or update of all records must be allowed by AllowGlobalUpdate`)
+	return r
+}
+
+// AllowGlobalUpdateMethod creates AllowGlobalUpdate method
+type AllowGlobalUpdateMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	retQuerySetMethod
+	constBodyMethod
+}
+
+// NewAllowGlobalUpdateMethod creates AllowGlobalUpdate method
+func NewAllowGlobalUpdateMethod(qsTypeName string) AllowGlobalUpdateMethod {
+	r := AllowGlobalUpdateMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("AllowGlobalUpdate"),
+		retQuerySetMethod:  newRetQuerySetMethod(qsTypeName),
+		constBodyMethod:    newConstBodyMethod("%s", wrapToGormScope("base.AllowGlobalUpdate(qs.db)")),
+	}
+	r.setDoc(`// AllowGlobalUpdate allows bulk updates (e.g. SetFieldForAll) of query set
+	// without conditions: all records are updated`)
+	return r
+}
+
+// FromDescriptionMethod creates FromDescription method
+type FromDescriptionMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// DescribeMethod creates Describe method
+type DescribeMethod struct {
+	baseQuerySetMethod
+	namedMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewDescribeMethod creates Describe method
+func NewDescribeMethod(qsTypeName string) DescribeMethod {
+	r := DescribeMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("Describe"),
+		constRetMethod:     newConstRetMethod("(base.QueryDescription, error)"),
+		constBodyMethod:    newConstBodyMethod("return base.Describe(qs.prepare().db)"),
+	}
+	r.setDoc(`// Describe returns serializable description of conditions of query set
+	// (e.g. to save search) to apply them again by FromDescription. Only
+	// conditions added by Eq, Ne, Lt, Gt, Lte and Gte filter methods of fields
+	// can be described: query set with other conditions is an error.`)
+	return r
+}
+
+// NewFromDescriptionMethod creates FromDescription method applying
+// conditions on fields (the same as fields of filter input)
+func NewFromDescriptionMethod(qsTypeName string, fields []FilterInputField,
+	fieldTypeNames []string) FromDescriptionMethod {
+
+	cases := []string{}
+	for i, f := range fields {
+		ops := []string{"Eq", "Ne"}
+		if f.IsOrdered {
+			ops = append(ops, "Lt", "Gt", "Lte", "Gte")
+		}
+
+		opCases := []string{}
+		for _, op := range ops {
+			opCases = append(opCases, fmt.Sprintf(`case "%s":
+				qs = qs.%s%s(v)`, strings.ToLower(op), f.Name, op))
+		}
+
+		cases = append(cases, fmt.Sprintf(`case "%s":
+			var v %s
+			if err := base.ConvertValue(c.Value, &v); err != nil {
+				return qs, fmt.Errorf("invalid value of condition %%d on %s: %%s", i, err)
+			}
+			switch c.Op {
+			%s
+			default:
+				return qs, fmt.Errorf("invalid op %%q of condition %%d on %s", c.Op, i)
+			}`, f.Name, fieldTypeNames[i], f.Name, strings.Join(opCases, "\n"), f.Name))
+	}
+
+	r := FromDescriptionMethod{
+		baseQuerySetMethod: newBaseQuerySetMethod(qsTypeName),
+		namedMethod:        newNamedMethod("FromDescription"),
+		constArgsMethod:    newConstArgsMethod("desc base.QueryDescription"),
+		constRetMethod:     newConstRetMethod(fmt.Sprintf("(%s, error)", qsTypeName)),
+		constBodyMethod: newConstBodyMethod(`for i, c := range desc.Conditions {
+			switch c.Field {
+			%s
+			default:
+				return qs, fmt.Errorf("invalid field %%q of condition %%d", c.Field, i)
+			}
+		}
+		return qs, nil`, strings.Join(cases, "\n")),
+	}
+	r.setDoc(`// FromDescription adds conditions of serialized description desc (e.g. saved
+	// search) by filter methods of query set. Fields, operators and values of
+	// conditions are validated.`)
+	return r
+}
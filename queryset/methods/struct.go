@@ -1,11 +1,60 @@
 package methods
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Tenant is a column of tenantColumn option: records are created, updated
+// and deleted only for tenant passed as tenantID argument. Zero Tenant is
+// no tenant.
+type Tenant struct {
+	Column    string
+	FieldName string
+	TypeName  string
+}
+
+// getArgsDeclaration returns declaration of tenantID argument after comma
+func (t Tenant) getArgsDeclaration() string {
+	if t.Column == "" {
+		return ""
+	}
+	return ", tenantID " + t.TypeName
+}
+
 // StructModifierMethod represents method, modifying current struct
 type StructModifierMethod struct {
 	namedMethod
 	structMethod
 	dbArgMethod
 	gormErroredMethod
+	preBody string
+	tenant  Tenant
+}
+
+// GetArgsDeclaration returns declaration of db and tenantID arguments
+func (m StructModifierMethod) GetArgsDeclaration() string {
+	return m.dbArgMethod.GetArgsDeclaration() + m.tenant.getArgsDeclaration()
+}
+
+// setTenant adds tenantID argument: struct is modified only if
+// it belongs to tenant
+func (m *StructModifierMethod) setTenant(t Tenant) {
+	m.tenant = t
+	if t.Column != "" {
+		m.gormVarName += fmt.Sprintf(`.Where("%s = ?", tenantID)`, t.Column)
+	}
+}
+
+// GetBody returns method's code
+func (m StructModifierMethod) GetBody() string {
+	const checkContext = `if err := base.CheckContext(db); err != nil {
+		return err
+	}
+	`
+	return checkContext + m.preBody + m.gormErroredMethod.GetBody()
 }
 
 // NewStructModifierMethod create StructModifierMethod method
@@ -14,7 +63,411 @@ func NewStructModifierMethod(name, structTypeName string) StructModifierMethod {
 		namedMethod:       newNamedMethod(name),
 		dbArgMethod:       newDbArgMethod(),
 		structMethod:      newStructMethod("o", "*"+structTypeName),
-		gormErroredMethod: newGormErroredMethod(name, "o", "db"),
+		gormErroredMethod: newGormErroredMethod(name, "o", "base.Primary(db)"),
+	}
+	return r
+}
+
+const checkPrimaryKey = `if err := base.CheckPrimaryKey(db, o); err != nil {
+	return err
+}
+`
+
+// NewStructDeleteMethod creates Delete method deleting struct by primary key
+// (and tenant): not set primary key is an error
+func NewStructDeleteMethod(structTypeName string, tenant Tenant) StructModifierMethod {
+	r := NewStructModifierMethod("Delete", structTypeName)
+	r.setTenant(tenant)
+	r.preBody = checkPrimaryKey
+	return r
+}
+
+// NewActiveFlagStructDeleteMethod creates Delete method for struct with active
+// flag column activeFlag of field fieldName: it's deleted by setting it to false
+func NewActiveFlagStructDeleteMethod(structTypeName, activeFlag, fieldName string,
+	tenant Tenant) StructModifierMethod {
+
+	r := NewStructModifierMethod("Delete", structTypeName)
+	r.gormErroredMethod = newGormErroredMethod("UpdateColumn",
+		fmt.Sprintf(`"%s", false`, activeFlag), "base.Primary(db)")
+	r.setTenant(tenant)
+	r.gormVarName += ".Model(o)"
+	r.preBody = checkPrimaryKey + fmt.Sprintf("o.%s = false\n", fieldName)
+	return r
+}
+
+// CreatePreparation describes changes and checks of record before its
+// creation by Create, {Struct}CreateBatch and {Struct}CreateFromChan
+type CreatePreparation struct {
+	// AutoTimeFieldNames are time fields set to current time if they are zero
+	AutoTimeFieldNames []string
+	// Validate is set if record is checked by Validate method
+	Validate bool
+	// ActiveFlagFieldName is a bool field of activeFlag option set to true
+	ActiveFlagFieldName string
+	// Tenant field is set to tenantID argument
+	Tenant Tenant
+}
+
+// getBody returns code preparing record o: errors are returned
+func (p CreatePreparation) getBody(o string) string {
+	body := []string{}
+	if p.Tenant.Column != "" {
+		body = append(body, fmt.Sprintf("%s.%s = tenantID", o, p.Tenant.FieldName))
+	}
+	if p.Validate {
+		body = append(body, fmt.Sprintf(`if err := %s.Validate(); err != nil {
+			return err
+		}`, o))
+	}
+	if len(p.AutoTimeFieldNames) != 0 {
+		body = append(body, "now := gorm.NowFunc()")
+	}
+	for _, f := range p.AutoTimeFieldNames {
+		body = append(body, fmt.Sprintf(`if %[1]s.%[2]s.IsZero() {
+			%[1]s.%[2]s = now
+		}`, o, f))
+	}
+	if p.ActiveFlagFieldName != "" {
+		// not active record is invisible for query sets
+		body = append(body, fmt.Sprintf("%s.%s = true", o, p.ActiveFlagFieldName))
+	}
+	if len(body) == 0 {
+		return ""
+	}
+	return strings.Join(body, "\n") + "\n"
+}
+
+// NewCreateMethod creates Create method preparing struct by p before creation
+func NewCreateMethod(structTypeName string, p CreatePreparation) StructModifierMethod {
+	r := NewStructModifierMethod("Create", structTypeName)
+	r.tenant = p.Tenant
+	r.preBody = p.getBody("o")
+	return r
+}
+
+// DiffFromDBMethod creates DiffFromDB method
+type DiffFromDBMethod struct {
+	namedMethod
+	structMethod
+	dbArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewDiffFromDBMethod creates DiffFromDB method comparing fields fieldNames
+func NewDiffFromDBMethod(structTypeName, dbSchemaFieldTypeName string,
+	fieldNames []string) DiffFromDBMethod {
+
+	body := []string{fmt.Sprintf(`var dbo %s
+	if err := base.ReloadByPK(db, o, &dbo); err != nil {
+		return nil, err
+	}
+
+	ret := []%s{}`, structTypeName, dbSchemaFieldTypeName)}
+	for _, f := range fieldNames {
+		body = append(body, fmt.Sprintf(`if !base.FieldsEqual(o.%s, dbo.%s) {
+			ret = append(ret, %sDBSchema.%s)
+		}`, f, f, structTypeName, f))
+	}
+	body = append(body, "return ret, nil")
+
+	r := DiffFromDBMethod{
+		namedMethod:     newNamedMethod("DiffFromDB"),
+		structMethod:    newStructMethod("o", "*"+structTypeName),
+		dbArgMethod:     newDbArgMethod(),
+		constRetMethod:  newConstRetMethod(fmt.Sprintf("([]%s, error)", dbSchemaFieldTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", strings.Join(body, "\n")),
+	}
+	r.setDoc(fmt.Sprintf(`// DiffFromDB reloads %s by primary key and returns fields
+	// having different values in o and in db`, structTypeName))
+	return r
+}
+
+// ValidateReferencesMethod creates ValidateReferences method
+type ValidateReferencesMethod struct {
+	namedMethod
+	structMethod
+	dbArgMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewValidateReferencesMethod creates ValidateReferences method checking
+// existence of records referenced by belongs-to associations
+func NewValidateReferencesMethod(structTypeName string) ValidateReferencesMethod {
+	r := ValidateReferencesMethod{
+		namedMethod:     newNamedMethod("ValidateReferences"),
+		structMethod:    newStructMethod("o", "*"+structTypeName),
+		dbArgMethod:     newDbArgMethod(),
+		constRetMethod:  newConstRetMethod("error"),
+		constBodyMethod: newConstBodyMethod("return base.ValidateReferences(db, o)"),
+	}
+	r.setDoc(fmt.Sprintf(`// ValidateReferences checks that records referenced by set foreign keys
+	// of belongs-to associations of %s exist, e.g. before Create to return
+	// error naming missing reference instead of constraint violation`, structTypeName))
+	return r
+}
+
+// PreloadForMethod creates Preload{Field}For{Struct} func loading
+// has-many association for slice of already loaded structs
+type PreloadForMethod struct {
+	namedMethod
+	receiverMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewPreloadForMethod creates Preload{Field}For{Struct} func. Records of
+// elemTypeName reference struct by {Struct}ID field of type pkTypeName
+func NewPreloadForMethod(structTypeName, pkTypeName, fieldName, elemTypeName string) PreloadForMethod {
+	argName := LowercaseFirstRune(structTypeName) + "s"
+	fkName := structTypeName + "ID"
+	body := fmt.Sprintf(`if len(%[1]s) == 0 {
+		return nil
+	}
+
+	ids := make([]%[2]s, 0, len(%[1]s))
+	idxs := map[%[2]s][]int{}
+	for i := range %[1]s {
+		id := %[1]s[i].ID
+		if _, ok := idxs[id]; !ok {
+			ids = append(ids, id)
+		}
+		idxs[id] = append(idxs[id], i)
+		%[1]s[i].%[3]s = nil
+	}
+
+	var elems []%[4]s
+	if err := db.Where("%[5]s IN (?)", ids).Find(&elems).Error; err != nil {
+		return fmt.Errorf("can't preload %[3]s for %[6]s: %%s", err)
+	}
+
+	for _, e := range elems {
+		for _, i := range idxs[e.%[7]s] {
+			%[1]s[i].%[3]s = append(%[1]s[i].%[3]s, e)
+		}
+	}
+	return nil`, argName, pkTypeName, fieldName, elemTypeName,
+		gorm.ToDBName(fkName), structTypeName, fkName)
+
+	r := PreloadForMethod{
+		namedMethod:     newNamedMethod(fmt.Sprintf("Preload%sFor%s", fieldName, structTypeName)),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf("db *gorm.DB, %s []%s", argName, structTypeName)),
+		constBodyMethod: newConstBodyMethod("%s", body),
+	}
+	r.setDoc(fmt.Sprintf(`// %s loads %s of all %s by one query:
+	// it's like preloading, but for already loaded records`, r.GetMethodName(), fieldName, argName))
+	return r
+}
+
+// ValidateMethod creates Validate method
+type ValidateMethod struct {
+	namedMethod
+	structMethod
+	noArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// EnumField is a field of enum type: values of pointer fields
+// are validated only if they aren't nil
+type EnumField struct {
+	Name      string
+	IsPointer bool
+}
+
+// NewValidateMethod creates Validate method checking values of enum fields
+func NewValidateMethod(structTypeName string, enumFields []EnumField) ValidateMethod {
+	body := []string{}
+	for _, f := range enumFields {
+		value := "o." + f.Name
+		if f.IsPointer {
+			value = "*" + value
+		}
+		check := fmt.Sprintf(`if err := base.ValidateEnum("%s", %s, string(%s)); err != nil {
+			return err
+		}`, getEnumFieldLabel(structTypeName, f.Name),
+			GetEnumMembersVarName(structTypeName, f.Name), value)
+		if f.IsPointer {
+			check = fmt.Sprintf("if o.%s != nil {\n%s\n}", f.Name, check)
+		}
+		body = append(body, check)
+	}
+	body = append(body, "return nil")
+
+	r := ValidateMethod{
+		namedMethod:     newNamedMethod("Validate"),
+		structMethod:    newStructMethod("o", "*"+structTypeName),
+		constBodyMethod: newConstBodyMethod("%s", strings.Join(body, "\n")),
+	}
+	r.setDoc(`// Validate checks that values of enum fields are members of enums:
+	// it's called before creation and updates`)
+	return r
+}
+
+// VerifySchemaMethod creates Verify{Struct}Schema func
+type VerifySchemaMethod struct {
+	namedMethod
+	receiverMethod
+	constArgsMethod
+	errorRetMethod
+	constBodyMethod
+}
+
+// NewVerifySchemaMethod creates Verify{Struct}Schema func checking columns:
+// columnKinds are kinds ("string", "numeric", "bool", "time" or empty
+// for any kind) of columns columnNames
+func NewVerifySchemaMethod(structTypeName string, columnNames, columnKinds []string) VerifySchemaMethod {
+	kindConsts := map[string]string{
+		"string":  "base.ColumnKindString",
+		"numeric": "base.ColumnKindNumeric",
+		"bool":    "base.ColumnKindBool",
+		"time":    "base.ColumnKindTime",
+	}
+	columns := []string{}
+	for i, c := range columnNames {
+		kind := kindConsts[columnKinds[i]]
+		if kind == "" {
+			kind = `""` // any kind
+		}
+		columns = append(columns, fmt.Sprintf("%q: %s,", c, kind))
+	}
+
+	r := VerifySchemaMethod{
+		namedMethod:     newNamedMethod(fmt.Sprintf("Verify%sSchema", structTypeName)),
+		constArgsMethod: newConstArgsMethod("db *gorm.DB"),
+		constBodyMethod: newConstBodyMethod(`return base.VerifySchema(db, &%s{}, map[string]string{
+			%s
+		})`, structTypeName, strings.Join(columns, "\n")),
+	}
+	r.setDoc(fmt.Sprintf(`// %s checks that table of %s has all columns of model
+	// with compatible types, e.g. to detect forgotten migrations on startup`,
+		r.GetMethodName(), structTypeName))
+	return r
+}
+
+// SchemaJSONMethod creates {Struct}SchemaJSON func
+type SchemaJSONMethod struct {
+	namedMethod
+	receiverMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewSchemaJSONMethod creates {Struct}SchemaJSON func returning
+// schemaJSON built on generation
+func NewSchemaJSONMethod(structTypeName, schemaJSON string) SchemaJSONMethod {
+	r := SchemaJSONMethod{
+		namedMethod:     newNamedMethod(fmt.Sprintf("%sSchemaJSON", structTypeName)),
+		constRetMethod:  newConstRetMethod("[]byte"),
+		constBodyMethod: newConstBodyMethod("return []byte(`%s`)", schemaJSON),
+	}
+	r.setDoc(fmt.Sprintf(`// %s returns JSON with fields of %s: their names,
+	// columns, types, nullability and primary key flags, e.g. for admin UIs`,
+		r.GetMethodName(), structTypeName))
+	return r
+}
+
+// CreateFromChanMethod creates {Struct}CreateFromChan func
+type CreateFromChanMethod struct {
+	namedMethod
+	receiverMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCreateFromChanMethod creates {Struct}CreateFromChan func preparing
+// every record by p like Create
+func NewCreateFromChanMethod(structTypeName string, p CreatePreparation) CreateFromChanMethod {
+	prepare := "nil"
+	if body := p.getBody("o"); body != "" {
+		prepare = fmt.Sprintf(`func(record interface{}) error {
+			o := record.(*%s)
+			%sreturn nil
+		}`, structTypeName, body)
+	}
+
+	args := fmt.Sprintf("db *gorm.DB%s, ch <-chan %s, batchSize int",
+		p.Tenant.getArgsDeclaration(), structTypeName)
+	r := CreateFromChanMethod{
+		namedMethod:     newNamedMethod(fmt.Sprintf("%sCreateFromChan", structTypeName)),
+		constArgsMethod: newConstArgsMethod(args),
+		constRetMethod:  newConstRetMethod("(int64, error)"),
+		constBodyMethod: newConstBodyMethod("return base.CreateFromChan(db, ch, batchSize, %s)", prepare),
+	}
+	r.setDoc(fmt.Sprintf(`// %s creates %s records received from ch by bulk
+	// INSERTs of up to batchSize records until ch is closed, e.g. for streaming
+	// ingestion, and returns count of created records. Records are validated and
+	// their auto time fields are set like by Create, but hooks aren't called and
+	// IDs of created records aren't set`, r.GetMethodName(), structTypeName))
+	return r
+}
+
+// CreateBatchMethod creates {Struct}CreateBatch func
+type CreateBatchMethod struct {
+	namedMethod
+	receiverMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewCreateBatchMethod creates {Struct}CreateBatch func preparing
+// all records by p like Create before the first INSERT
+func NewCreateBatchMethod(structTypeName string, p CreatePreparation) CreateBatchMethod {
+	body := "return base.CreateBatch(db, records)"
+	if prepare := p.getBody("o"); prepare != "" {
+		body = fmt.Sprintf(`for i := range records {
+			o := &records[i]
+			%s}
+		%s`, prepare, body)
+	}
+
+	args := fmt.Sprintf("db *gorm.DB%s, records []%s", p.Tenant.getArgsDeclaration(), structTypeName)
+	r := CreateBatchMethod{
+		namedMethod:     newNamedMethod(fmt.Sprintf("%sCreateBatch", structTypeName)),
+		constArgsMethod: newConstArgsMethod(args),
+		constRetMethod:  newConstRetMethod("error"),
+		constBodyMethod: newConstBodyMethod("%s", body),
+	}
+	r.setDoc(fmt.Sprintf(`// %s creates %s records by multi-row INSERTs split
+	// by base.MaxBatchVars in one transaction. Records are validated and their
+	// auto time fields are set like by Create before the first INSERT.
+	// Auto-increment IDs are set only in PostgreSQL (by RETURNING), they stay
+	// zero in other databases. Hooks aren't called`,
+		r.GetMethodName(), structTypeName))
+	return r
+}
+
+// KeysetCursorMethod creates KeysetCursor method
+type KeysetCursorMethod struct {
+	namedMethod
+	structMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewKeysetCursorMethod creates KeysetCursor method encoding values
+// of sort keys of struct
+func NewKeysetCursorMethod(structTypeName, dbSchemaFieldTypeName string) KeysetCursorMethod {
+	r := KeysetCursorMethod{
+		namedMethod:     newNamedMethod("KeysetCursor"),
+		structMethod:    newStructMethod("o", "*"+structTypeName),
+		constArgsMethod: newConstArgsMethod(fmt.Sprintf("desc bool, fields ...%s", dbSchemaFieldTypeName)),
+		constRetMethod:  newConstRetMethod("(string, error)"),
+		constBodyMethod: newConstBodyMethod(`columns := make([]string, 0, len(fields))
+		for _, f := range fields {
+			columns = append(columns, string(f))
+		}
+		return base.EncodeKeysetCursor(o, desc, columns)`),
 	}
+	r.setDoc(`// KeysetCursor returns opaque cursor of o as the last record of page
+	// ordered by fields, descending if desc is set: pass it to ContinueAfter
+	// to select the next page`)
 	return r
 }
@@ -1,5 +1,13 @@
 package methods
 
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
 // baseUpdaterMethod
 
 type baseUpdaterMethod struct {
@@ -49,6 +57,21 @@ func NewUpdaterSetMethod(fieldName, fieldTypeName,
 	return r
 }
 
+// NewEnumUpdaterSetMethod creates SetField method for enum field: invalid
+// value is an error of Update
+func NewEnumUpdaterSetMethod(structTypeName, fieldName, fieldTypeName,
+	updaterTypeName, dbSchemaTypeName string) UpdaterSetMethod {
+
+	r := NewUpdaterSetMethod(fieldName, fieldTypeName, updaterTypeName, dbSchemaTypeName)
+	r.constBodyMethod = newConstBodyMethod(`u.db = base.CheckEnum(u.db, "%s", %s, string(%s))
+		u.fields[string(%s.%s)] = %s
+		return u`,
+		getEnumFieldLabel(structTypeName, fieldName),
+		GetEnumMembersVarName(structTypeName, fieldName), r.getArgName(),
+		dbSchemaTypeName, fieldName, r.getArgName())
+	return r
+}
+
 // UpdaterUpdateMethod creates Update method
 type UpdaterUpdateMethod struct {
 	namedMethod
@@ -58,11 +81,136 @@ type UpdaterUpdateMethod struct {
 	constBodyMethod
 }
 
-// NewUpdaterUpdateMethod create new Update method
-func NewUpdaterUpdateMethod(updaterTypeName string) UpdaterUpdateMethod {
+// NewUpdaterUpdateMethod create new Update method. Fields from
+// autoTimeFieldNames are set to current time on every update
+func NewUpdaterUpdateMethod(updaterTypeName, dbSchemaTypeName string,
+	autoTimeFieldNames []string) UpdaterUpdateMethod {
+
+	body := getUpdaterPrepareBody("err", dbSchemaTypeName, autoTimeFieldNames) +
+		`return base.Retry(u.db, func() error {
+		return u.db.Updates(u.fields).Error
+	})`
+
 	return UpdaterUpdateMethod{
 		namedMethod:       newNamedMethod("Update"),
 		baseUpdaterMethod: newBaseUpdaterMethod(updaterTypeName),
-		constBodyMethod:   newConstBodyMethod("return u.db.Updates(u.fields).Error"),
+		constBodyMethod:   newConstBodyMethod("%s", body),
+	}
+}
+
+// UpdaterUpdateNumMethod creates UpdateNum method
+type UpdaterUpdateNumMethod struct {
+	namedMethod
+	baseUpdaterMethod
+	noArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// NewUpdaterUpdateNumMethod creates UpdateNum method returning count
+// of updated records. Fields from autoTimeFieldNames are set to current
+// time on every update
+func NewUpdaterUpdateNumMethod(updaterTypeName, dbSchemaTypeName string,
+	autoTimeFieldNames []string) UpdaterUpdateNumMethod {
+
+	body := getUpdaterPrepareBody("0, err", dbSchemaTypeName, autoTimeFieldNames) +
+		`var n int64
+	err := base.Retry(u.db, func() error {
+		res := u.db.Updates(u.fields)
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err`
+
+	r := UpdaterUpdateNumMethod{
+		namedMethod:       newNamedMethod("UpdateNum"),
+		baseUpdaterMethod: newBaseUpdaterMethod(updaterTypeName),
+		constRetMethod:    newConstRetMethod("(int64, error)"),
+		constBodyMethod:   newConstBodyMethod("%s", body),
+	}
+	r.setDoc(`// UpdateNum is like Update, but returns count of updated records:
+	// it's 0 if guard conditions (e.g. of WhereFieldGte) aren't met`)
+	return r
+}
+
+// getUpdaterPrepareBody returns code checking context of updater and setting
+// fields autoTimeFieldNames to current time: errors are returned by errRet
+func getUpdaterPrepareBody(errRet, dbSchemaTypeName string, autoTimeFieldNames []string) string {
+	body := fmt.Sprintf(`if err := base.CheckContext(u.db); err != nil {
+		return %s
+	}
+	`, errRet)
+	if len(autoTimeFieldNames) != 0 {
+		body += "now := gorm.NowFunc()\n"
+		for _, f := range autoTimeFieldNames {
+			body += fmt.Sprintf("u.fields[string(%s.%s)] = now\n", dbSchemaTypeName, f)
+		}
+	}
+	return body
+}
+
+// NewUpdaterIncrementMethod creates Increment{Field} method or Decrement{Field}
+// method if decrement is set: field is changed by n relatively to its value
+func NewUpdaterIncrementMethod(fieldName, fieldTypeName, updaterTypeName,
+	dbSchemaTypeName string, decrement bool) UpdaterSetMethod {
+
+	prefix, op := "Increment", "+"
+	if decrement {
+		prefix, op = "Decrement", "-"
+	}
+	r := UpdaterSetMethod{
+		onFieldMethod:     newOnFieldMethod(prefix, fieldName),
+		oneArgMethod:      newOneArgMethod("n", fieldTypeName),
+		baseUpdaterMethod: newBaseUpdaterMethod(updaterTypeName),
+		constRetMethod:    newConstRetMethod(updaterTypeName),
+		constBodyMethod: newConstBodyMethod(`u.fields[string(%s.%s)] = gorm.Expr(string(%s.%s)+" %s ?", n)
+		return u`, dbSchemaTypeName, fieldName, dbSchemaTypeName, fieldName, op),
+		dbSchemaTypeName: dbSchemaTypeName,
 	}
+	r.setFieldNameFirst(false)
+	r.setDoc(fmt.Sprintf(`// %s sets %s to %s %s n atomically`,
+		r.GetMethodName(), fieldName, gorm.ToDBName(fieldName), op))
+	return r
+}
+
+// UpdaterWhereMethod creates WhereField{Op} method
+type UpdaterWhereMethod struct {
+	namedMethod
+	baseUpdaterMethod
+	constArgsMethod
+	constRetMethod
+	constBodyMethod
+}
+
+// updaterWhereExamples are examples of use of guard conditions of update
+// by filter operators
+var updaterWhereExamples = map[string]string{
+	"eq":  "to update record only if it's still in expected state,\n// e.g. optimistic locking by version",
+	"ne":  "to skip records already having the new value,\n// so UpdateNum counts only changed ones",
+	"lt":  "to increment counter only while it's below limit",
+	"lte": "to raise value only while it doesn't exceed cap",
+	"gt":  "to decrement balance only while it stays positive",
+	"gte": "to decrement stock only if it's sufficient",
+}
+
+// NewUpdaterWhereMethod creates WhereField{Op} method adding guard condition
+// of update for binary filter operator name, e.g. "gte"
+func NewUpdaterWhereMethod(updaterTypeName, dbSchemaFieldTypeName, name string) UpdaterWhereMethod {
+	op := filterOperators[name]
+	if op == "" {
+		log.Fatalf("no operation for filter %q", name)
+	}
+
+	methodName := "WhereField" + strings.Title(name)
+	r := UpdaterWhereMethod{
+		namedMethod:       newNamedMethod(methodName),
+		baseUpdaterMethod: newBaseUpdaterMethod(updaterTypeName),
+		constArgsMethod:   newConstArgsMethod(fmt.Sprintf("field %s, value interface{}", dbSchemaFieldTypeName)),
+		constRetMethod:    newConstRetMethod(updaterTypeName),
+		constBodyMethod: newConstBodyMethod(`u.db = u.db.Where(string(field)+" %s ?", value)
+		return u`, op),
+	}
+	r.setDoc(fmt.Sprintf(`// %s adds guard condition field %s value to WHERE of update:
+	// use it %s`, methodName, op, updaterWhereExamples[name]))
+	return r
 }
@@ -6,31 +6,35 @@ import (
 	"go/ast"
 	"go/types"
 	"io"
+	"reflect"
 	"sort"
 	"strings"
 	"text/template"
 
 	"golang.org/x/tools/go/loader"
 
-	"github.com/jinzhu/gorm"
 	"github.com/jirfag/go-queryset/parser"
 	"github.com/jirfag/go-queryset/queryset/methods"
 )
 
-var qsTmpl = template.Must(
-	template.New("generator").
-		Funcs(template.FuncMap{
-			"lcf":      methods.LowercaseFirstRune,
-			"todbname": gorm.ToDBName,
-		}).
-		Parse(qsCode),
-)
+// methodsTmpl emits methods of query set config: they are emitted the same
+// way for all backends
+var methodsTmpl = template.Must(template.New("methods").Parse(`
+	{{ range .Methods }}
+		{{ .GetDoc .GetMethodName }}
+		func {{ with .GetReceiverDeclaration }}({{ . }}) {{ end }}{{ .GetMethodName }}({{ .GetArgsDeclaration }})
+		{{- .GetReturnValuesDeclaration }} {
+      {{ .GetBody }}
+		}
+	{{ end }}
+`))
 
 type querySetStructConfig struct {
 	StructName string
 	Name       string
 	Methods    methodsSlice
 	Fields     []parser.StructField
+	Info       StructInfo
 }
 
 type methodsSlice []methods.Method
@@ -49,58 +53,34 @@ func (s querySetStructConfigSlice) Less(i, j int) bool {
 }
 func (s querySetStructConfigSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-type baseFieldInfo struct {
-	name      string // name of field
-	typeName  string // name of type of field
-	isStruct  bool
-	isNumeric bool
+// BaseFieldInfo is a result of analysis of struct field type
+type BaseFieldInfo struct {
+	Name      string // name of field
+	TypeName  string // name of type of field
+	IsStruct  bool
+	IsNumeric bool
+	IsInteger bool
+	IsString  bool
+	IsTime    bool
 }
 
-type fieldInfo struct {
-	pointed *baseFieldInfo
-	baseFieldInfo
-	isPointer bool
+// FieldInfo is a result of analysis of struct field
+type FieldInfo struct {
+	pointed *BaseFieldInfo
+	BaseFieldInfo
+	IsPointer bool
+	Tag       reflect.StructTag
 }
 
-func (fi fieldInfo) getPointed() fieldInfo {
-	return fieldInfo{
-		baseFieldInfo: *fi.pointed,
+// GetPointed returns info about type pointed by pointer field
+func (fi FieldInfo) GetPointed() FieldInfo {
+	return FieldInfo{
+		BaseFieldInfo: *fi.pointed,
+		Tag:           fi.Tag,
 	}
 }
 
-func getQuerySetMethodsForField(f fieldInfo, qsTypeName string) []methods.Method {
-	basicTypeMethods := []methods.Method{
-		methods.NewBinaryFilterMethod("eq", f.name, f.typeName, qsTypeName),
-		methods.NewBinaryFilterMethod("ne", f.name, f.typeName, qsTypeName),
-	}
-	numericMethods := []methods.Method{
-		methods.NewBinaryFilterMethod("lt", f.name, f.typeName, qsTypeName),
-		methods.NewBinaryFilterMethod("gt", f.name, f.typeName, qsTypeName),
-		methods.NewBinaryFilterMethod("lte", f.name, f.typeName, qsTypeName),
-		methods.NewBinaryFilterMethod("gte", f.name, f.typeName, qsTypeName),
-		methods.NewOrderAscByMethod(f.name, qsTypeName),
-		methods.NewOrderDescByMethod(f.name, qsTypeName),
-	}
-
-	if f.isNumeric {
-		return append(basicTypeMethods, numericMethods...)
-	}
-
-	if f.isStruct {
-		// Association was found (any struct or struct pointer)
-		return []methods.Method{methods.NewPreloadMethod(f.name, qsTypeName)}
-	}
-
-	if f.isPointer {
-		ptrMethods := getQuerySetMethodsForField(f.getPointed(), qsTypeName)
-		return append(ptrMethods, methods.NewIsNullMethod(f.name, qsTypeName))
-	}
-
-	// it's a string
-	return basicTypeMethods
-}
-
-func generateFieldInfo(pkgInfo *loader.PackageInfo, name string, typ fmt.Stringer, originalTypeName string) *fieldInfo {
+func generateFieldInfo(pkgInfo *loader.PackageInfo, name string, typ fmt.Stringer, originalTypeName string) *FieldInfo {
 	typeName := typ.String()
 	if originalTypeName != "" {
 		// it's needed to preserver typedef's original name
@@ -109,11 +89,13 @@ func generateFieldInfo(pkgInfo *loader.PackageInfo, name string, typ fmt.Stringe
 
 	switch t := typ.(type) {
 	case *types.Basic:
-		return &fieldInfo{
-			baseFieldInfo: baseFieldInfo{
-				name:      name,
-				typeName:  typeName,
-				isNumeric: t.Info()&types.IsNumeric != 0,
+		return &FieldInfo{
+			BaseFieldInfo: BaseFieldInfo{
+				Name:      name,
+				TypeName:  typeName,
+				IsNumeric: t.Info()&types.IsNumeric != 0,
+				IsInteger: t.Info()&types.IsInteger != 0,
+				IsString:  t.Info()&types.IsString != 0,
 			},
 		}
 	case *types.Named:
@@ -125,31 +107,32 @@ func generateFieldInfo(pkgInfo *loader.PackageInfo, name string, typ fmt.Stringe
 		return generateFieldInfo(pkgInfo, name, t.Underlying(), otn)
 	case *types.Struct:
 		if typeName == "time.Time" {
-			return &fieldInfo{
-				baseFieldInfo: baseFieldInfo{
-					name:      name,
-					typeName:  typeName,
-					isNumeric: true,
+			return &FieldInfo{
+				BaseFieldInfo: BaseFieldInfo{
+					Name:      name,
+					TypeName:  typeName,
+					IsNumeric: true,
+					IsTime:    true,
 				},
 			}
 		}
 
-		return &fieldInfo{
-			baseFieldInfo: baseFieldInfo{
-				name:     name,
-				typeName: typeName,
-				isStruct: true,
+		return &FieldInfo{
+			BaseFieldInfo: BaseFieldInfo{
+				Name:     name,
+				TypeName: typeName,
+				IsStruct: true,
 			},
 		}
 	case *types.Pointer:
 		pf := generateFieldInfo(pkgInfo, name, t.Elem(), "")
-		return &fieldInfo{
-			baseFieldInfo: baseFieldInfo{
-				name:     name,
-				typeName: typeName,
+		return &FieldInfo{
+			BaseFieldInfo: BaseFieldInfo{
+				Name:     name,
+				TypeName: typeName,
 			},
-			isPointer: true,
-			pointed:   &pf.baseFieldInfo,
+			IsPointer: true,
+			pointed:   &pf.BaseFieldInfo,
 		}
 	default:
 		// no filtering is needed
@@ -157,222 +140,240 @@ func generateFieldInfo(pkgInfo *loader.PackageInfo, name string, typ fmt.Stringe
 	}
 }
 
-func getQuerySetFieldMethods(fields []fieldInfo, qsTypeName string) []methods.Method {
-	ret := []methods.Method{}
-	for _, f := range fields {
-		methods := getQuerySetMethodsForField(f, qsTypeName)
-		ret = append(ret, methods...)
+// parseQuerySetAnnotation finds "gen:qs [option[:value]...]" annotation in
+// doc and returns its options
+func parseQuerySetAnnotation(doc *ast.CommentGroup) (opts map[string]string, ok bool) {
+	if doc == nil {
+		return nil, false
 	}
 
-	return ret
-}
+	for _, c := range doc.List {
+		parts := strings.SplitN(strings.TrimSpace(c.Text), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(strings.TrimPrefix(parts[0], "//")) != "gen" {
+			continue
+		}
+
+		words := strings.Fields(parts[1])
+		if len(words) == 0 || words[0] != "qs" {
+			continue
+		}
 
-func getUpdaterTypeName(structTypeName string) string {
-	return structTypeName + "Updater"
+		opts = map[string]string{}
+		for _, w := range words[1:] {
+			kv := strings.SplitN(w, ":", 2)
+			if len(kv) == 2 {
+				opts[kv[0]] = kv[1]
+			} else {
+				opts[kv[0]] = ""
+			}
+		}
+		return opts, true
+	}
+
+	return nil, false
 }
 
-func getUpdaterMethods(fields []fieldInfo, structTypeName string) []methods.Method {
-	updaterTypeName := getUpdaterTypeName(structTypeName)
-	ret := []methods.Method{methods.NewUpdaterUpdateMethod(updaterTypeName)}
-	for _, f := range fields {
-		if f.isPointer {
-			// TODO
+// getHasManyInfos finds fields of struct ps which are slices of parsed
+// structs referencing it by {structTypeName}ID field
+func getHasManyInfos(pkgInfo *loader.PackageInfo, structTypeName string,
+	ps parser.ParsedStruct, structs parser.ParsedStructs) []HasManyInfo {
+
+	ret := []HasManyInfo{}
+	for _, f := range ps.Fields {
+		s, ok := f.Type.(*types.Slice)
+		if !ok {
+			continue
+		}
+		elem, ok := s.Elem().(*types.Named)
+		if !ok || elem.Obj().Pkg() != pkgInfo.Pkg {
 			continue
 		}
-		dbSchemaTypeName := structTypeName + "DBSchema"
-		ret = append(ret,
-			methods.NewUpdaterSetMethod(f.name, f.typeName, updaterTypeName,
-				dbSchemaTypeName))
+		elemStruct, ok := structs[elem.Obj().Name()]
+		if !ok {
+			continue
+		}
+		for _, ef := range elemStruct.Fields {
+			if ef.Name == structTypeName+"ID" {
+				ret = append(ret, HasManyInfo{
+					Name:         f.Name,
+					ElemTypeName: elem.Obj().Name(),
+				})
+				break
+			}
+		}
 	}
+
 	return ret
 }
 
-func getMethodsForStruct(structTypeName string, fieldInfos []fieldInfo) []methods.Method {
-	qsTypeName := structTypeName + "QuerySet"
-
-	ret := []methods.Method{
-		methods.NewLimitMethod(qsTypeName),
-		methods.NewAllMethod(structTypeName, qsTypeName),
-		methods.NewOneMethod(structTypeName, qsTypeName),
-		methods.NewGetUpdaterMethod(qsTypeName, getUpdaterTypeName(structTypeName)),
-		methods.NewDeleteMethod(qsTypeName, structTypeName),
-		methods.NewStructModifierMethod("Create", structTypeName),
-		methods.NewStructModifierMethod("Delete", structTypeName),
-	}
-
-	fieldMethods := getQuerySetFieldMethods(fieldInfos, qsTypeName)
-	ret = append(ret, fieldMethods...)
+// getSliceAssociationNames finds fields of struct ps which are slices
+// of parsed structs or pointers to them
+func getSliceAssociationNames(pkgInfo *loader.PackageInfo, ps parser.ParsedStruct,
+	structs parser.ParsedStructs) []string {
 
-	ret = append(ret, getUpdaterMethods(fieldInfos, structTypeName)...)
+	ret := []string{}
+	for _, f := range ps.Fields {
+		if isExcludedField(f.Tag) {
+			continue
+		}
+		s, ok := f.Type.(*types.Slice)
+		if !ok {
+			continue
+		}
+		elemType := s.Elem()
+		if p, ok := elemType.(*types.Pointer); ok {
+			elemType = p.Elem()
+		}
+		elem, ok := elemType.(*types.Named)
+		if !ok || elem.Obj().Pkg() != pkgInfo.Pkg {
+			continue
+		}
+		if _, ok := structs[elem.Obj().Name()]; ok {
+			ret = append(ret, f.Name)
+		}
+	}
 
 	return ret
 }
 
-func doesNeedToGenerateQuerySet(doc *ast.CommentGroup) bool {
-	if doc == nil {
-		return false
+func getStructInfo(structTypeName string, fieldInfos []FieldInfo,
+	opts map[string]string) (*StructInfo, error) {
+
+	s := StructInfo{
+		Name:   structTypeName,
+		Fields: fieldInfos,
+	}
+	if _, _, err := getRangeFields(fieldInfos); err != nil {
+		return nil, fmt.Errorf("invalid range of struct %s: %s", structTypeName, err)
+	}
+	if _, _, err := getPointFields(fieldInfos); err != nil {
+		return nil, fmt.Errorf("invalid point of struct %s: %s", structTypeName, err)
+	}
+	if _, _, err := getDeleteAuditFields(fieldInfos); err != nil {
+		return nil, fmt.Errorf("invalid delete audit of struct %s: %s", structTypeName, err)
+	}
+	if _, _, err := getClaimFields(fieldInfos); err != nil {
+		return nil, fmt.Errorf("invalid claim fields of struct %s: %s", structTypeName, err)
+	}
+	if _, _, err := getScheduleFields(fieldInfos); err != nil {
+		return nil, fmt.Errorf("invalid schedule fields of struct %s: %s", structTypeName, err)
 	}
 
-	for _, c := range doc.List {
-		parts := strings.Split(strings.TrimSpace(c.Text), ":")
-		ok := len(parts) == 2 &&
-			strings.TrimSpace(strings.TrimPrefix(parts[0], "//")) == "gen" &&
-			strings.TrimSpace(parts[1]) == "qs"
-		if ok {
-			return true
+	for name := range opts {
+		switch name {
+		case "readOnly":
+			s.ReadOnly = true
+		case "wrapErrors":
+			s.WrapErrors = true
+		case "sqlComments":
+			s.SQLComments = true
+		case "activeFlag":
+			f := s.getFieldByDBName(opts[name])
+			if f == nil || f.TypeName != "bool" {
+				return nil, fmt.Errorf("no bool field with db name %q for activeFlag of struct %s",
+					opts[name], structTypeName)
+			}
+			s.ActiveFlagField = f
+		case "tenantColumn":
+			f := s.getFieldByDBName(opts[name])
+			if f == nil || f.IsStruct || f.IsPointer {
+				return nil, fmt.Errorf("no field with db name %q for tenantColumn of struct %s",
+					opts[name], structTypeName)
+			}
+			s.TenantField = f
+		default:
+			return nil, fmt.Errorf("unknown gen:qs option %q of struct %s", name, structTypeName)
 		}
 	}
 
-	return false
+	// structs are updated and deleted by primary key: GORM updates and
+	// deletes all records without it
+	if !s.ReadOnly && getPrimaryKeyFields(fieldInfos) == nil {
+		return nil, fmt.Errorf("no primary key of struct %s: ID field or fields "+
+			`tagged by gorm:"primary_key" are required`, structTypeName)
+	}
+
+	return &s, nil
 }
 
-func generateQuerySetConfigs(pkgInfo *loader.PackageInfo,
-	structs parser.ParsedStructs) querySetStructConfigSlice {
+func generateQuerySetConfigs(b Backend, pkgInfo *loader.PackageInfo,
+	structs parser.ParsedStructs) (querySetStructConfigSlice, error) {
 
 	querySetStructConfigs := querySetStructConfigSlice{}
 
 	for structTypeName, ps := range structs {
-		if !doesNeedToGenerateQuerySet(ps.Doc) {
+		opts, ok := parseQuerySetAnnotation(ps.Doc)
+		if !ok {
 			continue
 		}
 
-		fieldInfos := []fieldInfo{}
+		fields := []parser.StructField{}
+		fieldInfos := []FieldInfo{}
 		for _, f := range ps.Fields {
+			if isExcludedField(f.Tag) {
+				continue
+			}
+			fields = append(fields, f)
+
 			fi := generateFieldInfo(pkgInfo, f.Name, f.Type, "")
 			if fi == nil {
 				continue
 			}
+			fi.Tag = f.Tag
 			fieldInfos = append(fieldInfos, *fi)
 		}
 
-		methods := getMethodsForStruct(structTypeName, fieldInfos)
+		s, err := getStructInfo(structTypeName, fieldInfos, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.HasMany = getHasManyInfos(pkgInfo, structTypeName, ps, structs)
+		s.SliceAssociations = getSliceAssociationNames(pkgInfo, ps, structs)
 
 		qsConfig := querySetStructConfig{
 			StructName: structTypeName,
 			Name:       structTypeName + "QuerySet",
-			Methods:    methods,
-			Fields:     ps.Fields,
+			Methods:    b.GetMethods(*s),
+			Fields:     fields,
+			Info:       *s,
 		}
 		sort.Sort(qsConfig.Methods)
 		querySetStructConfigs = append(querySetStructConfigs, qsConfig)
 	}
 
-	return querySetStructConfigs
+	return querySetStructConfigs, nil
 }
 
 // GenerateQuerySetsForStructs is an internal method to retrieve querysets
 // generated code from parsed structs
 func GenerateQuerySetsForStructs(pkgInfo *loader.PackageInfo, structs parser.ParsedStructs) (io.Reader, error) {
+	return GenerateQuerySetsForStructsWithBackend(GormBackend{}, pkgInfo, structs)
+}
 
-	querySetStructConfigs := generateQuerySetConfigs(pkgInfo, structs)
-	if len(querySetStructConfigs) == 0 {
-		return nil, nil
-	}
-
-	sort.Sort(querySetStructConfigs)
-
-	var b bytes.Buffer
-	err := qsTmpl.Execute(&b, struct {
-		Configs querySetStructConfigSlice
-	}{
-		Configs: querySetStructConfigs,
-	})
+// GenerateQuerySetsForStructsWithBackend is like GenerateQuerySetsForStructs,
+// but generates code targeting backend b instead of GORM
+func GenerateQuerySetsForStructsWithBackend(b Backend, pkgInfo *loader.PackageInfo,
+	structs parser.ParsedStructs) (io.Reader, error) {
 
+	querySetStructConfigs, err := generateQuerySetConfigs(b, pkgInfo, structs)
 	if err != nil {
-		return nil, fmt.Errorf("can't generate structs query sets: %s", err)
+		return nil, err
 	}
-
-	return &b, nil
-}
-
-const qsCode = `
-// ===== BEGIN of all query sets
-
-{{ range .Configs }}
-  // ===== BEGIN of query set {{ .Name }}
-
-	// {{ .Name }} is an queryset type for {{ .StructName }}
-  type {{ .Name }} struct {
-	  db *gorm.DB
-  }
-
-  // New{{ .Name }} constructs new {{ .Name }}
-  func New{{ .Name }}(db *gorm.DB) {{ .Name }} {
-	  return {{ .Name }}{
-		  db: db,
-	  }
-  }
-
-	func (qs {{ .Name }}) w(db *gorm.DB) {{ .Name }} {
-	  return New{{ .Name }}(db)
-  }
-
-	{{ range .Methods }}
-		{{ .GetDoc .GetMethodName }}
-		func ({{ .GetReceiverDeclaration }}) {{ .GetMethodName }}({{ .GetArgsDeclaration }})
-		{{- .GetReturnValuesDeclaration }} {
-      {{ .GetBody }}
-		}
-	{{ end }}
-
-  // ===== END of query set {{ .Name }}
-
-	// ===== BEGIN of {{ .StructName }} modifiers
-
-	{{ $ft := printf "%s%s" .StructName "DBSchemaField" | lcf }}
-	type {{ $ft }} string
-
-	// {{ .StructName }}DBSchema stores db field names of {{ .StructName }}
-	var {{ .StructName }}DBSchema = struct {
-		{{ range .Fields }}
-			{{ .Name }} {{ $ft }}
-		{{- end }}
-	}{
-		{{ range .Fields }}
-			{{ .Name }}: {{ $ft }}("{{ .Name | todbname }}"),
-		{{- end }}
+	if len(querySetStructConfigs) == 0 {
+		return nil, nil
 	}
 
-	// Update updates {{ .StructName }} fields by primary key
-	func (o *{{ .StructName }}) Update(db *gorm.DB, fields ...{{ $ft }}) error {
-		dbNameToFieldName := map[string]interface{}{
-			{{- range .Fields }}
-				"{{ .Name | todbname }}": o.{{ .Name }},
-			{{- end }}
-		}
-		u := map[string]interface{}{}
-		for _, f := range fields {
-			fs := string(f)
-			u[fs] = dbNameToFieldName[fs]
-		}
-		if err := db.Model(o).Updates(u).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return err
-			}
+	sort.Sort(querySetStructConfigs)
 
-			return fmt.Errorf("can't update {{ .StructName }} %v fields %v: %s",
-				o, fields, err)
+	var buf bytes.Buffer
+	for _, c := range querySetStructConfigs {
+		if err = b.GetDeclarationsTemplate().Execute(&buf, c); err != nil {
+			return nil, fmt.Errorf("can't generate declarations of query set %s: %s", c.Name, err)
 		}
-
-		return nil
-	}
-
-	// {{ .StructName }}Updater is an {{ .StructName }} updates manager
-	type {{ .StructName }}Updater struct {
-		fields map[string]interface{}
-		db *gorm.DB
-	}
-
-	// New{{ .StructName }}Updater creates new {{ .StructName }} updater
-	func New{{ .StructName }}Updater(db *gorm.DB) {{ .StructName }}Updater {
-		return {{ .StructName }}Updater{
-			fields: map[string]interface{}{},
-			db: db.Model(&{{ .StructName }}{}),
+		if err = methodsTmpl.Execute(&buf, c); err != nil {
+			return nil, fmt.Errorf("can't generate methods of query set %s: %s", c.Name, err)
 		}
 	}
 
-	// ===== END of {{ .StructName }} modifiers
-{{ end }}
-
-// ===== END of all query sets
-`
+	return &buf, nil
+}
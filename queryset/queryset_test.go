@@ -1,9 +1,18 @@
 package queryset
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
@@ -13,12 +22,17 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	"github.com/jirfag/go-queryset/parser"
+	"github.com/jirfag/go-queryset/queryset/base"
+	"github.com/jirfag/go-queryset/queryset/methods"
 	"github.com/jirfag/go-queryset/queryset/test"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/imports"
 
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
@@ -28,12 +42,20 @@ func fixedFullRe(s string) string {
 }
 
 func newDB() (sqlmock.Sqlmock, *gorm.DB) {
+	return newDBWithDialect("mysql")
+}
+
+func newPostgresDB() (sqlmock.Sqlmock, *gorm.DB) {
+	return newDBWithDialect("postgres")
+}
+
+func newDBWithDialect(dialect string) (sqlmock.Sqlmock, *gorm.DB) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatalf("can't create sqlmock: %s", err)
 	}
 
-	gormDB, gerr := gorm.Open("mysql", db)
+	gormDB, gerr := gorm.Open(dialect, db)
 	if gerr != nil {
 		log.Fatalf("can't open gorm connection: %s", err)
 	}
@@ -102,7 +124,157 @@ func TestQueries(t *testing.T) {
 		testUserUpdateByEmail,
 		testUserDeleteByEmail,
 		testUserDeleteByPK,
+		testUserCreatedAtOnDateInLocation,
+		testUserFindDuplicates,
+		testUserDeferredConditions,
+		testBlogCreateSetsAutoUpdateTime,
+		testBlogUpdateByPKBumpsAutoUpdateTime,
+		testBlogUpdaterBumpsAutoUpdateTime,
+		testUserResultHash,
+		testUserNotGroup,
+		testUserEmptyNotGroup,
+		testUserOneForUpdateNoWait,
+		testUserOneForUpdateNoWaitLocked,
+		testUserCountByTwoFields,
+		testUserSelectOnlyDeleted,
+		testUserRestore,
+		testNoteSelectOneWrappedError,
+		testUserOrderByNameCollate,
+		testUserOrderByInvalidCollate,
+		testUserDiffFromDB,
+		testUserApplyRangeFilter,
+		testEventSelectWithSQLComment,
+		testEventDeleteWithSQLComment,
+		testPreloadPostsForUsers,
+		testUserStatFlags,
+		testUserPageCursor,
+		testUserLastPageCursor,
+		testAccountActiveFlagScope,
+		testAccountActiveFlagDelete,
+		testAccountActiveFlagDeleteByPK,
+		testAccountActiveFlagOnlyDeleted,
+		testAccountActiveFlagCreate,
+		testUserAllCached,
+		testUserApplyFieldMask,
+		testUserIsEmpty,
+		testUserApplyFilterInput,
+		testUserExplain,
+		testUserCount,
+		testUserCountUnscoped,
+		testUserCountDeleted,
+		testUserStatHasNoCountDeleted,
+		testTicketEnumConditions,
+		testTicketEnumInvalidCondition,
+		testTicketApplyFilterInput,
+		testTicketEnumValidation,
+		testUserAllInto,
+		testTicketCountApprox,
+		testTicketCountApproxWithConditions,
+		testUserCountApproxSoftDelete,
+		testUserLatestPerField,
+		testUserWithTracer,
+		testJobClaimBatch,
+		testLeaseClaimBatch,
+		testBookingOverlapsRange,
+		testUserAsScope,
+		testUserSetFieldForAll,
+		testAccountSetFieldForAllWithoutConditions,
+		testUserVerifySchema,
+		testBookingTruncatedTimeConditions,
+		testUserFromDescription,
+		testTicketDescribeEnum,
+		testUserEachRow,
+		testUserMinMax,
+		testUserUseReplica,
+		testUserSearch,
+		testUserDeleteOlderThan,
+		testUserAllIndexedBy,
+		testTicketAssigneeEqNullable,
+		testBookingOrderByAPIField,
+		testUserCountByHour,
+		testUserCreateIfNotMatched,
+		testUserStringComparisons,
+		testUserInChunked,
+		testUserSelectByIDIn,
+		testUserSelectByIDNotIn,
+		testUserSelectByEmptyIn,
+		testDocumentTenantScope,
+		testDocumentTenantStructMethods,
+		testDocumentTenantCreate,
+		testDocumentTenantColumnIsNotUpdated,
+		testUserOrderByMultipleFields,
+		testInvoiceDeleteWithAudit,
+		testJobNextReady,
+		testUserSelectPage,
+		testUserSelectNegativeLimitOffset,
+		testUserStatSelectAboveAverage,
+		testUserWithContext,
+		testUserSelectByNameLike,
+		testUserSelectNestedAndOr,
+		testJobWriteNDJSON,
+		testUserSelectByRawClause,
+		testPlaceWithinBoundingBox,
+		testTicketTagsContains,
+		testUserGetOrCreate,
+		testJobCreateFromChan,
+		testUserStatPercentileApprox,
+		testUserCreateBatch,
+		testTicketCreateBatchValidates,
+		testBlogCreateBatchSetsAutoTime,
+		testUserFieldAggregates,
+		testTierValueInFieldRange,
+		testUserDeleteHard,
+		testUserAllWithHasMore,
+		testJobWithRetry,
+		testSessionModifyByUUID,
+		testMembershipDeleteByCompositeKey,
+		testTierContinueAfter,
+		testUserPreloadPosts,
+		testUserInTransaction,
+		testUserLockTable,
+		testUserSelect,
+		testUserCreateDuplicateKey,
+		testUserIntoTempTable,
+		testUserCountByFieldWithRollup,
+		testUserFacetField,
+		testTierFieldLtExpr,
+		testTxBuilderCreate,
+		testUserCreatedAtCurrentPeriod,
+		testTierAllRanked,
+		testTicketWhereMap,
+		testProductDecrementStock,
+		testPostJoinScan,
+		testProductHistogramField,
+		testTicketFilterFromStruct,
+		testUserNextPrevBy,
+		testPostValidateReferences,
+	}
+	runQueryTests(t, funcs, newDB)
+}
+
+func TestPostgresQueries(t *testing.T) {
+	funcs := []testQueryFunc{
+		testPostgresUserConditions,
+		testPostgresUserNotGroup,
+		testPostgresUserUpdateByEmail,
+		testPostgresUserOrderByNameCollate,
+		testPostgresUserWithAdvisoryLock,
+		testPostgresUserExplainAnalyze,
+		testPostgresTicketCountApprox,
+		testPostgresUserLatestPerField,
+		testPostgresUserAsScope,
+		testPostgresUserVerifySchema,
+		testPostgresTicketTagsContains,
+		testPostgresUserStatPercentile,
+		testPostgresUserCreateBatch,
+		testPostgresUserLockTable,
+		testPostgresUserCountByFieldWithRollup,
+		testPostgresProductHistogramField,
 	}
+	runQueryTests(t, funcs, newPostgresDB)
+}
+
+func runQueryTests(t *testing.T, funcs []testQueryFunc, newDB func() (sqlmock.Sqlmock, *gorm.DB)) {
 	for _, f := range funcs {
 		f := f // save range var
 		funcName := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
@@ -207,20 +379,3167 @@ func testUserDeleteByPK(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
 	assert.Nil(t, u.Delete(db))
 }
 
-func TestMain(m *testing.M) {
-	err := GenerateQuerySets("test/models.go", "test/autogenerated_models.go")
-	if err != nil {
-		panic(err)
+func testUserCreatedAtOnDateInLocation(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	date := time.Date(2017, time.October, 5, 23, 30, 0, 0, time.UTC)
+	from := time.Date(2017, time.October, 4, 21, 0, 0, 0, time.UTC)
+	to := time.Date(2017, time.October, 5, 21, 0, 0, 0, time.UTC)
+
+	expUsers := getTestUsers(1)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((created_at >= ? AND created_at < ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(from, to).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		CreatedAtOnDateInLocation(date, loc).
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testPostgresUserConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	date := time.Date(2017, time.October, 5, 0, 0, 0, 0, time.UTC)
+	expUsers := getTestUsers(1)
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ((email = $1) AND ` +
+		`(id > $2) AND (created_at >= $3 AND created_at < $4))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("qs@mail.ru", 1, date, date.AddDate(0, 0, 1)).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		EmailEq("qs@mail.ru").
+		IDGt(1).
+		CreatedAtOnDateInLocation(date, time.UTC).
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testPostgresUserNotGroup(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ((name = $1) AND ` +
+		`(NOT ((email = $2) AND (id > $3))))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name", "email", 1).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		NameEq("name").
+		Not(func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.EmailEq("email").IDGt(1)
+		}).
+		All(&users)
+	assert.Nil(t, err)
+}
+
+func testPostgresUserUpdateByEmail(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `UPDATE "users" SET "name" = $1 WHERE "users".deleted_at IS NULL AND ((email = $2))`
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("name", "qs@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := test.NewUserQuerySet(db).
+		EmailEq("qs@mail.ru").
+		GetUpdater().
+		SetName("name").
+		Update()
+	assert.Nil(t, err)
+}
+
+func testPostgresUserOrderByNameCollate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL ORDER BY name COLLATE "en_US" ASC`
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).OrderAscByNameCollate("en_US").All(&users)
+	assert.Nil(t, err)
+}
+
+func testPostgresUserWithAdvisoryLock(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ((name = $1))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectCommit()
+
+	tx := db.Begin()
+	qs := test.NewUserQuerySet(tx).WithAdvisoryLock(42).NameEq("name")
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+	assert.Nil(t, tx.Commit().Error)
+
+	// not in transaction
+	err := test.NewUserQuerySet(db).WithAdvisoryLock(42).All(&users)
+	assert.EqualError(t, err, "advisory lock must be acquired in transaction")
+}
+
+func testUserFindDuplicates(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT email, count(*) FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"GROUP BY email HAVING (count(*) > 1)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "count(*)"}).
+			AddRow("a@mail.ru", 2).
+			AddRow("b@mail.ru", 3))
+
+	groups, err := test.NewUserQuerySet(db).FindDuplicates(test.UserDBSchema.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.DuplicateGroup{
+		{Value: "a@mail.ru", Count: 2},
+		{Value: "b@mail.ru", Count: 3},
+	}, groups)
+}
+
+func testUserResultHash(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(3)
+	changedUsers := getTestUsers(3)
+	copy(changedUsers, users)
+	changedUsers[1].Name = "changed"
+
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email != ?)) " +
+		"ORDER BY `users`.`id`"
+	hashes := []string{}
+	for _, us := range [][]test.User{users, users, changedUsers} {
+		// drivers return NULL as nil, not as typed nil pointer
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at", "updated_at", "deleted_at"})
+		for _, u := range us {
+			rows = rows.AddRow(u.ID, u.Name, u.Email, u.CreatedAt, u.UpdatedAt, nil)
+		}
+		m.ExpectQuery(fixedFullRe(req)).
+			WithArgs("").
+			WillReturnRows(rows)
+
+		h, err := test.NewUserQuerySet(db).EmailNe("").ResultHash()
+		assert.Nil(t, err)
+		hashes = append(hashes, h)
 	}
 
-	os.Exit(m.Run())
+	assert.Len(t, hashes[0], 64)
+	assert.Equal(t, hashes[0], hashes[1])
+	assert.NotEqual(t, hashes[0], hashes[2])
 }
 
-func BenchmarkHello(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		err := GenerateQuerySets("test/models.go", "test/autogenerated_models.go")
-		if err != nil {
-			b.Fatalf("can't generate querysets: %s", err)
+func testUserNotGroup(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	expUsers := []test.User{u}
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?) AND " +
+		"(NOT ((email = ?) AND (id > ?))) AND (id < ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(u.Name, u.Email, 1, 10).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		NameEq(u.Name).
+		Not(func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.EmailEq(u.Email).IDGt(1)
+		}).
+		IDLt(10).
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserEmptyNotGroup(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	expUsers := []test.User{u}
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(u.Name).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		NameEq(u.Name).
+		Not(func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs
+		}).
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserOneForUpdateNoWait(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?)) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1 FOR UPDATE NOWAIT"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers([]test.User{u}))
+
+	var ret test.User
+	err := test.NewUserQuerySet(db).EmailEq(u.Email).OneForUpdateNoWait(&ret)
+	assert.Nil(t, err)
+	assert.Equal(t, u, ret)
+}
+
+func testUserOneForUpdateNoWaitLocked(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY `users`.`id` ASC LIMIT 1 FOR UPDATE NOWAIT"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnError(fmt.Errorf("Error 3572: Statement aborted because lock(s) " +
+			"could not be acquired immediately and NOWAIT is set."))
+
+	var ret test.User
+	err := test.NewUserQuerySet(db).OneForUpdateNoWait(&ret)
+	assert.Equal(t, base.ErrRowLocked, err)
+}
+
+func testUserCountByTwoFields(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT name, email, count(*) FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"GROUP BY name, email"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "email", "count(*)"}).
+			AddRow("a", "a@mail.ru", 2).
+			AddRow("a", []byte("b@mail.ru"), 1))
+
+	counts, err := test.NewUserQuerySet(db).
+		CountByTwoFields(test.UserDBSchema.Name, test.UserDBSchema.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.TwoFieldCount{
+		{A: "a", B: "a@mail.ru", Count: 2},
+		{A: "a", B: "b@mail.ru", Count: 1},
+	}, counts)
+}
+
+func testUserSelectOnlyDeleted(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(1)
+	req := "SELECT * FROM `users` WHERE (deleted_at IS NOT NULL) AND (email = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("qs@mail.ru").
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).OnlyDeleted().EmailEq("qs@mail.ru").All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserRestore(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `users` SET `deleted_at` = NULL " +
+		"WHERE (deleted_at IS NOT NULL) AND (email = ?) AND (deleted_at IS NOT NULL)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("qs@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := test.NewUserQuerySet(db).OnlyDeleted().EmailEq("qs@mail.ru").Restore()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func testNoteSelectOneWrappedError(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `notes` WHERE (text = ?) ORDER BY `notes`.`id` ASC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("note").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}))
+
+	var n test.Note
+	err := test.NewNoteQuerySet(db).TextEq("note").One(&n)
+	assert.EqualError(t, err, "NoteQuerySet.One: record not found")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// errors of query sets without wrapErrors option aren't wrapped
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `blogs` WHERE `blogs`.deleted_at IS NULL AND ((name = ?)) " +
+		"ORDER BY `blogs`.`id` ASC LIMIT 1")).
+		WithArgs("blog").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	var b test.Blog
+	assert.Equal(t, gorm.ErrRecordNotFound, test.NewBlogQuerySet(db).NameEq("blog").One(&b))
+}
+
+func testUserOrderByNameCollate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY name COLLATE `utf8mb4_unicode_ci` DESC"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		OrderDescByNameCollate("utf8mb4_unicode_ci").
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserOrderByInvalidCollate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		OrderAscByNameCollate("x`; DROP TABLE users").
+		All(&users)
+	assert.EqualError(t, err, "invalid collation \"x`; DROP TABLE users\"")
+}
+
+func testUserDiffFromDB(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	dbu := u
+	dbu.Name = "old name"
+	dbu.CreatedAt = u.CreatedAt.In(time.FixedZone("UTC+3", 3*60*60)) // same time
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((`users`.`id` = ?)) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(u.ID).
+		WillReturnRows(getRowsForUsers([]test.User{dbu}))
+
+	fields, err := u.DiffFromDB(db)
+	assert.Nil(t, err)
+	if assert.Len(t, fields, 1) {
+		assert.Equal(t, test.UserDBSchema.Name, fields[0])
+	}
+}
+
+func testUserApplyRangeFilter(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	minID := uint(10)
+	maxCreatedAt := time.Date(2017, time.October, 5, 0, 0, 0, 0, time.UTC)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id >= ?) AND (created_at <= ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(minID, maxCreatedAt).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		ApplyRangeFilter(test.UserRangeFilter{
+			IDMin:        &minID,
+			CreatedAtMax: &maxCreatedAt,
+		}).
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testEventSelectWithSQLComment(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `events` WHERE `events`.deleted_at IS NULL AND ((title = ?)) " +
+		"/* EventQuerySet.All */"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("title").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "title"))
+
+	var events []test.Event
+	err := test.NewEventQuerySet(db).TitleEq("title").All(&events)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+}
+
+func testEventDeleteWithSQLComment(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `events` SET deleted_at=? WHERE `events`.deleted_at IS NULL AND ((title = ?)) " +
+		"/* EventQuerySet.Delete */"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), "title").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := test.NewEventQuerySet(db).TitleEq("title").Delete()
+	assert.Nil(t, err)
+}
+
+func testPreloadPostsForUsers(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(3)
+	req := "SELECT * FROM `posts` WHERE `posts`.deleted_at IS NULL AND ((user_id IN (?,?,?)))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(users[0].ID, users[1].ID, users[2].ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).
+			AddRow(1, users[2].ID, "a").
+			AddRow(2, users[0].ID, "b").
+			AddRow(3, users[2].ID, "c"))
+
+	err := test.PreloadPostsForUser(db, users)
+	assert.Nil(t, err)
+
+	getTitles := func(posts []test.Post) (ret []string) {
+		for _, p := range posts {
+			ret = append(ret, p.Title)
+		}
+		return
+	}
+	assert.Equal(t, []string{"b"}, getTitles(users[0].Posts))
+	assert.Nil(t, users[1].Posts)
+	assert.Equal(t, []string{"a", "c"}, getTitles(users[2].Posts))
+}
+
+func testUserStatFlags(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `user_stats` WHERE (flags & ? = ?) AND (flags & ? = 0)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(5, 5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "flags"}).AddRow(1, 13))
+
+	var stats []test.UserStat
+	err := test.NewUserStatQuerySet(db).
+		FlagsHasFlag(5).
+		FlagsLacksFlag(2).
+		All(&stats)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.UserStat{{UserID: 1, Flags: 13}}, stats)
+}
+
+func testUserPageCursor(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(2)
+	users[0].ID, users[1].ID = 11, 12
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id > ?)) " +
+		"ORDER BY id ASC LIMIT 2"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(10).
+		WillReturnRows(getRowsForUsers(users))
+
+	after := base.EncodeCursor(10)
+	assert.Equal(t, "MTA", after)
+	page, next, err := test.NewUserQuerySet(db).PageCursor(after, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, users, page)
+	assert.Equal(t, base.EncodeCursor(12), next)
+}
+
+func testUserLastPageCursor(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(1)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL ORDER BY id ASC LIMIT 2"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(users))
+
+	page, next, err := test.NewUserQuerySet(db).PageCursor("", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, users, page)
+	assert.Equal(t, "", next)
+
+	_, _, err = test.NewUserQuerySet(db).PageCursor("!", 2)
+	assert.NotNil(t, err)
+
+	// no query for not positive size
+	_, _, err = test.NewUserQuerySet(db).PageCursor("", 0)
+	assert.NotNil(t, err)
+
+	// order of query set is replaced by ID order of pages
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(users))
+	page, _, err = test.NewUserQuerySet(db).OrderDescByName().PageCursor("", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, users, page)
+
+	// pages would skip or repeat records with offset or limit
+	_, _, err = test.NewUserQuerySet(db).Offset(10).PageCursor("", 2)
+	assert.NotNil(t, err)
+	_, _, err = test.NewUserQuerySet(db).Limit(10).PageCursor("", 2)
+	assert.NotNil(t, err)
+}
+
+func testAccountActiveFlagScope(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `accounts` WHERE (name = ?) AND (is_active = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active"}).AddRow(1, "a", true))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `accounts` WHERE (name = ?)")).
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active"}).AddRow(1, "a", true))
+
+	var accounts []test.Account
+	err := test.NewAccountQuerySet(db).NameEq("a").All(&accounts)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.Account{{ID: 1, Name: "a", IsActive: true}}, accounts)
+
+	err = test.NewAccountQuerySet(db).NameEq("a").Unscoped().All(&accounts)
+	assert.Nil(t, err)
+}
+
+func testAccountActiveFlagDelete(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `accounts` SET `is_active` = ? WHERE (name = ?) AND (is_active = ?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(false, "a", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := test.NewAccountQuerySet(db).NameEq("a").Delete()
+	assert.Nil(t, err)
+}
+
+func testAccountActiveFlagDeleteByPK(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `accounts` SET `is_active` = ? WHERE `accounts`.`id` = ?"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(false, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := test.Account{ID: 3, IsActive: true}
+	assert.Nil(t, a.Delete(db))
+	assert.False(t, a.IsActive)
+}
+
+func testAccountActiveFlagCreate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "INSERT INTO `accounts` (`name`,`is_active`) VALUES (?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("a", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("b", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("c", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// created records are visible for query sets
+	a := test.Account{Name: "a"}
+	assert.Nil(t, a.Create(db))
+	assert.True(t, a.IsActive)
+
+	accounts := []test.Account{{Name: "b"}}
+	assert.Nil(t, test.AccountCreateBatch(db, accounts))
+	assert.True(t, accounts[0].IsActive)
+
+	ch := make(chan test.Account, 1)
+	ch <- test.Account{Name: "c"}
+	close(ch)
+	n, err := test.AccountCreateFromChan(db, ch, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testAccountActiveFlagOnlyDeleted(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `accounts` WHERE (is_active = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active"}).AddRow(1, "a", false))
+
+	var accounts []test.Account
+	err := test.NewAccountQuerySet(db).OnlyDeleted().All(&accounts)
+	assert.Nil(t, err)
+	assert.Len(t, accounts, 1)
+}
+
+type fakeCache struct {
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (c *fakeCache) Get(key string) ([]byte, bool, error) {
+	v, ok := c.values[key]
+	return v, ok, nil
+}
+
+func (c *fakeCache) Set(key string, value []byte, ttl time.Duration) error {
+	c.values[key] = value
+	c.ttls[key] = ttl
+	return nil
+}
+
+func testUserAllCached(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	for i := range expUsers { // JSON has no monotonic clock
+		expUsers[i].CreatedAt = expUsers[i].CreatedAt.Round(0)
+		expUsers[i].UpdatedAt = expUsers[i].UpdatedAt.Round(0)
+	}
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email != ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("").
+		WillReturnRows(getRowsForUsers(expUsers)) // only once: second call is a cache hit
+
+	cache := &fakeCache{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	for i := 0; i < 2; i++ {
+		var users []test.User
+		err := test.NewUserQuerySet(db).EmailNe("").AllCached(cache, "users", time.Minute, &users)
+		assert.Nil(t, err)
+		assert.Len(t, users, len(expUsers))
+		for j := range users {
+			assert.Equal(t, expUsers[j].Email, users[j].Email)
+			assert.True(t, expUsers[j].CreatedAt.Equal(users[j].CreatedAt))
 		}
 	}
+	assert.Equal(t, time.Minute, cache.ttls["users"])
+}
+
+func testUserApplyFieldMask(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(1)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?) AND (created_at = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name", expUsers[0].CreatedAt).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	values := map[string]interface{}{
+		"name":      "name",
+		"createdAt": expUsers[0].CreatedAt,
+	}
+	qs, err := test.NewUserQuerySet(db).ApplyFieldMask([]string{"name", "createdAt"}, values)
+	assert.Nil(t, err)
+
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+	assert.Equal(t, expUsers, users)
+
+	_, err = test.NewUserQuerySet(db).ApplyFieldMask([]string{"password"}, values)
+	assert.EqualError(t, err, `invalid field mask path "password": no such column`)
+	_, err = test.NewUserQuerySet(db).ApplyFieldMask([]string{"email"}, values)
+	assert.EqualError(t, err, `no value for field mask path "email"`)
+}
+
+func testUserIsEmpty(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT 1 FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?)) LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a@mail.ru").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("b@mail.ru").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	empty, err := test.NewUserQuerySet(db).EmailEq("a@mail.ru").IsEmpty()
+	assert.Nil(t, err)
+	assert.True(t, empty)
+
+	empty, err = test.NewUserQuerySet(db).EmailEq("b@mail.ru").IsEmpty()
+	assert.Nil(t, err)
+	assert.False(t, empty)
+}
+
+func testUserDeferredConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	expUsers := []test.User{u}
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?) AND (name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(u.Email, u.Name).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var email string
+	qs := test.NewUserQuerySet(db).
+		Defer(func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.EmailEq(email)
+		}).
+		Defer(func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.NameEq(u.Name)
+		})
+	email = u.Email // is known only after building of query set
+
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+	assert.Equal(t, expUsers, users)
+}
+
+// recentTimeArg matches time args which are not before t
+type recentTimeArg struct {
+	t time.Time
+}
+
+func (a recentTimeArg) Match(v driver.Value) bool {
+	vt, ok := v.(time.Time)
+	return ok && !vt.Before(a.t)
+}
+
+func testBlogCreateSetsAutoUpdateTime(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	b := test.Blog{Name: "blog"}
+	req := "INSERT INTO `blogs` (`created_at`,`updated_at`,`deleted_at`,`name`,`refreshed_at`) VALUES (?,?,?,?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), b.Name,
+			recentTimeArg{startedAt}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, b.Create(db))
+	assert.False(t, b.RefreshedAt.Before(startedAt))
+}
+
+func testBlogUpdateByPKBumpsAutoUpdateTime(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	b := test.Blog{Model: gorm.Model{ID: 7}}
+	req := "UPDATE `blogs` SET `refreshed_at` = ? WHERE `blogs`.deleted_at IS NULL AND `blogs`.`id` = ?"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(recentTimeArg{startedAt}, b.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, b.Update(db))
+	assert.False(t, b.RefreshedAt.Before(startedAt))
+}
+
+func testBlogUpdaterBumpsAutoUpdateTime(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	req := "UPDATE `blogs` SET `refreshed_at` = ? WHERE `blogs`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(recentTimeArg{startedAt}, "blog").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := test.NewBlogQuerySet(db).
+		NameEq("blog").
+		GetUpdater().
+		Update()
+	assert.Nil(t, err)
+}
+
+// sqlBuilderBackend is a minimal non-GORM backend: it emits only equality
+// conditions in squirrel style
+type sqlBuilderBackend struct{}
+
+type sqlBuilderEqMethod struct {
+	selectTypeName string
+	field          FieldInfo
+}
+
+func (m sqlBuilderEqMethod) GetMethodName() string {
+	return m.field.Name + "Eq"
+}
+
+func (m sqlBuilderEqMethod) GetReceiverDeclaration() string {
+	return "q " + m.selectTypeName
+}
+
+func (m sqlBuilderEqMethod) GetArgsDeclaration() string {
+	return fmt.Sprintf("%s %s", methods.LowercaseFirstRune(m.field.Name), m.field.TypeName)
+}
+
+func (m sqlBuilderEqMethod) GetReturnValuesDeclaration() string {
+	return m.selectTypeName
+}
+
+func (m sqlBuilderEqMethod) GetBody() string {
+	return fmt.Sprintf(`return q.where(sq.Eq{"%s": %s})`,
+		gorm.ToDBName(m.field.Name), methods.LowercaseFirstRune(m.field.Name))
+}
+
+func (m sqlBuilderEqMethod) GetDoc(methodName string) string {
+	return "// " + methodName + " is an equality condition"
+}
+
+func (b sqlBuilderBackend) GetMethods(s StructInfo) []methods.Method {
+	ret := []methods.Method{}
+	for _, f := range s.Fields {
+		if f.IsStruct || f.IsPointer {
+			continue
+		}
+		ret = append(ret, sqlBuilderEqMethod{
+			selectTypeName: s.Name + "Select",
+			field:          f,
+		})
+	}
+	return ret
+}
+
+func (b sqlBuilderBackend) GetImports() []string {
+	return []string{`sq "github.com/Masterminds/squirrel"`}
+}
+
+func (b sqlBuilderBackend) GetDeclarationsTemplate() *template.Template {
+	const code = `
+// {{ .StructName }}Select builds SELECT of {{ .StructName }} records
+type {{ .StructName }}Select struct {
+	b sq.SelectBuilder
+}
+
+// New{{ .StructName }}Select constructs new {{ .StructName }}Select
+func New{{ .StructName }}Select(b sq.SelectBuilder) {{ .StructName }}Select {
+	return {{ .StructName }}Select{b: b}
+}
+
+func (q {{ .StructName }}Select) where(pred interface{}) {{ .StructName }}Select {
+	q.b = q.b.Where(pred)
+	return q
+}
+`
+	return template.Must(template.New("sqlbuilder").Parse(code))
+}
+
+// squirrelStub declares API of squirrel used by code of sqlBuilderBackend:
+// generated code is type-checked with it
+const squirrelStub = `package squirrel
+
+type Eq map[string]interface{}
+
+type SelectBuilder struct{}
+
+func (b SelectBuilder) Where(pred interface{}, args ...interface{}) SelectBuilder {
+	return b
+}
+`
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// typeCheckSQLBuilderCode type-checks code generated for sqlBuilderBackend
+// as a file of package pkg: packages imported by pkg and squirrel stub
+// can be imported
+func typeCheckSQLBuilderCode(pkg *types.Package, code []byte) error {
+	fset := token.NewFileSet()
+	stubFile, err := goparser.ParseFile(fset, "squirrel.go", squirrelStub, 0)
+	if err != nil {
+		return err
+	}
+	stub, err := (&types.Config{}).Check("github.com/Masterminds/squirrel", fset, []*ast.File{stubFile}, nil)
+	if err != nil {
+		return err
+	}
+
+	f, err := goparser.ParseFile(fset, "sqlbuilder.go", code, 0)
+	if err != nil {
+		return err
+	}
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if path == stub.Path() {
+			return stub, nil
+		}
+		for _, p := range pkg.Imports() {
+			if p.Path() == path {
+				return p, nil
+			}
+		}
+		return nil, fmt.Errorf("package %s isn't imported by %s", path, pkg.Path())
+	})}
+	_, err = conf.Check(pkg.Path(), fset, []*ast.File{f}, nil)
+	return err
+}
+
+func TestGenerateForCustomBackend(t *testing.T) {
+	pkgInfo, structs, err := parser.GetStructsInFile("test/models.go")
+	assert.Nil(t, err)
+
+	r, err := GenerateQuerySetsForStructsWithBackend(sqlBuilderBackend{}, pkgInfo, structs)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	code, err := imports.Process("sqlbuilder.go",
+		append([]byte(getFileHeader(sqlBuilderBackend{}, "test")), body...), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, typeCheckSQLBuilderCode(pkgInfo.Pkg, code))
+
+	assert.Contains(t, string(code), `// EmailEq is an equality condition
+func (q UserSelect) EmailEq(email string) UserSelect {
+	return q.where(sq.Eq{"email": email})
+}`)
+	assert.Contains(t, string(code), `func (q PostSelect) TitleEq(title string) PostSelect {`)
+	assert.NotContains(t, string(code), "gorm")
+	assert.NotContains(t, string(code), "QuerySet")
+
+	assert.Equal(t, "package test\nimport (\n\tsq \"github.com/Masterminds/squirrel\"\n)\n",
+		getFileHeader(sqlBuilderBackend{}, "test"))
+}
+
+func TestReadOnlyModelHasNoMutators(t *testing.T) {
+	mutators := []string{"Create", "Update", "Delete", "GetUpdater", "Restore"}
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(&test.UserStat{}),
+		reflect.TypeOf(test.UserStatQuerySet{}),
+	} {
+		for _, name := range mutators {
+			_, ok := typ.MethodByName(name)
+			assert.False(t, ok, "%s has method %s", typ, name)
+		}
+	}
+
+	_, ok := reflect.TypeOf(test.UserStatQuerySet{}).MethodByName("All")
+	assert.True(t, ok)
+	_, ok = reflect.TypeOf(test.UserQuerySet{}).MethodByName("Delete")
+	assert.True(t, ok)
+}
+
+func TestExcludedAndReadOnlyFields(t *testing.T) {
+	qsType := reflect.TypeOf(test.CredentialQuerySet{})
+	updaterType := reflect.TypeOf(test.CredentialUpdater{})
+	for _, field := range []string{"PasswordHash", "Strength"} {
+		for _, typ := range []reflect.Type{qsType, updaterType} {
+			for i := 0; i < typ.NumMethod(); i++ {
+				name := typ.Method(i).Name
+				assert.NotContains(t, name, field, "%s has method %s", typ, name)
+			}
+		}
+	}
+	_, ok := reflect.TypeOf(test.CredentialDBSchema).FieldByName("PasswordHash")
+	assert.False(t, ok)
+
+	for _, name := range []string{"LoginCountEq", "LoginCountGt", "OrderAscByLoginCount"} {
+		_, ok = qsType.MethodByName(name)
+		assert.True(t, ok, "%s has no method %s", qsType, name)
+	}
+	_, ok = updaterType.MethodByName("SetLoginCount")
+	assert.False(t, ok)
+	_, ok = updaterType.MethodByName("SetEmail")
+	assert.True(t, ok)
+}
+
+func TestSchemaJSON(t *testing.T) {
+	var schema struct {
+		Model  string
+		Fields []struct {
+			Name, Column, Type   string
+			Nullable, PrimaryKey bool
+		}
+	}
+	assert.Nil(t, json.Unmarshal(test.UserSchemaJSON(), &schema))
+	assert.Equal(t, "User", schema.Model)
+	assert.Len(t, schema.Fields, 6) // Posts association isn't a column
+
+	id, deletedAt := schema.Fields[0], schema.Fields[3]
+	assert.Equal(t, "id", id.Column)
+	assert.Equal(t, "uint", id.Type)
+	assert.True(t, id.PrimaryKey)
+	assert.False(t, id.Nullable)
+	assert.Equal(t, "DeletedAt", deletedAt.Name)
+	assert.Equal(t, "deleted_at", deletedAt.Column)
+	assert.Equal(t, "*time.Time", deletedAt.Type)
+	assert.True(t, deletedAt.Nullable)
+	assert.False(t, deletedAt.PrimaryKey)
+}
+
+func TestParseQuerySetAnnotation(t *testing.T) {
+	cases := []struct {
+		text string
+		ok   bool
+		opts map[string]string
+	}{
+		{"// gen:qs", true, map[string]string{}},
+		{"//gen: qs", true, map[string]string{}},
+		{"// gen:qs readOnly", true, map[string]string{"readOnly": ""}},
+		{"// gen:qs wrapErrors", true, map[string]string{"wrapErrors": ""}},
+		{"// gen:qs sqlComments", true, map[string]string{"sqlComments": ""}},
+		{"// gen:qs readOnly opt:val", true, map[string]string{"readOnly": "", "opt": "val"}},
+		{"// gen:qsx", false, nil},
+		{"// User is a user", false, nil},
+	}
+	for _, c := range cases {
+		doc := &ast.CommentGroup{List: []*ast.Comment{{Text: c.text}}}
+		opts, ok := parseQuerySetAnnotation(doc)
+		assert.Equal(t, c.ok, ok, c.text)
+		assert.Equal(t, c.opts, opts, c.text)
+	}
+
+	_, err := getStructInfo("User", nil, map[string]string{"unknown": ""})
+	assert.NotNil(t, err)
+
+	id := FieldInfo{BaseFieldInfo: BaseFieldInfo{Name: "ID", TypeName: "uint", IsNumeric: true}}
+	fields := []FieldInfo{id, {BaseFieldInfo: BaseFieldInfo{Name: "IsActive", TypeName: "bool"}}}
+	s, err := getStructInfo("User", fields, map[string]string{"activeFlag": "is_active"})
+	assert.Nil(t, err)
+	assert.Equal(t, "IsActive", s.ActiveFlagField.Name)
+	_, err = getStructInfo("User", fields, map[string]string{"activeFlag": "active"})
+	assert.NotNil(t, err)
+
+	fields = []FieldInfo{id, {BaseFieldInfo: BaseFieldInfo{Name: "TenantID", TypeName: "uint"}}}
+	s, err = getStructInfo("Document", fields, map[string]string{"tenantColumn": "tenant_id"})
+	assert.Nil(t, err)
+	assert.Equal(t, "TenantID", s.TenantField.Name)
+	assert.Equal(t, "uint", s.TenantField.TypeName)
+	_, err = getStructInfo("Document", fields, map[string]string{"tenantColumn": "org_id"})
+	assert.EqualError(t, err, `no field with db name "org_id" for tenantColumn of struct Document`)
+
+	fields = []FieldInfo{{
+		BaseFieldInfo: BaseFieldInfo{Name: "StartAt", TypeName: "time.Time", IsNumeric: true, IsTime: true},
+		Tag:           `queryset:"rangeStart"`,
+	}}
+	_, err = getStructInfo("Booking", fields, nil)
+	assert.EqualError(t, err, "invalid range of struct Booking: rangeStart and rangeEnd fields must be paired")
+
+	fields = []FieldInfo{{
+		BaseFieldInfo: BaseFieldInfo{Name: "DeletedBy", TypeName: "string", IsString: true},
+		Tag:           `queryset:"deletedBy"`,
+	}, {
+		BaseFieldInfo: BaseFieldInfo{Name: "DeleteReason", TypeName: "string", IsString: true},
+		Tag:           `queryset:"deleteReason"`,
+	}}
+	_, err = getStructInfo("Invoice", fields, nil)
+	assert.EqualError(t, err, "invalid delete audit of struct Invoice: "+
+		"audit fields are set on soft delete: deleted_at field is required")
+	_, err = getStructInfo("Invoice", fields[:1], nil)
+	assert.EqualError(t, err, "invalid delete audit of struct Invoice: deletedBy and deleteReason fields must be paired")
+
+	fields = []FieldInfo{{
+		BaseFieldInfo: BaseFieldInfo{Name: "Lat", TypeName: "float64", IsNumeric: true},
+		Tag:           `queryset:"lat"`,
+	}, {
+		BaseFieldInfo: BaseFieldInfo{Name: "Lng", TypeName: "int", IsNumeric: true},
+		Tag:           `queryset:"lng"`,
+	}}
+	_, err = getStructInfo("Place", fields, nil)
+	assert.EqualError(t, err, "invalid point of struct Place: point fields Lat and Lng must be float fields")
+	_, err = getStructInfo("Place", fields[:1], nil)
+	assert.EqualError(t, err, "invalid point of struct Place: lat and lng fields must be paired")
+
+	fields = []FieldInfo{{
+		BaseFieldInfo: BaseFieldInfo{Name: "Worker", TypeName: "string", IsString: true},
+		Tag:           `queryset:"claimedBy"`,
+	}, {
+		BaseFieldInfo: BaseFieldInfo{Name: "ClaimedAt", TypeName: "time.Time", IsNumeric: true, IsTime: true},
+		Tag:           `queryset:"claimedAt"`,
+	}}
+	_, err = getStructInfo("Job", fields, nil)
+	assert.EqualError(t, err, "invalid claim fields of struct Job: "+
+		"claim fields Worker and ClaimedAt must be string and *time.Time fields")
+	_, err = getStructInfo("Job", fields[:1], nil)
+	assert.EqualError(t, err, "invalid claim fields of struct Job: claimedBy and claimedAt fields must be paired")
+
+	fields = []FieldInfo{{
+		BaseFieldInfo: BaseFieldInfo{Name: "Priority", TypeName: "string", IsString: true},
+		Tag:           `queryset:"priority"`,
+	}, {
+		BaseFieldInfo: BaseFieldInfo{Name: "RunAt", TypeName: "time.Time", IsNumeric: true, IsTime: true},
+		Tag:           `queryset:"readyAt"`,
+	}}
+	_, err = getStructInfo("Job", fields, nil)
+	assert.EqualError(t, err, "invalid schedule fields of struct Job: "+
+		"schedule fields Priority and RunAt must be numeric and time.Time fields")
+	_, err = getStructInfo("Job", fields[1:], nil)
+	assert.EqualError(t, err, "invalid schedule fields of struct Job: priority and readyAt fields must be paired")
+
+	fields = []FieldInfo{{BaseFieldInfo: BaseFieldInfo{Name: "Token", TypeName: "string", IsString: true}}}
+	_, err = getStructInfo("Session", fields, nil)
+	assert.EqualError(t, err, "no primary key of struct Session: "+
+		`ID field or fields tagged by gorm:"primary_key" are required`)
+	_, err = getStructInfo("Session", fields, map[string]string{"readOnly": ""})
+	assert.Nil(t, err)
+	fields[0].Tag = `gorm:"primary_key"`
+	_, err = getStructInfo("Session", fields, nil)
+	assert.Nil(t, err)
+}
+
+func TestMain(m *testing.M) {
+	err := GenerateQuerySets("test/models.go", "test/autogenerated_models.go")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func BenchmarkHello(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		err := GenerateQuerySets("test/models.go", "test/autogenerated_models.go")
+		if err != nil {
+			b.Fatalf("can't generate querysets: %s", err)
+		}
+	}
+}
+
+func testUserApplyFilterInput(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(1)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((id > ?) AND (name >= ?) AND (name LIKE ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(18, "a", "a%").
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	like := "a%"
+	minName := "a"
+	var minID uint = 18
+	qs, err := test.NewUserQuerySet(db).ApplyFilterInput(test.UserFilterInput{
+		Name: &test.UserNameFilter{Gte: &minName, Like: &like},
+		ID:   &test.UserIDFilter{Gt: &minID},
+	})
+	assert.Nil(t, err)
+
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+	assert.Equal(t, expUsers, users)
+
+	_, err = test.NewUserQuerySet(db).ApplyFilterInput(test.UserFilterInput{
+		ID: &test.UserIDFilter{Like: &like},
+	})
+	assert.EqualError(t, err, "UserFilterInput.ID: Like is supported only by string fields")
+
+	// empty In list matches no records like NameIn()
+	req = "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((1 = 0))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows(nil))
+	qs, err = test.NewUserQuerySet(db).ApplyFilterInput(test.UserFilterInput{
+		Name: &test.UserNameFilter{In: []string{}},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, qs.All(&users))
+	assert.Empty(t, users)
+}
+
+func testUserExplain(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "EXPLAIN SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?)) ORDER BY id DESC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "type"}).
+			AddRow("1", "users", "ALL"))
+
+	plan, err := test.NewUserQuerySet(db).NameEq("name").OrderDescByID().Limit(1).Explain()
+	assert.Nil(t, err)
+	assert.Equal(t, "id\ttable\ttype\n1\tusers\tALL", plan)
+}
+
+func testPostgresUserExplainAnalyze(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `EXPLAIN ANALYZE SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ((name = $1))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).
+			AddRow("Seq Scan on users").
+			AddRow("  Filter: (name = 'name')"))
+
+	plan, err := test.NewUserQuerySet(db).NameEq("name").ExplainAnalyze()
+	assert.Nil(t, err)
+	assert.Equal(t, "Seq Scan on users\n  Filter: (name = 'name')", plan)
+}
+
+func testUserCount(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT count(*) FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	n, err := test.NewUserQuerySet(db).NameEq("name").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func testUserCountUnscoped(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT count(*) FROM `users` WHERE (name = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	n, err := test.NewUserQuerySet(db).NameEq("name").CountUnscoped()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func testUserCountDeleted(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT count(*) FROM `users` WHERE (name = ?) AND (deleted_at IS NOT NULL)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	n, err := test.NewUserQuerySet(db).NameEq("name").CountDeleted()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func testUserStatHasNoCountDeleted(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	qsType := reflect.TypeOf(test.UserStatQuerySet{})
+	_, ok := qsType.MethodByName("Count")
+	assert.True(t, ok)
+	for _, name := range []string{"CountUnscoped", "CountDeleted"} {
+		_, ok = qsType.MethodByName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func testTicketEnumConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tickets` WHERE (status IN (?,?)) AND (status != ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("new", "open", "closed").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "new"))
+
+	var tickets []test.Ticket
+	err := test.NewTicketQuerySet(db).StatusIn("new", "open").StatusNe("closed").All(&tickets)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.Ticket{{ID: 1, Status: "new"}}, tickets)
+}
+
+func testTicketEnumInvalidCondition(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	var tickets []test.Ticket
+	err := test.NewTicketQuerySet(db).StatusEq("reopened").All(&tickets)
+	assert.EqualError(t, err, `invalid value "reopened" of enum Ticket.Status: must be one of ["new" "open" "closed"]`)
+
+	err = test.NewTicketQuerySet(db).StatusIn("new", "deleted").All(&tickets)
+	assert.EqualError(t, err, `invalid value "deleted" of enum Ticket.Status: must be one of ["new" "open" "closed"]`)
+}
+
+func testTicketApplyFilterInput(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tickets` WHERE (id >= ?) AND (status IN (?,?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(2, "new", "open").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(2, "new"))
+
+	var minID uint = 2
+	qs, err := test.NewTicketQuerySet(db).ApplyFilterInput(test.TicketFilterInput{
+		ID:     &test.TicketIDFilter{Gte: &minID},
+		Status: &test.TicketStatusFilter{In: []string{"new", "open"}},
+	})
+	assert.Nil(t, err)
+	var tickets []test.Ticket
+	assert.Nil(t, qs.All(&tickets))
+	assert.Equal(t, []test.Ticket{{ID: 2, Status: "new"}}, tickets)
+
+	// values of In are validated like by StatusIn
+	qs, err = test.NewTicketQuerySet(db).ApplyFilterInput(test.TicketFilterInput{
+		Status: &test.TicketStatusFilter{In: []string{"new", "deleted"}},
+	})
+	assert.Nil(t, err)
+	assert.EqualError(t, qs.All(&tickets),
+		`invalid value "deleted" of enum Ticket.Status: must be one of ["new" "open" "closed"]`)
+
+	// enums and JSON arrays aren't ordered
+	status := "new"
+	_, err = test.NewTicketQuerySet(db).ApplyFilterInput(test.TicketFilterInput{
+		Status: &test.TicketStatusFilter{Gt: &status},
+	})
+	assert.EqualError(t, err, "TicketFilterInput.Status: Gt, Gte, Lt and Lte are supported only by ordered fields")
+	_, err = test.NewTicketQuerySet(db).ApplyFilterInput(test.TicketFilterInput{
+		Tags: &test.TicketTagsFilter{Lte: &status},
+	})
+	assert.NotNil(t, err)
+}
+
+func testTicketEnumValidation(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	tk := test.Ticket{ID: 1, Status: "new"}
+	assert.Nil(t, tk.Validate())
+
+	tk.Status = "reopened"
+	assert.NotNil(t, tk.Validate())
+	assert.NotNil(t, tk.Create(db))
+	assert.NotNil(t, tk.Update(db, test.TicketDBSchema.Status))
+
+	err := test.NewTicketQuerySet(db).IDEq(1).GetUpdater().SetStatus("reopened").Update()
+	assert.EqualError(t, err, `invalid value "reopened" of enum Ticket.Status: must be one of ["new" "open" "closed"]`)
+}
+
+type UserListItem struct {
+	ID   uint
+	Name string
+}
+
+func testUserAllInto(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT id, name FROM `users` WHERE `users`.deleted_at IS NULL AND ((email != ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	var items []UserListItem
+	err := test.NewUserQuerySet(db).EmailNe("").
+		AllInto(&items, test.UserDBSchema.ID, test.UserDBSchema.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, []UserListItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, items)
+
+	err = test.NewUserQuerySet(db).AllInto(&items, test.UserDBSchema.Posts)
+	assert.EqualError(t, err, `can't select field "posts": no such column`)
+
+	err = test.NewUserQuerySet(db).AllInto(items, test.UserDBSchema.ID)
+	assert.EqualError(t, err, "dest must be a pointer to slice, not []queryset.UserListItem")
+}
+
+func testTicketCountApprox(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("tickets").
+		WillReturnRows(sqlmock.NewRows([]string{"table_rows"}).AddRow(1000000))
+
+	n, err := test.NewTicketQuerySet(db).CountApprox()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000000), n)
+}
+
+func testTicketCountApproxWithConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT count(*) FROM `tickets` WHERE (status = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("new").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	n, err := test.NewTicketQuerySet(db).StatusEq("new").CountApprox()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+}
+
+func testUserCountApproxSoftDelete(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"table_rows"}).AddRow(500))
+	req = "SELECT count(*) FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	// implicit soft delete condition doesn't make count exact
+	n, err := test.NewUserQuerySet(db).CountApprox()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(500), n)
+
+	n, err = test.NewUserQuerySet(db).NameEq("name").CountApprox()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func testPostgresTicketCountApprox(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("tickets").
+		WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(-1))
+	m.ExpectQuery(fixedFullRe(`SELECT count(*) FROM "tickets"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	// table wasn't analyzed: exact count
+	n, err := test.NewTicketQuerySet(db).CountApprox()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+func testUserLatestPerField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	expUsers[1].Email = "other"
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((`users`.`updated_at` = (SELECT MAX(latest.`updated_at`) FROM `users` AS latest " +
+		"WHERE latest.`email` = `users`.`email`)))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).LatestPerField(test.UserDBSchema.Email).All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, expUsers, users)
+}
+
+func testPostgresUserLatestPerField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ` +
+		`(("users"."updated_at" = (SELECT MAX(latest."updated_at") FROM "users" AS latest ` +
+		`WHERE latest."email" = "users"."email")) AND (name = $1))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a").
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).LatestPerField(test.UserDBSchema.Email).NameEq("a").All(&users)
+	assert.Nil(t, err)
+}
+
+type fakeSpan struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+func (s *fakeSpan) SetAttribute(key, value string) { s.attrs[key] = value }
+func (s *fakeSpan) SetError(err error)             { s.err = err }
+func (s *fakeSpan) End()                           { s.ended = true }
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(name string) base.Span {
+	s := &fakeSpan{name: name, attrs: map[string]string{}}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func testUserWithTracer(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a").
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a").
+		WillReturnError(errors.New("db error"))
+
+	tracer := &fakeTracer{}
+	qs := test.NewUserQuerySet(db).WithTracer(tracer).NameEq("a")
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+	assert.NotNil(t, qs.All(&users))
+
+	assert.Len(t, tracer.spans, 2)
+	for _, s := range tracer.spans {
+		assert.Equal(t, "UserQuerySet.All", s.name)
+		// GORM has double spaces in SQL
+		assert.Equal(t, req, strings.Join(strings.Fields(s.attrs["db.statement"]), " "))
+		assert.True(t, s.ended)
+	}
+	assert.Nil(t, tracer.spans[0].err)
+	assert.EqualError(t, tracer.spans[1].err, "db error")
+
+	// no tracer: no spans
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a").
+		WillReturnRows(getRowsForUsers(nil))
+	assert.Nil(t, test.NewUserQuerySet(db).NameEq("a").All(&users))
+	assert.Len(t, tracer.spans, 2)
+}
+
+func testJobClaimBatch(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	req := "SELECT * FROM `jobs` WHERE (payload != ?) AND (claimed_at IS NULL) LIMIT 2 FOR UPDATE SKIP LOCKED"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "claimed_by", "claimed_at"}).
+			AddRow(1, "a", "", nil).
+			AddRow(3, "b", "", nil))
+	m.ExpectExec(fixedFullRe("UPDATE `jobs` SET `claimed_by` = ?, `claimed_at` = ? WHERE `id` IN (?,?)")).
+		WithArgs("w1", sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	m.ExpectCommit()
+
+	jobs, err := test.NewJobQuerySet(db).PayloadNe("").ClaimBatch("w1", 2)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+	for _, j := range jobs {
+		assert.Equal(t, "w1", j.ClaimedBy)
+		assert.NotNil(t, j.ClaimedAt)
+	}
+}
+
+func testLeaseClaimBatch(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	req := "SELECT * FROM `leases` WHERE (locked_at IS NULL) LIMIT 1 FOR UPDATE SKIP LOCKED"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "holder", "locked_at"}).AddRow("a", "", nil))
+	m.ExpectExec(fixedFullRe("UPDATE `leases` SET `holder` = ?, `locked_at` = ? WHERE `code` IN (?)")).
+		WithArgs("w1", sqlmock.AnyArg(), "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+
+	leases, err := test.NewLeaseQuerySet(db).ClaimBatch("w1", 1)
+	assert.Nil(t, err)
+	assert.Len(t, leases, 1)
+	assert.Equal(t, "w1", leases[0].Holder)
+	assert.NotNil(t, leases[0].LockedAt)
+}
+
+func testBookingOverlapsRange(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	from := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	req := "SELECT * FROM `bookings` WHERE (start_at < ? AND end_at > ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(to, from).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_at", "end_at"}).
+			AddRow(1, from.Add(-time.Hour), from.Add(time.Minute)))
+
+	var bookings []test.Booking
+	err := test.NewBookingQuerySet(db).OverlapsRange(from, to).All(&bookings)
+	assert.Nil(t, err)
+	assert.Len(t, bookings, 1)
+}
+
+func testUserAsScope(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"(((name = ?) AND (email != ?)) AND (id > ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a", "", 1).
+		WillReturnRows(getRowsForUsers(nil))
+
+	scope := test.NewUserQuerySet(db).NameEq("a").EmailNe("").AsScope()
+	var users []test.User
+	err := db.Scopes(scope).Where("id > ?", 1).Find(&users).Error
+	assert.Nil(t, err)
+}
+
+func testPostgresUserAsScope(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT * FROM "users" WHERE "users".deleted_at IS NULL AND ((id > $1) AND ((name = $2)))`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(1, "a").
+		WillReturnRows(getRowsForUsers(nil))
+
+	scope := test.NewUserQuerySet(db).NameEq("a").AsScope()
+	var users []test.User
+	err := db.Where("id > ?", 1).Scopes(scope).Find(&users).Error
+	assert.Nil(t, err)
+}
+
+func testUserSetFieldForAll(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `users` SET `email` = ? WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("e", "a").
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	n, err := test.NewUserQuerySet(db).NameEq("a").SetFieldForAll(test.UserDBSchema.Email, "e")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// no conditions
+	_, err = test.NewUserQuerySet(db).SetFieldForAll(test.UserDBSchema.Email, "e")
+	assert.Equal(t, base.ErrNoConditions, err)
+
+	req = "UPDATE `users` SET `email` = ? WHERE `users`.deleted_at IS NULL"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("e").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	n, err = test.NewUserQuerySet(db).AllowGlobalUpdate().SetFieldForAll(test.UserDBSchema.Email, "e")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+}
+
+func testAccountSetFieldForAllWithoutConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	// implicit active flag condition isn't explicit condition
+	_, err := test.NewAccountQuerySet(db).SetFieldForAll(test.AccountDBSchema.Name, "n")
+	assert.Equal(t, base.ErrNoConditions, err)
+}
+
+func testUserVerifySchema(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT column_name, data_type FROM information_schema.columns " +
+		"WHERE table_schema = DATABASE() AND table_name = ?"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+			AddRow("id", "int").
+			AddRow("created_at", "datetime").
+			AddRow("updated_at", "datetime").
+			AddRow("deleted_at", "datetime").
+			AddRow("name", "varchar"))
+
+	err := test.VerifyUserSchema(db)
+	assert.EqualError(t, err, "schema of table users differs from model: no column email")
+}
+
+func testPostgresUserVerifySchema(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT column_name, data_type FROM information_schema.columns " +
+		"WHERE table_schema = current_schema() AND table_name = $1"
+	rows := sqlmock.NewRows([]string{"column_name", "data_type"}).
+		AddRow("id", "integer").
+		AddRow("created_at", "timestamp with time zone").
+		AddRow("updated_at", "timestamp with time zone").
+		AddRow("deleted_at", "timestamp with time zone").
+		AddRow("name", "character varying").
+		AddRow("email", "character varying")
+	m.ExpectQuery(fixedFullRe(req)).WithArgs("users").WillReturnRows(rows)
+	assert.Nil(t, test.VerifyUserSchema(db))
+
+	rows = sqlmock.NewRows([]string{"column_name", "data_type"}).
+		AddRow("id", "integer").
+		AddRow("created_at", "timestamp with time zone").
+		AddRow("updated_at", "timestamp with time zone").
+		AddRow("deleted_at", "timestamp with time zone").
+		AddRow("name", "integer").
+		AddRow("email", "character varying")
+	m.ExpectQuery(fixedFullRe(req)).WithArgs("users").WillReturnRows(rows)
+	err := test.VerifyUserSchema(db)
+	assert.EqualError(t, err, "schema of table users differs from model: column name has type integer, expected string")
+}
+
+func testBookingTruncatedTimeConditions(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	at := time.Date(2020, 1, 1, 10, 0, 0, 123456789, time.UTC)
+	req := "SELECT * FROM `bookings` WHERE (start_at = ?) AND (end_at <= ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 1, 10, 0, 0, 123000000, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_at", "end_at"}))
+
+	var bookings []test.Booking
+	err := test.NewBookingQuerySet(db).StartAtEq(at).EndAtLte(at).All(&bookings)
+	assert.Nil(t, err)
+}
+
+func testUserFromDescription(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	createdBefore := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((name = ?) AND (id >= ?) AND (email > ?) AND (created_at < ?))"
+	for i := 0; i < 2; i++ {
+		m.ExpectQuery(fixedFullRe(req)).
+			WithArgs("a", 10, "b", createdBefore).
+			WillReturnRows(getRowsForUsers(nil))
+	}
+
+	qs := test.NewUserQuerySet(db).NameEq("a").IDGte(10).EmailGt("b").CreatedAtLt(createdBefore)
+	var users []test.User
+	assert.Nil(t, qs.All(&users))
+
+	// description is saved as JSON
+	desc, err := qs.Describe()
+	assert.Nil(t, err)
+	data, err := json.Marshal(desc)
+	assert.Nil(t, err)
+	var loaded base.QueryDescription
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	qs, err = test.NewUserQuerySet(db).FromDescription(loaded)
+	assert.Nil(t, err)
+	assert.Nil(t, qs.All(&users))
+
+	// conditions of other methods can't be described
+	_, err = test.NewUserQuerySet(db).NameEq("a").EmailContains("b").Describe()
+	assert.NotNil(t, err)
+	_, err = test.NewUserQuerySet(db).EmailContains("b").NameEq("a").Describe()
+	assert.NotNil(t, err)
+	desc, err = test.NewUserQuerySet(db).Describe()
+	assert.Nil(t, err)
+	assert.Empty(t, desc.Conditions)
+
+	invalid := []base.ConditionDescription{
+		{Field: "Password", Op: "eq", Value: "a"},
+		{Field: "Name", Op: "like", Value: "a"},
+		{Field: "ID", Op: "eq", Value: 1.5},
+		{Field: "Name", Op: "eq", Value: 1.0},
+	}
+	for _, c := range invalid {
+		_, err = test.NewUserQuerySet(db).FromDescription(base.QueryDescription{
+			Conditions: []base.ConditionDescription{c},
+		})
+		assert.NotNil(t, err, "%v", c)
+	}
+}
+
+func testTicketDescribeEnum(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	desc, err := test.NewTicketQuerySet(db).StatusEq("open").Describe()
+	assert.Nil(t, err)
+	assert.Equal(t, []base.ConditionDescription{{Field: "Status", Op: "eq", Value: "open"}},
+		desc.Conditions)
+
+	// enum fields aren't ordered
+	_, err = test.NewTicketQuerySet(db).FromDescription(base.QueryDescription{
+		Conditions: []base.ConditionDescription{{Field: "Status", Op: "gt", Value: "new"}},
+	})
+	assert.NotNil(t, err)
+}
+
+func testUserEachRow(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(3)
+	users[0].ID, users[1].ID, users[2].ID = 1, 2, 3
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY id ASC LIMIT 2")).
+		WillReturnRows(getRowsForUsers(users[:2]))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id > ?)) " +
+		"ORDER BY id ASC LIMIT 2")).
+		WithArgs(2).
+		WillReturnRows(getRowsForUsers(users[2:]))
+
+	var visited []test.User
+	err := test.NewUserQuerySet(base.WithEachRowChunkSize(db, 2)).EachRow(func(u test.User) error {
+		visited = append(visited, u)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, users, visited)
+
+	// no next chunk is loaded after error of callback
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY id ASC LIMIT 2")).
+		WillReturnRows(getRowsForUsers(users[:2]))
+	errStop := errors.New("stop")
+	visited = nil
+	err = test.NewUserQuerySet(base.WithEachRowChunkSize(db, 2)).EachRow(func(u test.User) error {
+		visited = append(visited, u)
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, users[:1], visited)
+
+	// order of query set is replaced by ID order of chunks
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY id ASC LIMIT 2")).
+		WillReturnRows(getRowsForUsers(users[:1]))
+	visited = nil
+	err = test.NewUserQuerySet(base.WithEachRowChunkSize(db, 2)).OrderDescByName().
+		EachRow(func(u test.User) error {
+			visited = append(visited, u)
+			return nil
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, users[:1], visited)
+
+	// chunks would skip or repeat records with offset or limit
+	fn := func(test.User) error { return nil }
+	assert.NotNil(t, test.NewUserQuerySet(db).Offset(10).EachRow(fn))
+	assert.NotNil(t, test.NewUserQuerySet(db).Limit(10).EachRow(fn))
+}
+
+func testUserMinMax(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT MIN(id), MAX(id) FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(3, 10))
+	min, max, err := test.NewUserQuerySet(db).NameEq("a").MinMaxID()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), min)
+	assert.Equal(t, uint(10), max)
+
+	// MIN and MAX of empty set are NULL
+	req = "SELECT MIN(updated_at), MAX(updated_at) FROM `users` WHERE `users`.deleted_at IS NULL"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(nil, nil))
+	minTime, maxTime, err := test.NewUserQuerySet(db).MinMaxUpdatedAt()
+	assert.Nil(t, err)
+	assert.True(t, minTime.IsZero())
+	assert.True(t, maxTime.IsZero())
+}
+
+type testResolver struct {
+	primary, replica *gorm.DB
+}
+
+func (r testResolver) Primary() *gorm.DB { return r.primary }
+func (r testResolver) Replica() *gorm.DB { return r.replica }
+
+func testUserUseReplica(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	replicaMock, replica := newDB()
+	defer checkMock(t, replicaMock)
+	db = base.WithResolver(db, testResolver{primary: db, replica: replica})
+
+	users := getTestUsers(2)
+	replicaMock.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))")).
+		WithArgs("a").
+		WillReturnRows(getRowsForUsers(users))
+	var ret []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).UseReplica().NameEq("a").All(&ret))
+	assert.Equal(t, users, ret)
+
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL")).
+		WillReturnRows(getRowsForUsers(users))
+	assert.Nil(t, test.NewUserQuerySet(db).UseReplica().UsePrimary().All(&ret))
+
+	u := getUserNoID()
+	req := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), u.Name, u.Email).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	assert.Nil(t, u.Create(base.UseReplica(db)))
+
+	// handle can't be selected after conditions
+	assert.NotNil(t, test.NewUserQuerySet(db).NameEq("a").UseReplica().All(&ret))
+	assert.Equal(t, base.ErrNoResolver, test.NewUserQuerySet(replica).UseReplica().All(&ret))
+}
+
+func testUserSearch(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((LOWER(name) LIKE ? ESCAPE ? OR LOWER(email) LIKE ? ESCAPE ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(`%jo\_n%`, `\`, `%jo\_n%`, `\`).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		Search("Jo_N", test.UserDBSchema.Name, test.UserDBSchema.Email).
+		All(&users)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, test.NewUserQuerySet(db).Search("a", test.UserDBSchema.ID).All(&users))
+	assert.NotNil(t, test.NewUserQuerySet(db).Search("a").All(&users))
+}
+
+type timeBetweenArg struct {
+	from, to time.Time
+}
+
+func (a timeBetweenArg) Match(v driver.Value) bool {
+	vt, ok := v.(time.Time)
+	return ok && !vt.Before(a.from) && !vt.After(a.to)
+}
+
+func testUserDeleteOlderThan(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	const retention = 30 * 24 * time.Hour
+	startedAt := time.Now()
+	req := "UPDATE `users` SET deleted_at=? WHERE `users`.deleted_at IS NULL AND ((name = ?) AND (created_at < ?))"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), "a", timeBetweenArg{
+			from: startedAt.Add(-retention),
+			to:   time.Now().Add(time.Minute - retention),
+		}).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := test.NewUserQuerySet(db).NameEq("a").DeleteOlderThan(test.UserDBSchema.CreatedAt, retention)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+
+	_, err = test.NewUserQuerySet(db).DeleteOlderThan(test.UserDBSchema.Name, retention)
+	assert.NotNil(t, err)
+}
+
+func testUserAllIndexedBy(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(3)
+	users[2].Email = users[0].Email
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL")).
+		WillReturnRows(getRowsForUsers(users))
+
+	var byEmail map[string]test.User
+	assert.Nil(t, test.NewUserQuerySet(db).AllIndexedBy(test.UserDBSchema.Email, &byEmail))
+	assert.Equal(t, map[string]test.User{
+		users[1].Email: users[1],
+		users[2].Email: users[2], // the last one wins
+	}, byEmail)
+
+	var byID map[string]test.User
+	assert.NotNil(t, test.NewUserQuerySet(db).AllIndexedBy(test.UserDBSchema.ID, &byID))
+	assert.NotNil(t, test.NewUserQuerySet(db).AllIndexedBy(test.UserDBSchema.CreatedAt, &byID))
+}
+
+func testTicketAssigneeEqNullable(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `tickets` WHERE (assignee = ?)")).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `tickets` WHERE (assignee IS NULL)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var tickets []test.Ticket
+	qs := test.NewTicketQuerySet(db)
+	assert.Nil(t, qs.AssigneeEqNullable(sql.NullString{String: "bob", Valid: true}).All(&tickets))
+	assert.Nil(t, qs.AssigneeEqNullable(sql.NullString{}).All(&tickets))
+}
+
+func testBookingOrderByAPIField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `bookings` ORDER BY start_at DESC")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	qs, err := test.NewBookingQuerySet(db).OrderByAPIField("start", true)
+	assert.Nil(t, err)
+	var bookings []test.Booking
+	assert.Nil(t, qs.All(&bookings))
+
+	_, err = test.NewBookingQuerySet(db).OrderByAPIField("start_at", false)
+	assert.NotNil(t, err)
+}
+
+func testUserCountByHour(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT DATE_FORMAT(created_at, '%Y-%m-%d %H:00:00') AS hour, count(*) FROM `users` " +
+		"WHERE `users`.deleted_at IS NULL GROUP BY hour ORDER BY `hour`"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"hour", "count(*)"}).
+			AddRow([]byte("2020-01-02 10:00:00"), 2).
+			AddRow([]byte("2020-01-02 11:00:00"), 5))
+
+	counts, err := test.NewUserQuerySet(db).CountByHour(test.UserDBSchema.CreatedAt)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.HourCount{
+		{Hour: time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC), Count: 2},
+		{Hour: time.Date(2020, 1, 2, 11, 0, 0, 0, time.UTC), Count: 5},
+	}, counts)
+
+	_, err = test.NewUserQuerySet(db).CountByHour(test.UserDBSchema.Name)
+	assert.NotNil(t, err)
+}
+
+func testUserCreateIfNotMatched(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUserNoID()
+	check := "SELECT 1 FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?)) LIMIT 1 FOR UPDATE"
+	insert := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	m.ExpectBegin()
+	m.ExpectQuery(fixedFullRe(check)).
+		WithArgs(u.Email).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	m.ExpectExec(fixedFullRe(insert)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), u.Name, u.Email).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	m.ExpectCommit()
+
+	created, err := test.NewUserQuerySet(db).EmailEq(u.Email).CreateIfNotMatched(&u)
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, uint(2), u.ID)
+
+	// matching record exists
+	m.ExpectBegin()
+	m.ExpectQuery(fixedFullRe(check)).
+		WithArgs(u.Email).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	m.ExpectCommit()
+
+	created, err = test.NewUserQuerySet(db).EmailEq(u.Email).CreateIfNotMatched(&test.User{Email: u.Email})
+	assert.Nil(t, err)
+	assert.False(t, created)
+}
+
+func testUserStringComparisons(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((name >= ?) AND (name < ?) AND (email > ?) AND (email <= ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a", "n", "a@", "z@").
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		NameGte("a").
+		NameLt("n").
+		EmailGt("a@").
+		EmailLte("z@").
+		All(&users)
+	assert.Nil(t, err)
+}
+
+func testUserInChunked(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id IN (?,?)))")).
+		WithArgs(1, 2).
+		WillReturnRows(getRowsForUsers(nil))
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((id IN (?,?) OR id IN (?,?) OR id IN (?)))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(1, 2, 3, 4, 5).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	qs := test.NewUserQuerySet(base.WithMaxInListSize(db, 2))
+	assert.Nil(t, qs.IDIn(1, 2).All(&users))
+	assert.Nil(t, qs.IDIn(1, 2, 3, 4, 5).All(&users))
+}
+
+func testUserSelectByIDIn(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id IN (?,?,?)))")).
+		WithArgs(1, 2, 3).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).IDIn(1, 2, 3).All(&users))
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserSelectByIDNotIn(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	expUsers := getTestUsers(2)
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND " +
+		"((id NOT IN (?,?) AND id NOT IN (?)))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(1, 2, 3).
+		WillReturnRows(getRowsForUsers(expUsers))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(base.WithMaxInListSize(db, 2)).IDNotIn(1, 2, 3).All(&users))
+	assert.Equal(t, expUsers, users)
+}
+
+func testUserSelectByEmptyIn(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((1 = 0))")).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL")).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).IDIn().All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).IDNotIn().All(&users))
+}
+
+func testDocumentTenantScope(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `documents` WHERE (title = ?) AND (tenant_id = ?)")).
+		WithArgs("a", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "title"}))
+	m.ExpectExec(fixedFullRe("UPDATE `documents` SET `title` = ? WHERE (id = ?) AND (tenant_id = ?)")).
+		WithArgs("b", 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe("DELETE FROM `documents` WHERE (id = ?) AND (tenant_id = ?)")).
+		WithArgs(1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var docs []test.Document
+	qs := test.NewDocumentQuerySetForTenant(db, 7)
+	assert.Nil(t, qs.TitleEq("a").All(&docs))
+	assert.Nil(t, qs.IDEq(1).GetUpdater().SetTitle("b").Update())
+	assert.Nil(t, qs.IDEq(1).Delete())
+}
+
+func testDocumentTenantStructMethods(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectExec(fixedFullRe("UPDATE `documents` SET `title` = ? WHERE `documents`.`id` = ? AND ((tenant_id = ?))")).
+		WithArgs("b", 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe("DELETE FROM `documents` WHERE `documents`.`id` = ? AND ((tenant_id = ?))")).
+		WithArgs(1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	doc := test.Document{ID: 1, TenantID: 7, Title: "b"}
+	assert.Nil(t, doc.Update(db, 7, test.DocumentDBSchema.Title))
+	assert.Nil(t, doc.Delete(db, 7))
+}
+
+func testDocumentTenantCreate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "INSERT INTO `documents` (`tenant_id`,`title`) VALUES (?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(7, "a").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `documents` WHERE (title = ?) AND (tenant_id = ?) ORDER BY `documents`.`id` ASC LIMIT 1")).
+		WithArgs("b", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "title"}))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(7, "b").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(7, "c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(7, "d").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// tenant is assigned even if it's set to other one
+	doc := test.Document{TenantID: 8, Title: "a"}
+	assert.Nil(t, doc.Create(db, 7))
+	assert.Equal(t, uint(7), doc.TenantID)
+
+	// created record is visible for query set
+	ret, created, err := test.NewDocumentQuerySetForTenant(db, 7).TitleEq("b").GetOrCreate(&test.Document{})
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, test.Document{ID: 2, TenantID: 7, Title: "b"}, ret)
+
+	docs := []test.Document{{Title: "c"}}
+	assert.Nil(t, test.DocumentCreateBatch(db, 7, docs))
+	assert.Equal(t, uint(7), docs[0].TenantID)
+
+	ch := make(chan test.Document, 1)
+	ch <- test.Document{Title: "d"}
+	close(ch)
+	n, err := test.DocumentCreateFromChan(db, 7, ch, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testDocumentTenantColumnIsNotUpdated(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	// records can't be moved to other tenant
+	doc := test.Document{ID: 1, TenantID: 8}
+	assert.NotNil(t, doc.Update(db, 7, test.DocumentDBSchema.TenantID))
+
+	qs := test.NewDocumentQuerySetForTenant(db, 7).IDEq(1)
+	_, err := qs.SetFieldForAll(test.DocumentDBSchema.TenantID, 8)
+	assert.NotNil(t, err)
+
+	updaterType := reflect.TypeOf(qs.GetUpdater())
+	for _, name := range []string{"SetTenantID", "IncrementTenantID", "DecrementTenantID"} {
+		_, ok := updaterType.MethodByName(name)
+		assert.False(t, ok, name)
+	}
+	_, ok := updaterType.MethodByName("SetTitle")
+	assert.True(t, ok)
+
+	// updater without tenant condition can't be constructed
+	src, err := ioutil.ReadFile("test/autogenerated_models.go")
+	assert.Nil(t, err)
+	assert.NotContains(t, string(src), "func NewDocumentUpdater(")
+}
+
+func testUserOrderByMultipleFields(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"ORDER BY created_at DESC,name ASC"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(getTestUsers(2)))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).OrderDescByCreatedAt().OrderAscByName().All(&users)
+	assert.Nil(t, err)
+}
+
+func testInvoiceDeleteWithAudit(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	req := "UPDATE `invoices` SET `deleted_at` = ?, `deleted_by` = ?, `delete_reason` = ? " +
+		"WHERE `invoices`.deleted_at IS NULL AND ((number = ?))"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(recentTimeArg{startedAt}, "admin", "duplicate", "N-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := test.NewInvoiceQuerySet(db).NumberEq("N-1").DeleteWithAudit("admin", "duplicate")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testJobNextReady(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	req := "SELECT * FROM `jobs` WHERE (payload != ?) AND (ready_at <= ?) " +
+		"ORDER BY priority DESC,ready_at ASC LIMIT 2 FOR UPDATE SKIP LOCKED"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("", recentTimeArg{startedAt}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "priority", "ready_at"}).
+			AddRow(3, "b", 10, startedAt.Add(-time.Minute)).
+			AddRow(1, "a", 0, startedAt.Add(-time.Hour)))
+
+	jobs, err := test.NewJobQuerySet(db).PayloadNe("").NextReady(2)
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, 10, jobs[0].Priority)
+}
+
+func testUserSelectPage(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL ORDER BY id ASC LIMIT 20 OFFSET 40"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(getTestUsers(2)))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).OrderAscByID().Limit(20).Offset(40).All(&users))
+	assert.Len(t, users, 2)
+}
+
+func testUserSelectNegativeLimitOffset(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL OFFSET 10")).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL LIMIT 5")).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).Limit(-1).Offset(10).All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).Limit(5).Offset(-1).All(&users))
+}
+
+func testUserStatSelectAboveAverage(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `user_stats` WHERE " +
+		"(posts_count > (SELECT AVG(posts_count) FROM `user_stats` WHERE (user_id > ?))) AND (user_id < ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(10, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "posts_count"}).AddRow(11, 5))
+
+	avg := test.NewUserStatQuerySet(db).UserIDGt(10).
+		ScalarSubQuery(base.AggregateAvg, test.UserStatDBSchema.PostsCount)
+	var stats []test.UserStat
+	err := test.NewUserStatQuerySet(db).
+		FieldGtScalarSubQuery(test.UserStatDBSchema.PostsCount, avg).
+		UserIDLt(100).
+		All(&stats)
+	assert.Nil(t, err)
+	assert.Len(t, stats, 1)
+
+	bad := test.NewUserStatQuerySet(db).ScalarSubQuery("SLEEP", test.UserStatDBSchema.PostsCount)
+	err = test.NewUserStatQuerySet(db).FieldGtScalarSubQuery(test.UserStatDBSchema.PostsCount, bad).All(&stats)
+	assert.EqualError(t, err, `unknown aggregate "SLEEP" of subquery`)
+}
+
+func testUserWithContext(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT count(*) FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?))")).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	n, err := test.NewUserQuerySet(db).WithContext(ctx).NameEq("name").Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	// no queries are executed with expired context
+	expiredCtx, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	qs := test.NewUserQuerySet(db).WithContext(expiredCtx).NameEq("name")
+	var users []test.User
+	var u test.User
+	assert.Equal(t, context.DeadlineExceeded, qs.All(&users))
+	assert.Equal(t, context.DeadlineExceeded, qs.One(&u))
+	_, err = qs.Count()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, context.DeadlineExceeded, qs.Delete())
+	assert.Equal(t, context.DeadlineExceeded, qs.GetUpdater().SetEmail("e").Update())
+	assert.Equal(t, context.DeadlineExceeded, u.Create(base.WithContext(db, expiredCtx)))
+}
+
+func testUserSelectByNameLike(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name LIKE ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a_%").
+		WillReturnRows(getRowsForUsers(nil))
+	req = "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name LIKE ? ESCAPE ?))"
+	for _, pattern := range []string{`%a\_b\%c%`, `a\_%`, `%\\a`} {
+		m.ExpectQuery(fixedFullRe(req)).
+			WithArgs(pattern, `\`).
+			WillReturnRows(getRowsForUsers(nil))
+	}
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).NameLike("a_%").All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).NameContains("a_b%c").All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).NameStartsWith("a_").All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).NameEndsWith(`\a`).All(&users))
+}
+
+func testUserSelectNestedAndOr(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?) AND " +
+		"(((name = ?)) OR ((((name = ?) AND (id > ?))))))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("a", "b", "c", 1).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).EmailEq("a").Or(
+		func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.NameEq("b")
+		},
+		func(qs test.UserQuerySet) test.UserQuerySet {
+			return qs.And(func(qs test.UserQuerySet) test.UserQuerySet {
+				return qs.NameEq("c").IDGt(1)
+			})
+		},
+	).All(&users)
+	assert.Nil(t, err)
+}
+
+func testJobWriteNDJSON(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `jobs` ORDER BY id ASC LIMIT 1000")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(1, "a").
+			AddRow(2, "b").
+			AddRow(3, "c"))
+
+	var buf bytes.Buffer
+	assert.Nil(t, test.NewJobQuerySet(db).WriteNDJSON(&buf))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 3)
+	for i, line := range lines {
+		var row map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &row), line)
+		assert.Equal(t, float64(i+1), row["id"])
+		assert.Equal(t, string(rune('a'+i)), row["payload"])
+		assert.Contains(t, row, "ClaimedBy") // field without json tag
+	}
+}
+
+func testUserSelectByRawClause(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?) AND (LENGTH(email) > ?)) " +
+		"ORDER BY id ASC LIMIT 2"
+	for i := 0; i < 2; i++ {
+		m.ExpectQuery(fixedFullRe(req)).
+			WithArgs("a", 3).
+			WillReturnRows(getRowsForUsers(nil))
+	}
+
+	var users []test.User
+	qs := test.NewUserQuerySet(db).NameEq("a").OrderAscByID().Limit(2)
+	assert.Nil(t, qs.GetDB().Where("LENGTH(email) > ?", 3).Find(&users).Error)
+
+	err := qs.Scopes(func(db *gorm.DB) *gorm.DB {
+		return db.Where("LENGTH(email) > ?", 3)
+	}).All(&users)
+	assert.Nil(t, err)
+}
+
+func testPlaceWithinBoundingBox(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `places` WHERE (name != ?) AND " +
+		"(lat >= ? AND lat <= ? AND (lng >= ? AND lng <= ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("", 55.5, 56.0, 37.3, 37.9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "lat", "lng"}).AddRow(1, "a", 55.75, 37.61))
+	// box crossing the antimeridian
+	req = "SELECT * FROM `places` WHERE (lat >= ? AND lat <= ? AND (lng >= ? OR lng <= ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(-20.0, -10.0, 170.0, -170.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "lat", "lng"}))
+
+	var places []test.Place
+	err := test.NewPlaceQuerySet(db).NameNe("").WithinBoundingBox(55.5, 37.3, 56.0, 37.9).All(&places)
+	assert.Nil(t, err)
+	assert.Len(t, places, 1)
+	assert.Nil(t, test.NewPlaceQuerySet(db).WithinBoundingBox(-20, 170, -10, -170).All(&places))
+}
+
+func testTicketTagsContains(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tickets` WHERE (status = ?) AND (JSON_CONTAINS(tags, ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("open", `"go"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "tags"}).AddRow(1, "open", `["go","db"]`))
+
+	var tickets []test.Ticket
+	err := test.NewTicketQuerySet(db).StatusEq("open").TagsContains("go").All(&tickets)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.Ticket{{ID: 1, Status: "open", Tags: `["go","db"]`}}, tickets)
+}
+
+func testPostgresTicketTagsContains(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe(`SELECT * FROM "tickets" WHERE (tags @> $1)`)).
+		WithArgs(`["go"]`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tags"}))
+
+	var tickets []test.Ticket
+	assert.Nil(t, test.NewTicketQuerySet(db).TagsContains("go").All(&tickets))
+	assert.Empty(t, tickets)
+}
+
+func testUserGetOrCreate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUserNoID()
+	lookup := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((email = ?)) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1"
+	insert := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	m.ExpectQuery(fixedFullRe(lookup)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectExec(fixedFullRe(insert)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), u.Name, u.Email).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	user, created, err := test.NewUserQuerySet(db).EmailEq(u.Email).GetOrCreate(&u)
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, uint(2), user.ID)
+	assert.Equal(t, u.Name, user.Name)
+
+	// matching record exists
+	existing := getTestUsers(1)
+	m.ExpectQuery(fixedFullRe(lookup)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers(existing))
+
+	user, created, err = test.NewUserQuerySet(db).EmailEq(u.Email).GetOrCreate(&test.User{Email: u.Email})
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existing[0], user)
+
+	// attrs get values of equality conditions
+	m.ExpectQuery(fixedFullRe(lookup)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectExec(fixedFullRe(insert)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "x", u.Email).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	user, created, err = test.NewUserQuerySet(db).EmailEq(u.Email).GetOrCreate(&test.User{Name: "x"})
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, u.Email, user.Email)
+	assert.Equal(t, "x", user.Name)
+
+	// record was created concurrently
+	m.ExpectQuery(fixedFullRe(lookup)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectExec(fixedFullRe(insert)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "x", u.Email).
+		WillReturnError(errors.New("Error 1062: Duplicate entry 'u@example.com' for key 'email'"))
+	m.ExpectQuery(fixedFullRe(lookup)).
+		WithArgs(u.Email).
+		WillReturnRows(getRowsForUsers(existing))
+
+	user, created, err = test.NewUserQuerySet(db).EmailEq(u.Email).GetOrCreate(&test.User{Name: "x"})
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existing[0], user)
+}
+
+func testJobCreateFromChan(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	row := "(?,?,?,?,?)"
+	req := "INSERT INTO `jobs` (`payload`,`claimed_by`,`claimed_at`,`priority`,`ready_at`) VALUES "
+	for _, n := range []int{2, 2, 1} {
+		rows := strings.TrimSuffix(strings.Repeat(row+",", n), ",")
+		args := []driver.Value{}
+		for i := 0; i < n*5; i++ {
+			args = append(args, sqlmock.AnyArg())
+		}
+		m.ExpectExec(fixedFullRe(req + rows)).
+			WithArgs(args...).
+			WillReturnResult(sqlmock.NewResult(0, int64(n)))
+	}
+
+	ch := make(chan test.Job)
+	go func() {
+		for i := 0; i < 5; i++ {
+			ch <- test.Job{Payload: fmt.Sprintf("job %d", i)}
+		}
+		close(ch)
+	}()
+
+	n, err := test.JobCreateFromChan(db, ch, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+}
+
+func testPostgresUserStatPercentile(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT PERCENTILE_CONT($1) WITHIN GROUP (ORDER BY posts_count) FROM "user_stats" WHERE (flags & $2 = $3)`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(0.95, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"percentile_cont"}).AddRow(17.5))
+
+	p, err := test.NewUserStatQuerySet(db).
+		FlagsHasFlag(1).
+		Percentile(test.UserStatDBSchema.PostsCount, 0.95)
+	assert.Nil(t, err)
+	assert.Equal(t, 17.5, p)
+
+	_, err = test.NewUserQuerySet(db).Percentile(test.UserDBSchema.Name, 0.5)
+	assert.NotNil(t, err)
+	_, err = test.NewUserStatQuerySet(db).Percentile(test.UserStatDBSchema.PostsCount, 1.5)
+	assert.NotNil(t, err)
+}
+
+func testUserStatPercentileApprox(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectQuery(fixedFullRe("SELECT count(*) FROM `user_stats` WHERE (posts_count IS NOT NULL)")).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	req := "SELECT posts_count FROM `user_stats` WHERE (posts_count IS NOT NULL) " +
+		"ORDER BY posts_count LIMIT 1 OFFSET 3"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"posts_count"}).AddRow(12))
+
+	p, err := test.NewUserStatQuerySet(db).Percentile(test.UserStatDBSchema.PostsCount, 0.75)
+	assert.Nil(t, err)
+	assert.Equal(t, 12.0, p)
+}
+
+func testUserCreateBatch(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := []test.User{getUserNoID(), getUserNoID(), getUserNoID()}
+	req := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) " +
+		"VALUES (?,?,?,?,?),(?,?,?,?,?),(?,?,?,?,?)"
+	args := []driver.Value{}
+	for _, u := range users {
+		args = append(args, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, u.Name, u.Email)
+	}
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(10, 3))
+
+	// MySQL doesn't return IDs of all created rows
+	assert.Nil(t, test.UserCreateBatch(db, users))
+	for _, u := range users {
+		assert.Zero(t, u.ID)
+		assert.False(t, u.CreatedAt.IsZero())
+	}
+
+	assert.Nil(t, test.UserCreateBatch(db, nil))
+
+	// split by max count of placeholders: 2 rows of 5 columns in one transaction
+	req = "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES "
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe(req + "(?,?,?,?,?),(?,?,?,?,?)")).
+		WithArgs(args[:10]...).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	m.ExpectExec(fixedFullRe(req + "(?,?,?,?,?)")).
+		WithArgs(args[10:]...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+	assert.Nil(t, test.UserCreateBatch(base.WithMaxBatchVars(db, 12), users))
+
+	// the first chunk is rolled back if the second one fails
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe(req + "(?,?,?,?,?),(?,?,?,?,?)")).
+		WithArgs(args[:10]...).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	m.ExpectExec(fixedFullRe(req + "(?,?,?,?,?)")).
+		WithArgs(args[10:]...).
+		WillReturnError(errors.New("connection reset"))
+	m.ExpectRollback()
+	assert.NotNil(t, test.UserCreateBatch(base.WithMaxBatchVars(db, 12), users))
+
+	// zero primary keys can't be inserted with set ones
+	users[1].ID = 5
+	err := test.UserCreateBatch(db, users)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "primary key ID is set only for some records")
+	}
+}
+
+func testTicketCreateBatchValidates(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	// nothing is inserted if any record is invalid
+	tickets := []test.Ticket{{Status: "new"}, {Status: "deleted"}}
+	err := test.TicketCreateBatch(db, tickets)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `invalid value "deleted"`)
+	}
+
+	ch := make(chan test.Ticket, 1)
+	ch <- test.Ticket{Status: "deleted"}
+	close(ch)
+	n, err := test.TicketCreateFromChan(db, ch, 2)
+	assert.NotNil(t, err)
+	assert.Zero(t, n)
+}
+
+func testBlogCreateBatchSetsAutoTime(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	startedAt := time.Now()
+	req := "INSERT INTO `blogs` (`created_at`,`updated_at`,`deleted_at`,`name`,`refreshed_at`) VALUES (?,?,?,?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "blog", recentTimeArg{startedAt}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "feed", recentTimeArg{startedAt}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	blogs := []test.Blog{{Name: "blog"}}
+	assert.Nil(t, test.BlogCreateBatch(db, blogs))
+	assert.False(t, blogs[0].RefreshedAt.IsZero())
+
+	ch := make(chan test.Blog, 1)
+	ch <- test.Blog{Name: "feed"}
+	close(ch)
+	n, err := test.BlogCreateFromChan(db, ch, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testPostgresUserCreateBatch(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := []test.User{getUserNoID(), getUserNoID()}
+	req := `INSERT INTO "users" ("created_at","updated_at","deleted_at","name","email") ` +
+		`VALUES ($1,$2,$3,$4,$5),($6,$7,$8,$9,$10) RETURNING "id"`
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7).AddRow(9))
+
+	assert.Nil(t, test.UserCreateBatch(db, users))
+	assert.Equal(t, uint(7), users[0].ID)
+	assert.Equal(t, uint(9), users[1].ID)
+}
+
+func testUserFieldAggregates(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT SUM(priority) FROM `jobs` WHERE (payload = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("x").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow([]byte("12")))
+	// no matching records: AVG is NULL
+	req = "SELECT AVG(priority) FROM `jobs` WHERE (payload = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("y").
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(nil))
+	req = "SELECT MAX(priority) FROM `jobs` WHERE (payload = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("x").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(7))
+
+	// integer sum and bounds have type of field
+	total, err := test.NewJobQuerySet(db).PayloadEq("x").SumPriority()
+	assert.Nil(t, err)
+	assert.Equal(t, 12, total)
+
+	avg, err := test.NewJobQuerySet(db).PayloadEq("y").AvgPriority()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, avg)
+
+	max, err := test.NewJobQuerySet(db).PayloadEq("x").MaxPriority()
+	assert.Nil(t, err)
+	assert.Equal(t, 7, max)
+
+	// there are no aggregates of primary keys
+	_, ok := reflect.TypeOf(test.UserQuerySet{}).MethodByName("SumID")
+	assert.False(t, ok)
+}
+
+func testTierValueInFieldRange(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tiers` WHERE (min_amount <= ? AND max_amount >= ?) ORDER BY `tiers`.`id` ASC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(150, 150).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "min_amount", "max_amount"}).
+			AddRow(2, "silver", 100, 499))
+
+	var tier test.Tier
+	err := test.NewTierQuerySet(db).
+		ValueInFieldRange(test.TierDBSchema.MinAmount, test.TierDBSchema.MaxAmount, 150).
+		One(&tier)
+	assert.Nil(t, err)
+	assert.Equal(t, "silver", tier.Name)
+
+	err = test.NewTierQuerySet(db).
+		ValueInFieldRange(test.TierDBSchema.MinAmount, test.TierDBSchema.Name, 150).
+		One(&tier)
+	assert.NotNil(t, err)
+}
+
+func testUserDeleteHard(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectExec(fixedFullRe("DELETE FROM `users` WHERE (email = ?)")).
+		WithArgs("qs@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// without soft delete it's the same as Delete
+	m.ExpectExec(fixedFullRe("DELETE FROM `jobs` WHERE (payload = ?)")).
+		WithArgs("p").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// deactivated records are deleted too
+	m.ExpectExec(fixedFullRe("DELETE FROM `accounts` WHERE (name = ?)")).
+		WithArgs("a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := test.NewUserQuerySet(db).EmailEq("qs@mail.ru").DeleteHard()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = test.NewJobQuerySet(db).PayloadEq("p").DeleteHard()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	_, err = test.NewAccountQuerySet(db).NameEq("a").DeleteHard()
+	assert.Nil(t, err)
+}
+
+func testUserAllWithHasMore(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL ORDER BY id ASC LIMIT 3"
+	expUsers := getTestUsers(3)
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(expUsers))
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(getRowsForUsers(expUsers[:2]))
+
+	var users []test.User
+	hasMore, err := test.NewUserQuerySet(db).OrderAscByID().AllWithHasMore(2, &users)
+	assert.Nil(t, err)
+	assert.True(t, hasMore)
+	assert.Equal(t, expUsers[:2], users)
+
+	hasMore, err = test.NewUserQuerySet(db).OrderAscByID().AllWithHasMore(2, &users)
+	assert.Nil(t, err)
+	assert.False(t, hasMore)
+	assert.Len(t, users, 2)
+}
+
+func testJobWithRetry(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	deadlock := errors.New("Error 1213: Deadlock found when trying to get lock; try restarting transaction")
+	req := "UPDATE `jobs` SET `claimed_by` = ? WHERE (payload = ?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("w", "p").
+		WillReturnError(deadlock)
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("w", "p").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// not retryable error is returned immediately
+	m.ExpectExec(fixedFullRe("DELETE FROM `jobs` WHERE (payload = ?)")).
+		WithArgs("p").
+		WillReturnError(errors.New("Error 1146: Table 'jobs' doesn't exist"))
+
+	qs := test.NewJobQuerySet(db).WithRetry(3, time.Millisecond).PayloadEq("p")
+	assert.Nil(t, qs.GetUpdater().SetClaimedBy("w").Update())
+	assert.NotNil(t, qs.Delete())
+}
+
+func testSessionModifyByUUID(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	s := test.Session{UUID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", Token: "t"}
+	m.ExpectExec(fixedFullRe("UPDATE `sessions` SET `token` = ? WHERE `sessions`.`uuid` = ?")).
+		WithArgs(s.Token, s.UUID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe("DELETE FROM `sessions` WHERE `sessions`.`uuid` = ?")).
+		WithArgs(s.UUID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, s.Update(db, test.SessionDBSchema.Token))
+	assert.Nil(t, s.Delete(db))
+
+	// not set primary key would update and delete all records
+	noPK := test.Session{Token: "t"}
+	assert.NotNil(t, noPK.Update(db, test.SessionDBSchema.Token))
+	assert.NotNil(t, noPK.Delete(db))
+}
+
+func testMembershipDeleteByCompositeKey(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "DELETE FROM `memberships` WHERE `memberships`.`group_id` = ? AND `memberships`.`user_id` = ?"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ms := test.Membership{GroupID: 1, UserID: 2}
+	assert.Nil(t, ms.Delete(db))
+	ms.UserID = 0
+	assert.NotNil(t, ms.Delete(db))
+}
+
+func testTierContinueAfter(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	last := test.Tier{ID: 2, Name: "silver"}
+	cursor, err := last.KeysetCursor(false, test.TierDBSchema.Name, test.TierDBSchema.ID)
+	assert.Nil(t, err)
+
+	req := "SELECT * FROM `tiers` WHERE ((name, id) > (?, ?)) ORDER BY name ASC,id ASC LIMIT 10"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("silver", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "silver"))
+
+	qs, err := test.NewTierQuerySet(db).OrderAscByName().OrderAscByID().ContinueAfter(cursor)
+	assert.Nil(t, err)
+	var tiers []test.Tier
+	assert.Nil(t, qs.Limit(10).All(&tiers))
+	assert.Len(t, tiers, 1)
+
+	// descending order and values of time fields
+	createdAt := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	lastUser := test.User{Name: "u"}
+	lastUser.ID, lastUser.CreatedAt = 5, createdAt
+	cursor, err = lastUser.KeysetCursor(true, test.UserDBSchema.CreatedAt, test.UserDBSchema.ID)
+	assert.Nil(t, err)
+
+	req = "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND (((created_at, id) < (?, ?))) " +
+		"ORDER BY created_at DESC,id DESC"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(createdAt, 5).
+		WillReturnRows(getRowsForUsers(nil))
+
+	uqs, err := test.NewUserQuerySet(db).OrderDescByCreatedAt().OrderDescByID().ContinueAfter(cursor)
+	assert.Nil(t, err)
+	var users []test.User
+	assert.Nil(t, uqs.All(&users))
+
+	_, err = test.NewTierQuerySet(db).ContinueAfter("invalid")
+	assert.NotNil(t, err)
+}
+
+func testUserPreloadPosts(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	users := getTestUsers(2)
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL")).
+		WillReturnRows(getRowsForUsers(users))
+	req := "SELECT * FROM `posts` WHERE `posts`.deleted_at IS NULL AND ((`user_id` IN (?,?)))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(users[0].ID, users[1].ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).
+			AddRow(1, users[1].ID, "a"))
+
+	var ret []test.User
+	err := test.NewUserQuerySet(db).PreloadPosts().All(&ret)
+	assert.Nil(t, err)
+	assert.Len(t, ret, 2)
+	assert.Nil(t, ret[0].Posts)
+	assert.Len(t, ret[1].Posts, 1)
+}
+
+func testUserInTransaction(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUser()
+	newUser := getUserNoID()
+	m.ExpectBegin()
+	req := "UPDATE `users` SET `name` = ? WHERE `users`.deleted_at IS NULL AND ((email = ?))"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(u.Name, u.Email).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	req = "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), newUser.Name, newUser.Email).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	m.ExpectCommit()
+
+	err := test.NewUserQuerySet(db).EmailEq(u.Email).InTransaction(func(tx *gorm.DB, qs test.UserQuerySet) error {
+		if err := qs.GetUpdater().SetName(u.Name).Update(); err != nil {
+			return err
+		}
+		return newUser.Create(tx)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), newUser.ID)
+
+	m.ExpectBegin()
+	m.ExpectRollback()
+	fnErr := errors.New("fn error")
+	err = test.NewUserQuerySet(db).InTransaction(func(tx *gorm.DB, qs test.UserQuerySet) error {
+		return fnErr
+	})
+	assert.Equal(t, fnErr, err)
+}
+
+func testUserLockTable(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe("LOCK TABLES `users` WRITE")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(fixedFullRe("DELETE FROM `users` WHERE (email = ?)")).
+		WithArgs("qs@mail.ru").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe("UNLOCK TABLES")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectCommit()
+
+	err := test.NewUserQuerySet(db).InTransaction(func(tx *gorm.DB, qs test.UserQuerySet) error {
+		if err := qs.LockTable("write"); err != nil {
+			return err
+		}
+		if _, err := qs.EmailEq("qs@mail.ru").DeleteHard(); err != nil {
+			return err
+		}
+		return qs.UnlockTables()
+	})
+	assert.Nil(t, err)
+
+	// lock is held by connection: it must be acquired in transaction
+	assert.NotNil(t, test.NewUserQuerySet(db).LockTable("WRITE"))
+	assert.NotNil(t, test.NewUserQuerySet(db).UnlockTables())
+}
+
+func testPostgresUserLockTable(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe(`LOCK TABLE "users" IN SHARE ROW EXCLUSIVE MODE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectRollback()
+
+	err := test.NewUserQuerySet(db).InTransaction(func(tx *gorm.DB, qs test.UserQuerySet) error {
+		if err := qs.LockTable("SHARE ROW EXCLUSIVE"); err != nil {
+			return err
+		}
+		// locks are released at the end of transaction
+		assert.NotNil(t, qs.UnlockTables())
+		return qs.LockTable("WRITE") // MySQL mode
+	})
+	assert.NotNil(t, err)
+}
+
+func testUserSelect(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT id, email FROM `users` WHERE `users`.deleted_at IS NULL AND ((name = ?)) ORDER BY id DESC"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "qs@mail.ru"))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `users` WHERE `users`.deleted_at IS NULL")).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	err := test.NewUserQuerySet(db).
+		Select(test.UserDBSchema.ID, test.UserDBSchema.Email).
+		NameEq("name").
+		OrderDescByID().
+		All(&users)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.User{{Model: gorm.Model{ID: 1}, Email: "qs@mail.ru"}}, users)
+
+	assert.Nil(t, test.NewUserQuerySet(db).Select().All(&users))
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func testUserCreateDuplicateKey(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	u := getUserNoID()
+	req := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), u.Name, u.Email).
+		WillReturnError(errors.New("Error 1062: Duplicate entry 'qs@mail.ru' for key 'email'"))
+
+	err := u.Create(db)
+	assert.NotNil(t, err)
+	assert.Equal(t, "1062", base.ErrorCode(err))
+	assert.True(t, base.IsDuplicateKey(err))
+	assert.True(t, base.IsDuplicateKey(fmt.Errorf("create user: %w", err)))
+
+	assert.Equal(t, "23505", base.ErrorCode(sqlStateError("23505")))
+	assert.True(t, base.IsDuplicateKey(sqlStateError("23505")))
+	assert.False(t, base.IsDuplicateKey(sqlStateError("23503")))
+	assert.False(t, base.IsDuplicateKey(errors.New("Error 1213: Deadlock found")))
+	assert.False(t, base.IsDuplicateKey(nil))
+}
+
+func testUserIntoTempTable(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	m.ExpectBegin()
+	req := "CREATE TEMPORARY TABLE `active_users` AS SELECT * FROM `users` " +
+		"WHERE `users`.deleted_at IS NULL AND ((name = ?)) ORDER BY id ASC"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs("name").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	m.ExpectCommit()
+
+	err := test.NewUserQuerySet(db).NameEq("name").OrderAscByID().
+		InTransaction(func(tx *gorm.DB, qs test.UserQuerySet) error {
+			return qs.IntoTempTable("active_users")
+		})
+	assert.Nil(t, err)
+
+	// temporary table is visible only to connection
+	assert.NotNil(t, test.NewUserQuerySet(db).IntoTempTable("active_users"))
+}
+
+func testUserCountByFieldWithRollup(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT name, GROUPING(name), count(*) FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"GROUP BY name WITH ROLLUP"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "GROUPING(name)", "count(*)"}).
+			AddRow([]byte("a"), 0, 2).
+			AddRow(nil, 0, 1).
+			AddRow(nil, 1, 3))
+
+	counts, err := test.NewUserQuerySet(db).CountByFieldWithRollup(test.UserDBSchema.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.RollupEntry{
+		{Value: "a", Count: 2},
+		{Value: nil, Count: 1},
+		{Value: nil, Count: 3, Total: true},
+	}, counts)
+}
+
+func testPostgresUserCountByFieldWithRollup(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT name, GROUPING(name), count(*) FROM "users" WHERE "users".deleted_at IS NULL ` +
+		"GROUP BY GROUPING SETS ((name), ())"
+	m.ExpectQuery(fixedFullRe(req)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "grouping", "count"}).
+			AddRow("a", 0, 2).
+			AddRow("b", 0, 1).
+			AddRow(nil, 1, 3))
+
+	counts, err := test.NewUserQuerySet(db).CountByFieldWithRollup(test.UserDBSchema.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.RollupEntry{
+		{Value: "a", Count: 2},
+		{Value: "b", Count: 1},
+		{Value: nil, Count: 3, Total: true},
+	}, counts)
+}
+
+func testPostgresProductHistogramField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := `SELECT FLOOR(stock / CAST($1 AS DOUBLE PRECISION)) AS bucket, count(*) FROM "products" ` +
+		`WHERE (stock IS NOT NULL) GROUP BY bucket ORDER BY "bucket"`
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(2.5).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+			AddRow(0, 3).
+			AddRow(4, 1))
+
+	buckets, err := test.NewProductQuerySet(db).HistogramField(test.ProductDBSchema.Stock, 2.5)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.Bucket{
+		{From: 0, To: 2.5, Count: 3},
+		{From: 10, To: 12.5, Count: 1},
+	}, buckets)
+}
+
+func testUserFacetField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT name, count(*) FROM `users` WHERE `users`.deleted_at IS NULL " +
+		"AND ((email LIKE ? ESCAPE ?)) GROUP BY name"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("%@mail.ru", `\`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count(*)"}).
+			AddRow([]byte("a"), 2).
+			AddRow("b", 1))
+
+	facets, err := test.NewUserQuerySet(db).EmailEndsWith("@mail.ru").FacetField(test.UserDBSchema.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.Facet{{Value: "a", Count: 2}, {Value: "b", Count: 1}}, facets)
+}
+
+func testTierFieldLtExpr(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tiers` WHERE (name = ?) AND (max_amount < (min_amount * ? + ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("silver", 2, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "min_amount", "max_amount"}).
+			AddRow(2, "silver", 100, 150))
+
+	var tiers []test.Tier
+	err := test.NewTierQuerySet(db).
+		NameEq("silver").
+		FieldLtExpr(test.TierDBSchema.MaxAmount, "min_amount * ? + ?", 2, 10).
+		All(&tiers)
+	assert.Nil(t, err)
+	assert.Len(t, tiers, 1)
+}
+
+func testTxBuilderCreate(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	userReq := "INSERT INTO `users` (`created_at`,`updated_at`,`deleted_at`,`name`,`email`) VALUES (?,?,?,?,?)"
+	tierReq := "INSERT INTO `tiers` (`name`,`min_amount`,`max_amount`) VALUES (?,?,?)"
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe(userReq)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "u", "u@mail.ru").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	m.ExpectExec(fixedFullRe(tierReq)).
+		WithArgs("gold", 500, 1000).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	m.ExpectCommit()
+
+	user := test.User{Name: "u", Email: "u@mail.ru"}
+	tier := test.Tier{Name: "gold", MinAmount: 500, MaxAmount: 1000}
+	err := base.NewTx(db).Create(&user).Create(&tier).Commit()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), user.ID)
+	assert.Equal(t, uint(3), tier.ID)
+
+	// all creations are rolled back on failure of any of them
+	insertErr := errors.New("Error 1062: Duplicate entry 'gold' for key 'name'")
+	m.ExpectBegin()
+	m.ExpectExec(fixedFullRe(userReq)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "u", "u@mail.ru").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	m.ExpectExec(fixedFullRe(tierReq)).
+		WithArgs("gold", 500, 1000).
+		WillReturnError(insertErr)
+	m.ExpectRollback()
+
+	err = base.NewTx(db).
+		Create(&test.User{Name: "u", Email: "u@mail.ru"}).
+		Create(&test.Tier{Name: "gold", MinAmount: 500, MaxAmount: 1000}).
+		Commit()
+	assert.True(t, base.IsDuplicateKey(err))
+}
+
+func testUserCreatedAtCurrentPeriod(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	prevNowFunc := gorm.NowFunc
+	defer func() { gorm.NowFunc = prevNowFunc }()
+	// Friday, 6 Oct 2017 02:30 in UTC+3
+	gorm.NowFunc = func() time.Time {
+		return time.Date(2017, time.October, 5, 23, 30, 0, 0, time.UTC)
+	}
+	loc := time.FixedZone("UTC+3", 3*60*60)
+
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((created_at >= ? AND created_at < ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(time.Date(2017, time.October, 5, 21, 0, 0, 0, time.UTC),
+			time.Date(2017, time.October, 6, 21, 0, 0, 0, time.UTC)).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(time.Date(2017, time.October, 1, 21, 0, 0, 0, time.UTC),
+			time.Date(2017, time.October, 8, 21, 0, 0, 0, time.UTC)).
+		WillReturnRows(getRowsForUsers(nil))
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(time.Date(2017, time.September, 30, 21, 0, 0, 0, time.UTC),
+			time.Date(2017, time.October, 31, 21, 0, 0, 0, time.UTC)).
+		WillReturnRows(getRowsForUsers(nil))
+
+	var users []test.User
+	assert.Nil(t, test.NewUserQuerySet(db).CreatedAtToday(loc).All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).CreatedAtThisWeek(loc).All(&users))
+	assert.Nil(t, test.NewUserQuerySet(db).CreatedAtThisMonth(loc).All(&users))
+}
+
+func testTierAllRanked(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT `tiers`.*, ROW_NUMBER() OVER (ORDER BY max_amount DESC) AS `rank` FROM `tiers` " +
+		"WHERE (min_amount > ?) ORDER BY `rank` LIMIT 2"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "min_amount", "max_amount", "rank"}).
+			AddRow(3, "gold", 500, 1000, 1).
+			AddRow(2, "silver", 100, 499, 2))
+
+	tiers, err := test.NewTierQuerySet(db).MinAmountGt(0).Limit(2).AllRanked(test.TierDBSchema.MaxAmount)
+	assert.Nil(t, err)
+	assert.Equal(t, []test.RankedTier{
+		{Tier: test.Tier{ID: 3, Name: "gold", MinAmount: 500, MaxAmount: 1000}, Rank: 1},
+		{Tier: test.Tier{ID: 2, Name: "silver", MinAmount: 100, MaxAmount: 499}, Rank: 2},
+	}, tiers)
+}
+
+func testTicketWhereMap(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT * FROM `tickets` WHERE (assignee IS NULL) AND (id = ?) AND (status = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(1, "open").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "open"))
+	m.ExpectQuery(fixedFullRe("SELECT * FROM `tickets` WHERE (assignee = ?)")).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}))
+
+	qs, err := test.NewTicketQuerySet(db).WhereMap(test.TicketConditions{
+		test.TicketDBSchema.Status:   "open",
+		test.TicketDBSchema.ID:       uint(1),
+		test.TicketDBSchema.Assignee: nil,
+	})
+	assert.Nil(t, err)
+	var tickets []test.Ticket
+	assert.Nil(t, qs.All(&tickets))
+	assert.Len(t, tickets, 1)
+
+	// values of pointer fields can be values of pointed types
+	qs, err = test.NewTicketQuerySet(db).WhereMap(test.TicketConditions{
+		test.TicketDBSchema.Assignee: "bob",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, qs.All(&tickets))
+
+	_, err = test.NewTicketQuerySet(db).WhereMap(test.TicketConditions{
+		test.TicketDBSchema.Status: "open",
+		test.TicketDBSchema.ID:     "1",
+	})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `"id"`)
+	}
+	_, err = test.NewTicketQuerySet(db).WhereMap(test.TicketConditions{
+		test.TicketDBSchema.Status: nil,
+	})
+	assert.NotNil(t, err)
+	_, err = test.NewTicketQuerySet(db).WhereMap(test.TicketConditions{"unknown": 1})
+	assert.NotNil(t, err)
+}
+
+func testProductDecrementStock(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "UPDATE `products` SET `stock` = stock - ? WHERE (id = ?) AND (stock >= ?)"
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(3, 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(fixedFullRe(req)).
+		WithArgs(5, 1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	decrement := func(n int) (int64, error) {
+		return test.NewProductQuerySet(db).IDEq(1).GetUpdater().
+			DecrementStock(n).
+			WhereFieldGte(test.ProductDBSchema.Stock, n).
+			UpdateNum()
+	}
+
+	updated, err := decrement(3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	// stock isn't sufficient: nothing is updated
+	updated, err = decrement(5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), updated)
+}
+
+func testPostJoinScan(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT `posts`.*, `users`.`id` AS `user_id`, `users`.`created_at` AS `user_created_at`, " +
+		"`users`.`updated_at` AS `user_updated_at`, `users`.`deleted_at` AS `user_deleted_at`, " +
+		"`users`.`name` AS `user_name`, `users`.`email` AS `user_email` FROM `posts` " +
+		"JOIN `users` ON `users`.`id` = `posts`.`user_id` AND `users`.`deleted_at` IS NULL " +
+		"WHERE `posts`.deleted_at IS NULL AND ((title LIKE ? ESCAPE ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("%go%", `\`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "user_id", "user_name", "user_email"}).
+			AddRow(1, "go", 2, "a", "a@mail.ru").
+			AddRow(3, "golang", 4, "b", "b@mail.ru"))
+
+	type postWithAuthor struct {
+		ID        uint
+		Title     string
+		UserName  string
+		UserEmail string
+	}
+	var row postWithAuthor
+	var rows []postWithAuthor
+	err := test.NewPostQuerySet(db).TitleContains("go").JoinScan("User", &row, func() error {
+		rows = append(rows, row)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []postWithAuthor{
+		{ID: 1, Title: "go", UserName: "a", UserEmail: "a@mail.ru"},
+		{ID: 3, Title: "golang", UserName: "b", UserEmail: "b@mail.ru"},
+	}, rows)
+
+	err = test.NewPostQuerySet(db).JoinScan("Title", &row, func() error { return nil })
+	assert.NotNil(t, err)
+}
+
+func testProductHistogramField(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT FLOOR(stock / ?) AS bucket, count(*) FROM `products` WHERE (name LIKE ? ESCAPE ?) AND " +
+		"(stock IS NOT NULL) GROUP BY bucket ORDER BY `bucket`"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(10.0, "%phone%", `\`).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count(*)"}).
+			AddRow(0, 4).
+			AddRow(2, 1))
+
+	buckets, err := test.NewProductQuerySet(db).NameContains("phone").
+		HistogramField(test.ProductDBSchema.Stock, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []base.Bucket{
+		{From: 0, To: 10, Count: 4},
+		{From: 20, To: 30, Count: 1},
+	}, buckets)
+
+	_, err = test.NewProductQuerySet(db).HistogramField(test.ProductDBSchema.Name, 10)
+	assert.NotNil(t, err)
+	_, err = test.NewProductQuerySet(db).HistogramField(test.ProductDBSchema.Stock, 0)
+	assert.NotNil(t, err)
+}
+
+func testTicketFilterFromStruct(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	type ticketSearch struct {
+		Status   string
+		Owner    string `gorm:"column:assignee"`
+		Page     int    `queryset:"-"`
+		Priority int
+	}
+
+	req := "SELECT * FROM `tickets` WHERE (assignee = ?) AND (status = ?)"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("bob", "open").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "open"))
+
+	// zero fields (Priority) aren't used as conditions
+	qs, err := test.NewTicketQuerySet(db).FilterFromStruct(&ticketSearch{
+		Status: "open",
+		Owner:  "bob",
+		Page:   2,
+	})
+	assert.Nil(t, err)
+	var tickets []test.Ticket
+	assert.Nil(t, qs.All(&tickets))
+	assert.Len(t, tickets, 1)
+
+	_, err = test.NewTicketQuerySet(db).FilterFromStruct(ticketSearch{Priority: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "priority")
+	}
+	_, err = test.NewTicketQuerySet(db).FilterFromStruct("open")
+	assert.NotNil(t, err)
+}
+
+func testUserNextPrevBy(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	current := getUser()
+	current.ID = 5
+	current.Name = "bob"
+
+	req := "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND (((name, id) > (?, ?))) " +
+		"ORDER BY name ASC,id ASC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("bob", current.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "bob"))
+	req = "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND (((name, id) < (?, ?))) " +
+		"ORDER BY name DESC,id DESC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs("bob", current.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "alice"))
+	req = "SELECT * FROM `users` WHERE `users`.deleted_at IS NULL AND ((id < ?)) " +
+		"ORDER BY id DESC LIMIT 1"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(current.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var next, prev test.User
+	assert.Nil(t, test.NewUserQuerySet(db).NextBy(test.UserDBSchema.Name, &current, &next))
+	assert.Equal(t, uint(7), next.ID)
+	assert.Nil(t, test.NewUserQuerySet(db).PrevBy(test.UserDBSchema.Name, &current, &prev))
+	assert.Equal(t, "alice", prev.Name)
+
+	err := test.NewUserQuerySet(db).PrevBy(test.UserDBSchema.ID, &current, &prev)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func testPostValidateReferences(t *testing.T, m sqlmock.Sqlmock, db *gorm.DB) {
+	req := "SELECT count(*) FROM `users` WHERE `users`.deleted_at IS NULL AND ((id = ?))"
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	m.ExpectQuery(fixedFullRe(req)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+
+	p := test.Post{UserID: 5, Title: "title"}
+	err := p.ValidateReferences(db)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid reference User")
+		assert.Contains(t, err.Error(), "no User with id = 5")
+	}
+
+	p.UserID = 7
+	assert.Nil(t, p.ValidateReferences(db))
+
+	// not set references aren't checked
+	p.UserID = 0
+	assert.Nil(t, p.ValidateReferences(db))
 }
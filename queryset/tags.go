@@ -0,0 +1,372 @@
+package queryset
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jirfag/go-queryset/parser"
+)
+
+// gormTagSettings parses sql and gorm tags like GORM does: keys are uppercased
+func gormTagSettings(tag reflect.StructTag) map[string]string {
+	return parseTagSettings(tag.Get("sql"), tag.Get("gorm"))
+}
+
+// querySetTagSettings parses queryset tag: it has the same syntax as gorm tag,
+// e.g. `queryset:"bitmask"`
+func querySetTagSettings(tag reflect.StructTag) map[string]string {
+	return parseTagSettings(tag.Get("queryset"))
+}
+
+func parseTagSettings(tagValues ...string) map[string]string {
+	settings := map[string]string{}
+	for _, str := range tagValues {
+		for _, value := range strings.Split(str, ";") {
+			v := strings.Split(value, ":")
+			k := strings.TrimSpace(strings.ToUpper(v[0]))
+			if k == "" {
+				continue
+			}
+
+			if len(v) >= 2 {
+				settings[k] = strings.Join(v[1:], ":")
+			} else {
+				settings[k] = k
+			}
+		}
+	}
+	return settings
+}
+
+func hasGormTagSetting(tag reflect.StructTag, name string) bool {
+	_, ok := gormTagSettings(tag)[name]
+	return ok
+}
+
+func hasQuerySetTagSetting(tag reflect.StructTag, name string) bool {
+	_, ok := querySetTagSettings(tag)[name]
+	return ok
+}
+
+// isExcludedField returns true for fields ignored by GORM by `gorm:"-"` and
+// for fields excluded from query sets by `queryset:"-"`, e.g. sensitive ones:
+// no methods are generated for them
+func isExcludedField(tag reflect.StructTag) bool {
+	return hasGormTagSetting(tag, "-") || hasQuerySetTagSetting(tag, "-")
+}
+
+// isReadOnlyField returns true for fields with readonly setting of queryset
+// tag: they can be filtered and ordered by, but updater can't set them
+func (fi FieldInfo) isReadOnlyField() bool {
+	return hasQuerySetTagSetting(fi.Tag, "READONLY")
+}
+
+// getPrimaryKeyFields returns fields of primary key detected like GORM does:
+// fields tagged by gorm:"primary_key" (many for composite key) or ID field
+func getPrimaryKeyFields(fields []FieldInfo) []FieldInfo {
+	ret := []FieldInfo{}
+	for _, f := range fields {
+		if hasGormTagSetting(f.Tag, "PRIMARY_KEY") {
+			ret = append(ret, f)
+		}
+	}
+	if len(ret) != 0 {
+		return ret
+	}
+
+	for _, f := range fields {
+		if gorm.ToDBName(f.Name) == "id" {
+			return []FieldInfo{f}
+		}
+	}
+	return nil
+}
+
+// isPrimaryKeyField returns true for field f of fields of primary key
+func isPrimaryKeyField(f FieldInfo, fields []FieldInfo) bool {
+	for _, pk := range getPrimaryKeyFields(fields) {
+		if pk.Name == f.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isBitmaskField returns true for numeric fields with bitmask setting of queryset tag
+func (fi FieldInfo) isBitmaskField() bool {
+	return fi.IsNumeric && !fi.IsTime && hasQuerySetTagSetting(fi.Tag, "BITMASK")
+}
+
+// isJSONArrayField returns true for string fields with JSON array
+// stored and jsonArray setting of queryset tag
+func (fi FieldInfo) isJSONArrayField() bool {
+	return fi.IsString && !fi.IsPointer && hasQuerySetTagSetting(fi.Tag, "JSONARRAY")
+}
+
+// getEnumMembers returns members of enum of string field listed by enum
+// setting of queryset tag, e.g. `queryset:"enum:new,open,closed"`
+func (fi FieldInfo) getEnumMembers() []string {
+	if fi.IsPointer {
+		return fi.GetPointed().getEnumMembers()
+	}
+
+	members := querySetTagSettings(fi.Tag)["ENUM"]
+	if !fi.IsString || members == "" || members == "ENUM" {
+		return nil
+	}
+
+	ret := []string{}
+	for _, m := range strings.Split(members, ",") {
+		ret = append(ret, strings.TrimSpace(m))
+	}
+	return ret
+}
+
+// getEnumFields returns string fields with enum setting of queryset tag
+func getEnumFields(fields []FieldInfo) []FieldInfo {
+	ret := []FieldInfo{}
+	for _, f := range fields {
+		if f.getEnumMembers() != nil {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// getRangeFields returns start and end fields of range tagged by rangeStart
+// and rangeEnd settings of queryset tag: both are nil if there is no range
+func getRangeFields(fields []FieldInfo) (start, end *FieldInfo, err error) {
+	for i, f := range fields {
+		settings := querySetTagSettings(f.Tag)
+		if _, ok := settings["RANGESTART"]; ok {
+			if start != nil {
+				return nil, nil, fmt.Errorf("more than one rangeStart field: %s and %s", start.Name, f.Name)
+			}
+			start = &fields[i]
+		}
+		if _, ok := settings["RANGEEND"]; ok {
+			if end != nil {
+				return nil, nil, fmt.Errorf("more than one rangeEnd field: %s and %s", end.Name, f.Name)
+			}
+			end = &fields[i]
+		}
+	}
+
+	if (start == nil) != (end == nil) {
+		return nil, nil, errors.New("rangeStart and rangeEnd fields must be paired")
+	}
+	if start != nil && (!start.IsNumeric || start.TypeName != end.TypeName) {
+		return nil, nil, fmt.Errorf("range fields %s and %s must be numeric fields of the same type",
+			start.Name, end.Name)
+	}
+	return start, end, nil
+}
+
+// getPointFields returns latitude and longitude fields of point tagged by lat
+// and lng settings of queryset tag: both are nil if there is no point
+func getPointFields(fields []FieldInfo) (lat, lng *FieldInfo, err error) {
+	for i, f := range fields {
+		settings := querySetTagSettings(f.Tag)
+		if _, ok := settings["LAT"]; ok {
+			if lat != nil {
+				return nil, nil, fmt.Errorf("more than one lat field: %s and %s", lat.Name, f.Name)
+			}
+			lat = &fields[i]
+		}
+		if _, ok := settings["LNG"]; ok {
+			if lng != nil {
+				return nil, nil, fmt.Errorf("more than one lng field: %s and %s", lng.Name, f.Name)
+			}
+			lng = &fields[i]
+		}
+	}
+
+	if (lat == nil) != (lng == nil) {
+		return nil, nil, errors.New("lat and lng fields must be paired")
+	}
+	if lat != nil && (!isFloatField(*lat) || !isFloatField(*lng)) {
+		return nil, nil, fmt.Errorf("point fields %s and %s must be float fields", lat.Name, lng.Name)
+	}
+	return lat, lng, nil
+}
+
+func isFloatField(f FieldInfo) bool {
+	return !f.IsPointer && (f.TypeName == "float64" || f.TypeName == "float32")
+}
+
+// getDeleteAuditFields returns fields tagged by deletedBy and deleteReason
+// settings of queryset tag: both are nil if there are no such fields
+func getDeleteAuditFields(fields []FieldInfo) (by, reason *FieldInfo, err error) {
+	for i, f := range fields {
+		settings := querySetTagSettings(f.Tag)
+		if _, ok := settings["DELETEDBY"]; ok {
+			if by != nil {
+				return nil, nil, fmt.Errorf("more than one deletedBy field: %s and %s", by.Name, f.Name)
+			}
+			by = &fields[i]
+		}
+		if _, ok := settings["DELETEREASON"]; ok {
+			if reason != nil {
+				return nil, nil, fmt.Errorf("more than one deleteReason field: %s and %s", reason.Name, f.Name)
+			}
+			reason = &fields[i]
+		}
+	}
+
+	if by == nil && reason == nil {
+		return nil, nil, nil
+	}
+	if by == nil || reason == nil {
+		return nil, nil, errors.New("deletedBy and deleteReason fields must be paired")
+	}
+	if !by.IsString || !reason.IsString || by.IsPointer || reason.IsPointer {
+		return nil, nil, fmt.Errorf("audit fields %s and %s must be string fields", by.Name, reason.Name)
+	}
+	if !isSoftDeleteStruct(fields) {
+		return nil, nil, errors.New("audit fields are set on soft delete: deleted_at field is required")
+	}
+	return by, reason, nil
+}
+
+// getClaimFields returns fields tagged by claimedBy and claimedAt settings
+// of queryset tag, e.g. of queue of jobs: both are nil if there are no such
+// fields. Claimed records are updated by primary key: it must be single field.
+func getClaimFields(fields []FieldInfo) (by, at *FieldInfo, err error) {
+	for i, f := range fields {
+		settings := querySetTagSettings(f.Tag)
+		if _, ok := settings["CLAIMEDBY"]; ok {
+			if by != nil {
+				return nil, nil, fmt.Errorf("more than one claimedBy field: %s and %s", by.Name, f.Name)
+			}
+			by = &fields[i]
+		}
+		if _, ok := settings["CLAIMEDAT"]; ok {
+			if at != nil {
+				return nil, nil, fmt.Errorf("more than one claimedAt field: %s and %s", at.Name, f.Name)
+			}
+			at = &fields[i]
+		}
+	}
+
+	if by == nil && at == nil {
+		return nil, nil, nil
+	}
+	if by == nil || at == nil {
+		return nil, nil, errors.New("claimedBy and claimedAt fields must be paired")
+	}
+	if !by.IsString || by.IsPointer || !at.IsPointer || !at.GetPointed().IsTime {
+		return nil, nil, fmt.Errorf("claim fields %s and %s must be string and *time.Time fields",
+			by.Name, at.Name)
+	}
+	if len(getPrimaryKeyFields(fields)) != 1 {
+		return nil, nil, errors.New("claimed records are updated by primary key: it must be single field")
+	}
+	return by, at, nil
+}
+
+// getScheduleFields returns fields tagged by priority and readyAt settings
+// of queryset tag: both are nil if there are no such fields
+func getScheduleFields(fields []FieldInfo) (priority, readyAt *FieldInfo, err error) {
+	for i, f := range fields {
+		settings := querySetTagSettings(f.Tag)
+		if _, ok := settings["PRIORITY"]; ok {
+			if priority != nil {
+				return nil, nil, fmt.Errorf("more than one priority field: %s and %s", priority.Name, f.Name)
+			}
+			priority = &fields[i]
+		}
+		if _, ok := settings["READYAT"]; ok {
+			if readyAt != nil {
+				return nil, nil, fmt.Errorf("more than one readyAt field: %s and %s", readyAt.Name, f.Name)
+			}
+			readyAt = &fields[i]
+		}
+	}
+
+	if priority == nil && readyAt == nil {
+		return nil, nil, nil
+	}
+	if priority == nil || readyAt == nil {
+		return nil, nil, errors.New("priority and readyAt fields must be paired")
+	}
+	if !priority.IsNumeric || priority.IsTime || priority.IsPointer || !readyAt.IsTime || readyAt.IsPointer {
+		return nil, nil, fmt.Errorf("schedule fields %s and %s must be numeric and time.Time fields",
+			priority.Name, readyAt.Name)
+	}
+	return priority, readyAt, nil
+}
+
+// getAPIName returns name of field in API set by api setting of queryset tag,
+// e.g. `queryset:"api:createdAt"`, or empty string if it isn't set
+func (fi FieldInfo) getAPIName() string {
+	name := querySetTagSettings(fi.Tag)["API"]
+	if name == "API" {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+var timeTypePrecisionRe = regexp.MustCompile(`(?i)^(datetime|timestamp)\((\d)\)`)
+
+// timePrecisionDurations are Go expressions of durations of time
+// precisions: digits of fractional part of seconds
+var timePrecisionDurations = []string{
+	"time.Second",
+	"100 * time.Millisecond",
+	"10 * time.Millisecond",
+	"time.Millisecond",
+	"100 * time.Microsecond",
+	"10 * time.Microsecond",
+	"time.Microsecond",
+}
+
+// getTruncateTimePrecision returns Go expression of duration to truncate values
+// of time field tagged by `queryset:"truncateTime"` to precision of column
+// declared by gorm tag, e.g. `gorm:"type:datetime(3)"`: second precision
+// is used for columns without declared precision. Empty string is returned
+// for other fields.
+func (fi FieldInfo) getTruncateTimePrecision() string {
+	if !fi.IsTime || !hasQuerySetTagSetting(fi.Tag, "TRUNCATETIME") {
+		return ""
+	}
+
+	m := timeTypePrecisionRe.FindStringSubmatch(gormTagSettings(fi.Tag)["TYPE"])
+	if m == nil {
+		return timePrecisionDurations[0]
+	}
+
+	digits, _ := strconv.Atoi(m[2])
+	if digits >= len(timePrecisionDurations) {
+		return "" // nanoseconds are stored as is
+	}
+	return timePrecisionDurations[digits]
+}
+
+// isAutoCreateTimeField returns true for time fields which must be set on creation:
+// fields with GORM v2 autoCreateTime or autoUpdateTime tags
+func (fi FieldInfo) isAutoCreateTimeField() bool {
+	return fi.IsTime && !fi.IsPointer &&
+		(hasGormTagSetting(fi.Tag, "AUTOCREATETIME") || fi.isAutoUpdateTimeField())
+}
+
+// isAutoUpdateTimeField returns true for time fields with autoUpdateTime tag
+func (fi FieldInfo) isAutoUpdateTimeField() bool {
+	return fi.IsTime && !fi.IsPointer && hasGormTagSetting(fi.Tag, "AUTOUPDATETIME")
+}
+
+// getAutoUpdateTimeFieldNames returns names of fields with autoUpdateTime tag
+func getAutoUpdateTimeFieldNames(fields []parser.StructField) []string {
+	ret := []string{}
+	for _, f := range fields {
+		isTime := f.Type.String() == "time.Time"
+		if isTime && hasGormTagSetting(f.Tag, "AUTOUPDATETIME") {
+			ret = append(ret, f.Name)
+		}
+	}
+	return ret
+}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
 	"github.com/jirfag/go-queryset/queryset/tmp"
 )
@@ -12,7 +14,7 @@ import (
 type User struct {
 	gorm.Model
 
-	//Posts []Post
+	Posts []Post
 	Name  string
 	Email string
 }
@@ -22,7 +24,8 @@ type User struct {
 type Blog struct {
 	gorm.Model
 
-	Name string
+	Name        string
+	RefreshedAt time.Time `gorm:"autoUpdateTime"`
 }
 
 // Post is an article
@@ -30,10 +33,147 @@ type Blog struct {
 type Post struct {
 	gorm.Model
 
-	Blog  *Blog // may be no blog
-	User  User
+	Blog   *Blog // may be no blog
+	User   User
+	UserID uint
+	Title  string
+	Str    tmp.StringDef
+}
+
+// Event is an event: its queries are annotated with SQL comments
+// gen:qs sqlComments
+type Event struct {
+	gorm.Model
 	Title string
-	Str   tmp.StringDef
+}
+
+// Note is a note: errors of its query set methods are wrapped
+// gen:qs wrapErrors
+type Note struct {
+	ID   uint
+	Text string
+}
+
+// UserStat is a row of read-only view
+// gen:qs readOnly
+type UserStat struct {
+	UserID     uint
+	PostsCount int
+	Flags      uint `queryset:"bitmask"`
+}
+
+// Account is deleted by resetting IsActive
+// gen:qs activeFlag:is_active
+type Account struct {
+	ID       uint
+	Name     string
+	IsActive bool
+}
+
+// Ticket has status stored in ENUM column
+// gen:qs
+type Ticket struct {
+	ID       uint
+	Status   string `gorm:"type:ENUM('new','open','closed')" queryset:"enum:new,open,closed"`
+	Assignee *string
+	Tags     string `gorm:"type:json" queryset:"jsonArray"`
+}
+
+// Job is a task of workers queue
+// gen:qs
+type Job struct {
+	ID        uint       `json:"id"`
+	Payload   string     `json:"payload"`
+	ClaimedBy string     `queryset:"claimedBy"`
+	ClaimedAt *time.Time `queryset:"claimedAt"`
+	Priority  int        `queryset:"priority"`
+	ReadyAt   time.Time  `queryset:"readyAt"`
+}
+
+// Lease is a resource claimed by holders: claim fields are found by tags
+// gen:qs
+type Lease struct {
+	Code     string     `gorm:"primary_key"`
+	Holder   string     `queryset:"claimedBy"`
+	LockedAt *time.Time `queryset:"claimedAt"`
+}
+
+// Booking reserves something for [StartAt, EndAt)
+// gen:qs
+type Booking struct {
+	ID      uint
+	StartAt time.Time `gorm:"type:datetime(0)" queryset:"rangeStart;truncateTime;api:start"`
+	EndAt   time.Time `gorm:"type:datetime(3)" queryset:"rangeEnd;truncateTime;api:end"`
+}
+
+// Document belongs to tenant: its query sets are constructed for tenant
+// gen:qs tenantColumn:tenant_id
+type Document struct {
+	ID       uint
+	TenantID uint
+	Title    string
+}
+
+// Invoice keeps actor and reason of its soft deletion
+// gen:qs
+type Invoice struct {
+	gorm.Model
+
+	Number       string
+	DeletedBy    string `queryset:"deletedBy"`
+	DeleteReason string `queryset:"deleteReason"`
+}
+
+// Place is a point on map
+// gen:qs
+type Place struct {
+	ID   uint
+	Name string
+	Lat  float64 `queryset:"lat"`
+	Lng  float64 `queryset:"lng"`
+}
+
+// Tier is a pricing tier for amounts in [MinAmount, MaxAmount]
+// gen:qs
+type Tier struct {
+	ID        uint
+	Name      string
+	MinAmount int64
+	MaxAmount int64
+}
+
+// Credential has fields hidden from query sets
+// gen:qs
+type Credential struct {
+	ID           uint
+	Email        string
+	PasswordHash string `queryset:"-"`
+	Strength     int    `gorm:"-"`
+	LoginCount   int    `queryset:"readonly"`
+}
+
+// Session is a legacy table with UUID primary key
+// gen:qs
+type Session struct {
+	UUID   string `gorm:"primary_key"`
+	UserID uint
+	Token  string
+}
+
+// Membership has composite primary key
+// gen:qs
+type Membership struct {
+	GroupID uint `gorm:"primary_key"`
+	UserID  uint `gorm:"primary_key"`
+	Role    string
+}
+
+// Product is a product in stock
+// gen:qs
+type Product struct {
+	ID    uint
+	Name  string
+	Stock int
 }
 
 // String is just for testing purposes